@@ -0,0 +1,193 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Arg is an OSC argument together with its exact type tag, so that, for
+// example, a symbol is not sent as a string nor an impulse as nil. The value
+// has the Go type decoded by ParseMessage for the tag. The zero Arg is
+// invalid; use the constructors or NewArg.
+type Arg struct {
+	tag   byte
+	value interface{}
+}
+
+// Int32 returns an i argument.
+func Int32(v int32) Arg {
+	return Arg{'i', v}
+}
+
+// Int64 returns an h argument.
+func Int64(v int64) Arg {
+	return Arg{'h', v}
+}
+
+// Float32 returns an f argument.
+func Float32(v float32) Arg {
+	return Arg{'f', v}
+}
+
+// Float64 returns a d argument.
+func Float64(v float64) Arg {
+	return Arg{'d', v}
+}
+
+// String returns an s argument. The string must not contain a zero byte.
+func String(v string) Arg {
+	return Arg{'s', v}
+}
+
+// Symbol returns an S argument, the alternate string type of OSC 1.0.
+func Symbol(v string) Arg {
+	return Arg{'S', v}
+}
+
+// Blob returns a b argument.
+func Blob(v []byte) Arg {
+	return Arg{'b', v}
+}
+
+// Time returns a t argument.
+func Time(v TimeTag) Arg {
+	return Arg{'t', v}
+}
+
+// Char returns a c argument, an ASCII character sent as 32 bits.
+func Char(v rune) Arg {
+	return Arg{'c', v}
+}
+
+// Color returns an r argument.
+func Color(v RGBA) Arg {
+	return Arg{'r', v}
+}
+
+// MIDIMessage returns an m argument.
+func MIDIMessage(v MIDI) Arg {
+	return Arg{'m', v}
+}
+
+// Bool returns a T argument if v is true and an F argument otherwise.
+func Bool(v bool) Arg {
+	if v {
+		return Arg{'T', true}
+	}
+	return Arg{'F', false}
+}
+
+// Nil returns an N argument.
+func Nil() Arg {
+	return Arg{'N', nil}
+}
+
+// Impulse returns an I argument, also known as Infinitum or Bang.
+func Impulse() Arg {
+	return Arg{'I', nil}
+}
+
+// NewArg returns the argument for the type tag, converting v as
+// Msg.MarshalBinary does, e.g. any Go integer for i. Array brackets are not
+// arguments.
+func NewArg(tag byte, v interface{}) (Arg, error) {
+	b, err := appendArg(nil, tag, v)
+	if err != nil {
+		return Arg{}, err
+	}
+	value, _, err := readArg(tag, b)
+	if err != nil {
+		return Arg{}, err
+	}
+	return Arg{tag, value}, nil
+}
+
+// Tag returns the type tag of the argument.
+func (a Arg) Tag() byte {
+	return a.tag
+}
+
+// Value returns the value of the argument, with the Go type decoded by
+// ParseMessage for the tag.
+func (a Arg) Value() interface{} {
+	return a.value
+}
+
+// constructors names the constructor of the arguments of each type tag.
+var constructors = map[byte]string{
+	'i': "Int32",
+	'h': "Int64",
+	'f': "Float32",
+	'd': "Float64",
+	's': "String",
+	'S': "Symbol",
+	'b': "Blob",
+	't': "Time",
+	'c': "Char",
+	'r': "Color",
+	'm': "MIDIMessage",
+	'T': "Bool",
+	'F': "Bool",
+	'N': "Nil",
+	'I': "Impulse",
+}
+
+// String returns the argument as a call to its constructor, with the value
+// in the text syntax of ParseText, e.g. Symbol("kick").
+func (a Arg) String() string {
+	name, ok := constructors[a.tag]
+	switch {
+	case !ok:
+		return "invalid"
+	case a.tag == 'N' || a.tag == 'I':
+		return name + "()"
+	case a.tag == 'T' || a.tag == 'F':
+		return fmt.Sprintf("%s(%t)", name, a.tag == 'T')
+	}
+	return fmt.Sprintf("%s(%s)", name, formatArg(a.tag, a.value))
+}
+
+// NewMessage returns the message to the address with the arguments, its type
+// tag being the tags of the arguments. An error is returned if an argument is
+// the zero Arg.
+func NewMessage(addr string, args ...Arg) (*Msg, error) {
+	m := &Msg{Address: addr}
+	var tags strings.Builder
+	for i, a := range args {
+		if a.tag == 0 {
+			return nil, fmt.Errorf("argument %d has no type tag", i)
+		}
+		tags.WriteByte(a.tag)
+		m.Args = append(m.Args, a.value)
+	}
+	m.TypeTag = tags.String()
+	return m, nil
+}
+
+// Arguments returns the arguments of the message with their type tags, one
+// per tag of the type tag that is not an array bracket. Arguments that do
+// not match their type tag are converted as by NewArg; if that fails an error
+// is returned.
+func (m *Msg) Arguments() ([]Arg, error) {
+	if n := numArgs(m.TypeTag); n != len(m.Args) {
+		return nil, fmt.Errorf("%d arguments for type tag %q", len(m.Args), m.TypeTag)
+	}
+	args := make([]Arg, 0, len(m.Args))
+	for i := 0; i < len(m.TypeTag); i++ {
+		tag := m.TypeTag[i]
+		if tag == '[' || tag == ']' {
+			continue
+		}
+		a, err := NewArg(tag, m.Args[len(args)])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %s", len(args), err)
+		}
+		args = append(args, a)
+	}
+	return args, nil
+}
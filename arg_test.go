@@ -0,0 +1,109 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewMessage(t *testing.T) {
+	args := []Arg{
+		Int32(1), Int64(1), Float32(0.5), Float64(0.5), String("kick"), Symbol("kick"),
+		Blob([]byte{1}), Time(Immediately), Char('a'), Color(0x102030ff),
+		MIDIMessage(MIDI{0, 0x90, 60, 127}), Bool(true), Bool(false), Nil(), Impulse(),
+	}
+	m, err := NewMessage("/all", args...)
+	if err != nil {
+		t.Fatalf("error creating message: %s", err)
+	}
+	if got, want := m.TypeTag, "ihfdsSbtcrmTFNI"; got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error encoding: %s", err)
+	}
+	if _, err := NewMessage("/zero", Int32(1), Arg{}); err == nil {
+		t.Error("expected error for the zero Arg")
+	}
+	// The decoded arguments keep their exact types.
+	decoded, err := ParseMessage(b)
+	if err != nil {
+		t.Fatalf("error decoding: %s", err)
+	}
+	got, err := decoded.Arguments()
+	if err != nil {
+		t.Fatalf("error getting arguments: %s", err)
+	}
+	if !reflect.DeepEqual(got, args) {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", got, args)
+	}
+}
+
+func TestArgString(t *testing.T) {
+	var tests = []struct {
+		arg  Arg
+		want string
+	}{
+		{Int32(-1), "Int32(-1)"},
+		{Float32(0.1), "Float32(0.1)"},
+		{Symbol("kick"), `Symbol("kick")`},
+		{Blob([]byte{0xc0, 0xff}), "Blob(0xc0ff)"},
+		{Char('a'), "Char('a')"},
+		{Bool(false), "Bool(false)"},
+		{Impulse(), "Impulse()"},
+		{Arg{}, "invalid"},
+	}
+	for _, test := range tests {
+		if got := test.arg.String(); got != test.want {
+			t.Errorf("\t got = %s\n\t\t\twant = %s", got, test.want)
+		}
+	}
+}
+
+func TestNewArg(t *testing.T) {
+	var tests = []struct {
+		tag   byte
+		value interface{}
+		want  Arg
+		err   string
+	}{
+		{'i', 7, Int32(7), ""},
+		{'h', int8(-7), Int64(-7), ""},
+		{'d', float32(0.5), Float64(0.5), ""},
+		{'c', 'x', Char('x'), ""},
+		{'T', nil, Bool(true), ""},
+		{'I', nil, Impulse(), ""},
+		{'i', "7", Arg{}, "cannot encode string 7 as i"},
+		{'[', nil, Arg{}, "unknown type tag ["},
+	}
+	for _, test := range tests {
+		got, err := NewArg(test.tag, test.value)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%c %v: error\n\t got = %s\n\t\t\twant = %s", test.tag, test.value, err, test.err)
+			}
+			continue
+		}
+		if got != test.want {
+			t.Errorf("\t got = %v\n\t\t\twant = %v", got, test.want)
+		}
+	}
+
+	m := &Msg{Address: "/a", TypeTag: "i[f]", Args: []interface{}{1, 0.5}}
+	args, err := m.Arguments()
+	if err != nil {
+		t.Fatalf("error getting arguments: %s", err)
+	}
+	if want := []Arg{Int32(1), Float32(0.5)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", args, want)
+	}
+	m.Args = m.Args[:1]
+	if _, err := m.Arguments(); err == nil || err.Error() != `1 arguments for type tag "i[f]"` {
+		t.Errorf("got error %v", err)
+	}
+}
@@ -0,0 +1,335 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"fmt"
+)
+
+// ChannelStrip models the processing of an input channel, from the preamp to
+// the insert. Levels are in dB, frequencies in Hz and times in ms. Values
+// outside of the range of a parameter are clamped, and all values are rounded
+// to the resolution of the mixer.
+type ChannelStrip struct {
+	Preamp   Preamp
+	Delay    Delay
+	Gate     Gate
+	Dynamics Dynamics
+	EQ       EQ
+	Insert   Insert
+}
+
+// Preamp models the preamp of a channel.
+type Preamp struct {
+	// Gain, from -12 to +60 dB, and Phantom are the settings of the headamp
+	// of the channel's source. They are left unchanged if the source has no
+	// headamp.
+	Gain    float64
+	Phantom bool
+
+	// Trim, from -18 to +18 dB, applies to digital sources.
+	Trim   float64
+	Invert bool
+
+	// LowCut enables the low cut filter, with a slope of 12, 18 or 24 dB per
+	// octave and a frequency from 20 to 400 Hz.
+	LowCut          bool
+	LowCutSlope     float64
+	LowCutFrequency float64
+}
+
+// Delay models the delay of a channel, from 0.3 to 500 ms.
+type Delay struct {
+	On   bool
+	Time float64
+}
+
+// GateMode provides an enumeration for the modes of a gate.
+type GateMode int
+
+// Enum for the gate modes.
+const (
+	Expander2 GateMode = iota
+	Expander3
+	Expander4
+	NoiseGate
+	Ducker
+)
+
+// String implements the Stringer interface for GateMode.
+func (mode GateMode) String() string {
+	return gateModeDescription[mode]
+}
+
+var gateModeDescription = map[GateMode]string{
+	Expander2: "EXP2",
+	Expander3: "EXP3",
+	Expander4: "EXP4",
+	NoiseGate: "GATE",
+	Ducker:    "DUCK",
+}
+
+// Gate models the gate of a channel.
+type Gate struct {
+	On   bool
+	Mode GateMode
+
+	// Threshold is from -80 to 0 dB and Range from 3 to 60 dB.
+	Threshold float64
+	Range     float64
+
+	// Attack is from 0 to 120 ms, Hold from 0.02 to 2000 ms and Release from
+	// 5 to 4000 ms.
+	Attack  float64
+	Hold    float64
+	Release float64
+
+	// KeySource selects the key signal: 0 for the channel itself, then the
+	// 32 inputs, 8 aux inputs, 8 FX returns and 16 mix buses, up to 64.
+	KeySource int
+}
+
+// DynamicsMode provides an enumeration for the modes of a compressor.
+type DynamicsMode int
+
+// Enum for the dynamics modes.
+const (
+	Compressor DynamicsMode = iota
+	Expander
+)
+
+// String implements the Stringer interface for DynamicsMode.
+func (mode DynamicsMode) String() string {
+	return dynamicsModeDescription[mode]
+}
+
+var dynamicsModeDescription = map[DynamicsMode]string{
+	Compressor: "COMP",
+	Expander:   "EXP",
+}
+
+// Ratios lists the ratios of the compressor.
+var Ratios = []float64{1.1, 1.3, 1.5, 2, 2.5, 3, 4, 5, 7, 10, 20, 100}
+
+// Dynamics models the compressor of a channel.
+type Dynamics struct {
+	On   bool
+	Mode DynamicsMode
+
+	// Threshold is from -60 to 0 dB. Ratio is set to the nearest of Ratios.
+	Threshold float64
+	Ratio     float64
+	// Knee is from 0 to 5 and Makeup from 0 to 24 dB.
+	Knee   float64
+	Makeup float64
+
+	// Attack is from 0 to 120 ms and Release from 5 to 4000 ms.
+	Attack  float64
+	Release float64
+}
+
+// EQType provides an enumeration for the types of EQ bands.
+type EQType int
+
+// Enum for the EQ band types.
+const (
+	LowCut EQType = iota
+	LowShelf
+	Parametric
+	Vintage
+	HighShelf
+	HighCut
+)
+
+// String implements the Stringer interface for EQType.
+func (t EQType) String() string {
+	return eqTypeDescription[t]
+}
+
+var eqTypeDescription = map[EQType]string{
+	LowCut:     "LCut",
+	LowShelf:   "LShv",
+	Parametric: "PEQ",
+	Vintage:    "VEQ",
+	HighShelf:  "HShv",
+	HighCut:    "HCut",
+}
+
+// EQ models the 4-band parametric EQ of a channel.
+type EQ struct {
+	On    bool
+	Bands [4]EQBand
+}
+
+// EQBand models a band of an EQ. Frequency is from 20 to 20000 Hz, Gain from
+// -15 to +15 dB and Q from 0.3 to 10.
+type EQBand struct {
+	Type      EQType
+	Frequency float64
+	Gain      float64
+	Q         float64
+}
+
+// InsertPosition provides an enumeration for the positions of an insert.
+type InsertPosition int
+
+// Enum for the insert positions.
+const (
+	PreInsert InsertPosition = iota
+	PostInsert
+)
+
+// InsertSource provides an enumeration for the FX slots and aux buses that
+// can be inserted: the left and right sides of the 8 FX slots, then the 6
+// aux buses.
+type InsertSource int
+
+// Enum for the insert sources.
+const (
+	NoInsert   InsertSource = 0
+	FX1L       InsertSource = 1
+	AuxInsert1 InsertSource = 17
+)
+
+// String implements the Stringer interface for InsertSource.
+func (src InsertSource) String() string {
+	switch {
+	case src == NoInsert:
+		return "OFF"
+	case src >= FX1L && src < AuxInsert1:
+		return fmt.Sprintf("FX%d%c", (src-FX1L)/2+1, "LR"[(src-FX1L)%2])
+	case src >= AuxInsert1 && src < AuxInsert1+6:
+		return fmt.Sprintf("AUX%d", src-AuxInsert1+1)
+	}
+	return fmt.Sprintf("InsertSource(%d)", int(src))
+}
+
+// Insert models the insert of a channel.
+type Insert struct {
+	On       bool
+	Position InsertPosition
+	Source   InsertSource
+}
+
+// params returns the parameters of the strip relative to the channel's
+// address, except for the headamp.
+func (s *ChannelStrip) params() []param {
+	params := []param{
+		linf("preamp/trim", &s.Preamp.Trim, -18, 18, 0.25),
+		onOff("preamp/invert", &s.Preamp.Invert),
+		onOff("preamp/hpon", &s.Preamp.LowCut),
+		choice("preamp/hpslope", &s.Preamp.LowCutSlope, []float64{12, 18, 24}),
+		logf("preamp/hpf", &s.Preamp.LowCutFrequency, 20, 400, 101),
+
+		onOff("delay/on", &s.Delay.On),
+		linf("delay/time", &s.Delay.Time, 0.3, 500, 0.1),
+
+		onOff("gate/on", &s.Gate.On),
+		enum("gate/mode", (*int)(&s.Gate.Mode), len(gateModeDescription)),
+		linf("gate/thr", &s.Gate.Threshold, -80, 0, 0.5),
+		linf("gate/range", &s.Gate.Range, 3, 60, 1),
+		linf("gate/attack", &s.Gate.Attack, 0, 120, 1),
+		logf("gate/hold", &s.Gate.Hold, 0.02, 2000, 101),
+		logf("gate/release", &s.Gate.Release, 5, 4000, 101),
+		intRange("gate/keysrc", &s.Gate.KeySource, 0, 64),
+
+		onOff("dyn/on", &s.Dynamics.On),
+		enum("dyn/mode", (*int)(&s.Dynamics.Mode), len(dynamicsModeDescription)),
+		linf("dyn/thr", &s.Dynamics.Threshold, -60, 0, 0.5),
+		choice("dyn/ratio", &s.Dynamics.Ratio, Ratios),
+		linf("dyn/knee", &s.Dynamics.Knee, 0, 5, 1),
+		linf("dyn/mgain", &s.Dynamics.Makeup, 0, 24, 0.5),
+		linf("dyn/attack", &s.Dynamics.Attack, 0, 120, 1),
+		logf("dyn/release", &s.Dynamics.Release, 5, 4000, 101),
+
+		onOff("eq/on", &s.EQ.On),
+
+		onOff("insert/on", &s.Insert.On),
+		enum("insert/pos", (*int)(&s.Insert.Position), 2),
+		enum("insert/sel", (*int)(&s.Insert.Source), int(AuxInsert1)+6),
+	}
+	for i := range s.EQ.Bands {
+		band := &s.EQ.Bands[i]
+		prefix := fmt.Sprintf("eq/%d/", i+1)
+		params = append(params,
+			enum(prefix+"type", (*int)(&band.Type), len(eqTypeDescription)),
+			logf(prefix+"f", &band.Frequency, 20, 20000, 201),
+			linf(prefix+"g", &band.Gain, -15, 15, 0.25),
+			logf(prefix+"q", &band.Q, 10, 0.3, 72),
+		)
+	}
+	return params
+}
+
+// headampParams returns the parameters of a headamp.
+func (p *Preamp) headampParams() []param {
+	return []param{
+		linf("gain", &p.Gain, -12, 60, 0.5),
+		onOff("phantom", &p.Phantom),
+	}
+}
+
+// channelHeadamp returns the address of the headamp of the channel's source,
+// or "" if it has none.
+func (m Mixer) channelHeadamp(ctx context.Context, ch int) (string, error) {
+	index, err := m.queryInt(ctx, fmt.Sprintf("/-ha/%02d/index", ch-1))
+	if err != nil || index < 0 {
+		return "", err
+	}
+	return fmt.Sprintf("/headamp/%03d/", index), nil
+}
+
+// SetChannelStrip sets the processing of the given channel. The context
+// bounds the query of the channel's headamp, which is made before any
+// parameter is set.
+func (m Mixer) SetChannelStrip(ctx context.Context, ch int, s *ChannelStrip) error {
+	addr, err := Channel(ch).address()
+	if err != nil {
+		return err
+	}
+	params, headampParams := s.params(), s.Preamp.headampParams()
+	// Check the parameters before querying the headamp or setting any of
+	// them.
+	for _, p := range append(params[:len(params):len(params)], headampParams...) {
+		if _, _, err := p.encode(); err != nil {
+			return err
+		}
+	}
+	headamp, err := m.channelHeadamp(ctx, ch)
+	if err != nil {
+		return err
+	}
+	if err := m.setParams(addr+"/", params); err != nil {
+		return err
+	}
+	if headamp == "" {
+		return nil
+	}
+	return m.setParams(headamp, headampParams)
+}
+
+// ChannelStrip returns the processing of the given channel.
+func (m Mixer) ChannelStrip(ctx context.Context, ch int) (*ChannelStrip, error) {
+	addr, err := Channel(ch).address()
+	if err != nil {
+		return nil, err
+	}
+	s := &ChannelStrip{}
+	if err := m.getParams(ctx, addr+"/", s.params()); err != nil {
+		return nil, err
+	}
+	headamp, err := m.channelHeadamp(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+	if headamp != "" {
+		if err := m.getParams(ctx, headamp, s.Preamp.headampParams()); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+func TestChannelStrip(t *testing.T) {
+	dev, mixer := newEchoDevice(t, "/ch/*/*/*", "/ch/*/*/*/*", "/headamp/*/*")
+	dev.ReplyFunc("/-ha/*/index", func(m *osc.Msg) []osc.Packet {
+		// Channel 2 is patched to headamp 5, channel 3 to a digital source.
+		index := int32(-1)
+		if m.Address == "/-ha/01/index" {
+			index = 5
+		}
+		return []osc.Packet{&osc.Msg{Address: m.Address, TypeTag: "i", Args: []interface{}{index}}}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	strip := &ChannelStrip{
+		Preamp: Preamp{Gain: 32.5, Phantom: true, Trim: -2.25, Invert: true, LowCut: true, LowCutSlope: 17, LowCutFrequency: 80},
+		Delay:  Delay{On: true, Time: 12.3},
+		Gate: Gate{On: true, Mode: Ducker, Threshold: -100, Range: 20, Attack: 10,
+			Hold: 50, Release: 200, KeySource: 33},
+		Dynamics: Dynamics{On: true, Mode: Compressor, Threshold: -20.5, Ratio: 3.5, Knee: 2,
+			Makeup: 6, Attack: 15, Release: 150},
+		EQ: EQ{On: true, Bands: [4]EQBand{
+			{LowShelf, 100, 3, 2},
+			{Parametric, 1000, -4.75, 1.4},
+			{Vintage, 4000, 2, 0.7},
+			{HighCut, 18000, 0, 0.3},
+		}},
+		Insert: Insert{On: true, Position: PostInsert, Source: AuxInsert1 + 2},
+	}
+	if err := mixer.SetChannelStrip(ctx, 2, strip); err != nil {
+		t.Fatalf("error setting channel strip: %s", err)
+	}
+	// The headamp is queried before any parameter is set.
+	dev.ExpectMessage("/-ha/01/index")
+	dev.ExpectMessage("/ch/02/preamp/trim", 0.4375)
+	got, err := mixer.ChannelStrip(ctx, 2)
+	if err != nil {
+		t.Fatalf("error getting channel strip: %s", err)
+	}
+
+	// Exact values, clamped values and enumerations.
+	if got.Preamp.Gain != 32.5 || !got.Preamp.Phantom || got.Preamp.Trim != -2.25 || got.Preamp.LowCutSlope != 18 {
+		t.Errorf("unexpected preamp %+v", got.Preamp)
+	}
+	if got.Delay.Time != 12.3 || got.Gate.Threshold != -80 || got.Gate.Mode != Ducker || got.Gate.KeySource != 33 {
+		t.Errorf("unexpected delay %+v or gate %+v", got.Delay, got.Gate)
+	}
+	if got.Dynamics.Ratio != 3 || got.Dynamics.Threshold != -20.5 || got.Insert != strip.Insert {
+		t.Errorf("unexpected dynamics %+v or insert %+v", got.Dynamics, got.Insert)
+	}
+	// Values on logarithmic scales are rounded to their nearest step.
+	for _, test := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"low cut", got.Preamp.LowCutFrequency, 80},
+		{"gate hold", got.Gate.Hold, 50},
+		{"dynamics release", got.Dynamics.Release, 150},
+		{"EQ 2 frequency", got.EQ.Bands[1].Frequency, 1000},
+		{"EQ 3 Q", got.EQ.Bands[2].Q, 0.7},
+	} {
+		if math.Abs(test.got-test.want)/test.want > 0.05 {
+			t.Errorf("%s:\t got = %g\n\t\t\twant = %g", test.name, test.got, test.want)
+		}
+	}
+
+	// The values read back are set exactly.
+	if err := mixer.SetChannelStrip(ctx, 2, got); err != nil {
+		t.Fatalf("error setting channel strip: %s", err)
+	}
+	again, err := mixer.ChannelStrip(ctx, 2)
+	if err != nil {
+		t.Fatalf("error getting channel strip: %s", err)
+	}
+	if !reflect.DeepEqual(again, got) {
+		t.Errorf("\t got = %+v\n\t\t\twant = %+v", again, got)
+	}
+
+	// Channel 3 has no headamp.
+	if err := mixer.SetChannelStrip(ctx, 3, strip); err != nil {
+		t.Fatalf("error setting channel strip: %s", err)
+	}
+	got, err = mixer.ChannelStrip(ctx, 3)
+	if err != nil {
+		t.Fatalf("error getting channel strip: %s", err)
+	}
+	if got.Preamp.Gain != 0 || got.Preamp.Phantom {
+		t.Errorf("unexpected headamp %+v", got.Preamp)
+	}
+}
+
+func TestChannelStripErrors(t *testing.T) {
+	var b packetBuffer
+	mixer := NewMixer(&b)
+	ctx := context.Background()
+	for _, s := range []*ChannelStrip{
+		{Gate: Gate{KeySource: 65}},
+		{Gate: Gate{Mode: Ducker + 1}},
+		{Insert: Insert{Source: AuxInsert1 + 6}},
+		{EQ: EQ{Bands: [4]EQBand{{Type: -1}}}},
+	} {
+		if err := mixer.SetChannelStrip(ctx, 1, s); err == nil {
+			t.Errorf("%+v: expected error", s)
+		}
+	}
+	if b.Len() != 0 {
+		t.Errorf("unexpected packets written %q", b.String())
+	}
+	if err := mixer.SetChannelStrip(ctx, 33, &ChannelStrip{}); err == nil {
+		t.Error("expected error for channel 33")
+	}
+}
+
+func TestInsertSource(t *testing.T) {
+	for src, want := range map[InsertSource]string{
+		NoInsert:       "OFF",
+		FX1L:           "FX1L",
+		FX1L + 1:       "FX1R",
+		FX1L + 15:      "FX8R",
+		AuxInsert1:     "AUX1",
+		AuxInsert1 + 5: "AUX6",
+		AuxInsert1 + 6: "InsertSource(23)",
+	} {
+		if got := src.String(); got != want {
+			t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+		}
+	}
+}
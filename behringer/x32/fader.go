@@ -0,0 +1,107 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"math"
+)
+
+// FaderSteps is the resolution of the mixer's faders.
+const FaderSteps = 1024
+
+// QuantizeFader returns the level in dB, from -90.0 dB (off) to +10.0 dB, to
+// which the mixer sets a fader given the level. The fader getters return
+// this level after the fader is set.
+func QuantizeFader(db float64) float64 {
+	return decimalToDBLevel(float64(faderValue(db)))
+}
+
+// faderValue returns the fader value from 0.0 to 1.0 for the level in dB,
+// rounded to the nearest of the FaderSteps values of the mixer.
+func faderValue(db float64) float32 {
+	return float32(math.Round(dbLevelToDecimal(db)*(FaderSteps-1)) / (FaderSteps - 1))
+}
+
+// SetChannelFader sets the fader of the given channel to the level in dB.
+func (m Mixer) SetChannelFader(ch int, db float64) error {
+	return m.SetFader(Channel(ch), db)
+}
+
+// ChannelFader returns the level in dB of the fader of the given channel.
+func (m Mixer) ChannelFader(ctx context.Context, ch int) (float64, error) {
+	return m.Fader(ctx, Channel(ch))
+}
+
+// SetAuxInFader sets the fader of the given aux input to the level in dB.
+func (m Mixer) SetAuxInFader(aux int, db float64) error {
+	return m.SetFader(AuxIn(aux), db)
+}
+
+// AuxInFader returns the level in dB of the fader of the given aux input.
+func (m Mixer) AuxInFader(ctx context.Context, aux int) (float64, error) {
+	return m.Fader(ctx, AuxIn(aux))
+}
+
+// SetFXReturnFader sets the fader of the given FX return to the level in dB.
+func (m Mixer) SetFXReturnFader(fx int, db float64) error {
+	return m.SetFader(FXReturn(fx), db)
+}
+
+// FXReturnFader returns the level in dB of the fader of the given FX return.
+func (m Mixer) FXReturnFader(ctx context.Context, fx int) (float64, error) {
+	return m.Fader(ctx, FXReturn(fx))
+}
+
+// SetBusFader sets the fader of the given mix bus to the level in dB.
+func (m Mixer) SetBusFader(bus int, db float64) error {
+	return m.SetFader(Bus(bus), db)
+}
+
+// BusFader returns the level in dB of the fader of the given mix bus.
+func (m Mixer) BusFader(ctx context.Context, bus int) (float64, error) {
+	return m.Fader(ctx, Bus(bus))
+}
+
+// SetMatrixFader sets the fader of the given matrix to the level in dB.
+func (m Mixer) SetMatrixFader(mtx int, db float64) error {
+	return m.SetFader(Matrix(mtx), db)
+}
+
+// MatrixFader returns the level in dB of the fader of the given matrix.
+func (m Mixer) MatrixFader(ctx context.Context, mtx int) (float64, error) {
+	return m.Fader(ctx, Matrix(mtx))
+}
+
+// SetDCAFader sets the fader of the given DCA group to the level in dB.
+func (m Mixer) SetDCAFader(dca int, db float64) error {
+	return m.SetFader(DCA(dca), db)
+}
+
+// DCAFader returns the level in dB of the fader of the given DCA group.
+func (m Mixer) DCAFader(ctx context.Context, dca int) (float64, error) {
+	return m.Fader(ctx, DCA(dca))
+}
+
+// SetMainFader sets the fader of the main stereo bus to the level in dB.
+func (m Mixer) SetMainFader(db float64) error {
+	return m.SetFader(Main, db)
+}
+
+// MainFader returns the level in dB of the fader of the main stereo bus.
+func (m Mixer) MainFader(ctx context.Context) (float64, error) {
+	return m.Fader(ctx, Main)
+}
+
+// SetMonoFader sets the fader of the mono (center) bus to the level in dB.
+func (m Mixer) SetMonoFader(db float64) error {
+	return m.SetFader(Mono, db)
+}
+
+// MonoFader returns the level in dB of the fader of the mono (center) bus.
+func (m Mixer) MonoFader(ctx context.Context) (float64, error) {
+	return m.Fader(ctx, Mono)
+}
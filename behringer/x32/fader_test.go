@@ -0,0 +1,144 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+	"github.com/goaudiovideo/osc/osctest"
+)
+
+// newEchoDevice returns a fake mixer that stores the parameters set at the
+// addresses matching the patterns and replies with their values when queried.
+func newEchoDevice(t *testing.T, patterns ...string) (*osctest.Device, Mixer) {
+	dev, conn := osctest.NewDevice(t)
+	state := make(map[string]*osc.Msg)
+	echo := func(m *osc.Msg) []osc.Packet {
+		if len(m.Args) > 0 {
+			state[m.Address] = m
+			return nil
+		}
+		if v, ok := state[m.Address]; ok {
+			return []osc.Packet{v}
+		}
+		return nil
+	}
+	for _, pattern := range patterns {
+		dev.ReplyFunc(pattern, echo)
+	}
+	return dev, NewMixer(conn)
+}
+
+func TestFaders(t *testing.T) {
+	dev, mixer := newEchoDevice(t, "/*/*/mix/fader", "/dca/*/fader")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var tests = []struct {
+		addr string
+		set  func(db float64) error
+		get  func() (float64, error)
+	}{
+		{
+			"/ch/32/mix/fader",
+			func(db float64) error { return mixer.SetChannelFader(32, db) },
+			func() (float64, error) { return mixer.ChannelFader(ctx, 32) },
+		},
+		{
+			"/auxin/08/mix/fader",
+			func(db float64) error { return mixer.SetAuxInFader(8, db) },
+			func() (float64, error) { return mixer.AuxInFader(ctx, 8) },
+		},
+		{
+			"/fxrtn/01/mix/fader",
+			func(db float64) error { return mixer.SetFXReturnFader(1, db) },
+			func() (float64, error) { return mixer.FXReturnFader(ctx, 1) },
+		},
+		{
+			"/bus/16/mix/fader",
+			func(db float64) error { return mixer.SetBusFader(16, db) },
+			func() (float64, error) { return mixer.BusFader(ctx, 16) },
+		},
+		{
+			"/mtx/06/mix/fader",
+			func(db float64) error { return mixer.SetMatrixFader(6, db) },
+			func() (float64, error) { return mixer.MatrixFader(ctx, 6) },
+		},
+		{
+			"/dca/8/fader",
+			func(db float64) error { return mixer.SetDCAFader(8, db) },
+			func() (float64, error) { return mixer.DCAFader(ctx, 8) },
+		},
+		{
+			"/main/st/mix/fader",
+			func(db float64) error { return mixer.SetMainFader(db) },
+			func() (float64, error) { return mixer.MainFader(ctx) },
+		},
+		{
+			"/main/m/mix/fader",
+			func(db float64) error { return mixer.SetMonoFader(db) },
+			func() (float64, error) { return mixer.MonoFader(ctx) },
+		},
+	}
+	for _, test := range tests {
+		for _, db := range []float64{-90, -42.3, -10, 0, 3.7, 10} {
+			if err := test.set(db); err != nil {
+				t.Fatalf("%s: error setting %g dB: %s", test.addr, db, err)
+			}
+			dev.ExpectMessage(test.addr, float64(faderValue(db)))
+			got, err := test.get()
+			if err != nil {
+				t.Fatalf("%s: error getting fader: %s", test.addr, err)
+			}
+			dev.ExpectMessage(test.addr)
+			if want := QuantizeFader(db); got != want {
+				t.Errorf("%s %g dB:\t got = %g\n\t\t\twant = %g", test.addr, db, got, want)
+			}
+		}
+	}
+}
+
+func TestFaderRanges(t *testing.T) {
+	var b packetBuffer
+	mixer := NewMixer(&b)
+	for _, err := range []error{
+		mixer.SetChannelFader(0, 0),
+		mixer.SetChannelFader(33, 0),
+		mixer.SetAuxInFader(9, 0),
+		mixer.SetFXReturnFader(9, 0),
+		mixer.SetBusFader(17, 0),
+		mixer.SetMatrixFader(7, 0),
+		mixer.SetDCAFader(9, 0),
+	} {
+		if err == nil {
+			t.Error("expected out of range error")
+		}
+	}
+	if b.Len() != 0 {
+		t.Errorf("unexpected packets written %q", b.String())
+	}
+}
+
+func TestQuantizeFader(t *testing.T) {
+	for db := -90.0; db <= 10; db += 0.1 {
+		q := QuantizeFader(db)
+		if faderValue(q) != faderValue(db) {
+			t.Errorf("%g dB quantized to %g dB, which is not a fader step", db, q)
+		}
+		if math.Abs(q-db) > 0.1 && db > -60 {
+			t.Errorf("%g dB quantized too far to %g dB", db, q)
+		}
+	}
+	if q := QuantizeFader(-90); q != -90 {
+		t.Errorf("\t got = %g\n\t\t\twant = -90", q)
+	}
+	if q := QuantizeFader(10); q != 10 {
+		t.Errorf("\t got = %g\n\t\t\twant = 10", q)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"fmt"
+)
+
+// MuteGroup is a mute group, numbered from 1 to 6.
+type MuteGroup int
+
+// String implements the Stringer interface for MuteGroup.
+func (g MuteGroup) String() string { return fmt.Sprintf("mute group %d", int(g)) }
+
+func (g MuteGroup) address() (string, error) {
+	return stripAddress("/config/mute/%d", "mute group", int(g), 6)
+}
+
+// DCASet is a set of DCA groups, stored as the bitmask used by the mixer: bit
+// 0 for DCA 1 up to bit 7 for DCA 8. The zero value is the empty set.
+type DCASet uint8
+
+// NewDCASet returns the set of the given DCA groups.
+func NewDCASet(dcas ...DCA) DCASet {
+	return DCASet(0).Add(dcas...)
+}
+
+// Add returns the set with the given DCA groups added. DCA groups out of
+// range are ignored.
+func (s DCASet) Add(dcas ...DCA) DCASet {
+	for _, dca := range dcas {
+		s |= DCASet(groupBit(int(dca), 8))
+	}
+	return s
+}
+
+// Remove returns the set with the given DCA groups removed.
+func (s DCASet) Remove(dcas ...DCA) DCASet {
+	for _, dca := range dcas {
+		s &^= DCASet(groupBit(int(dca), 8))
+	}
+	return s
+}
+
+// Contains reports whether the set contains the DCA group.
+func (s DCASet) Contains(dca DCA) bool {
+	bit := DCASet(groupBit(int(dca), 8))
+	return bit != 0 && s&bit != 0
+}
+
+// DCAs returns the DCA groups of the set in order.
+func (s DCASet) DCAs() []DCA {
+	var dcas []DCA
+	for dca := DCA(1); dca <= 8; dca++ {
+		if s.Contains(dca) {
+			dcas = append(dcas, dca)
+		}
+	}
+	return dcas
+}
+
+// String implements the Stringer interface for DCASet.
+func (s DCASet) String() string {
+	return fmt.Sprint(s.DCAs())
+}
+
+// MuteGroupSet is a set of mute groups, stored as the bitmask used by the
+// mixer: bit 0 for mute group 1 up to bit 5 for mute group 6. The zero value
+// is the empty set.
+type MuteGroupSet uint8
+
+// NewMuteGroupSet returns the set of the given mute groups.
+func NewMuteGroupSet(groups ...MuteGroup) MuteGroupSet {
+	return MuteGroupSet(0).Add(groups...)
+}
+
+// Add returns the set with the given mute groups added. Mute groups out of
+// range are ignored.
+func (s MuteGroupSet) Add(groups ...MuteGroup) MuteGroupSet {
+	for _, g := range groups {
+		s |= MuteGroupSet(groupBit(int(g), 6))
+	}
+	return s
+}
+
+// Remove returns the set with the given mute groups removed.
+func (s MuteGroupSet) Remove(groups ...MuteGroup) MuteGroupSet {
+	for _, g := range groups {
+		s &^= MuteGroupSet(groupBit(int(g), 6))
+	}
+	return s
+}
+
+// Contains reports whether the set contains the mute group.
+func (s MuteGroupSet) Contains(g MuteGroup) bool {
+	bit := MuteGroupSet(groupBit(int(g), 6))
+	return bit != 0 && s&bit != 0
+}
+
+// MuteGroups returns the mute groups of the set in order.
+func (s MuteGroupSet) MuteGroups() []MuteGroup {
+	var groups []MuteGroup
+	for g := MuteGroup(1); g <= 6; g++ {
+		if s.Contains(g) {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// String implements the Stringer interface for MuteGroupSet.
+func (s MuteGroupSet) String() string {
+	return fmt.Sprint(s.MuteGroups())
+}
+
+// groupBit returns the bit of the group numbered n of count groups, or 0 if n
+// is out of range.
+func groupBit(n, count int) uint8 {
+	if n < 1 || n > count {
+		return 0
+	}
+	return 1 << uint(n-1)
+}
+
+// groupAddress returns the address of a group assignment of the strip. Only
+// the inputs and the mix buses can be assigned to groups.
+func groupAddress(s Strip, param string) (string, error) {
+	switch s.(type) {
+	case Channel, AuxIn, FXReturn, Bus:
+		addr, err := s.address()
+		return addr + "/grp/" + param, err
+	}
+	return "", fmt.Errorf("%s cannot be assigned to groups", s)
+}
+
+// SetMuteGroup mutes or unmutes the strips assigned to the mute group.
+func (m Mixer) SetMuteGroup(g MuteGroup, muted bool) error {
+	addr, err := g.address()
+	if err != nil {
+		return err
+	}
+	on := 0
+	if muted {
+		on = 1
+	}
+	return m.WriteMessage(addr, "i", on)
+}
+
+// MuteGroupMuted reports whether the mute group is on.
+func (m Mixer) MuteGroupMuted(ctx context.Context, g MuteGroup) (bool, error) {
+	addr, err := g.address()
+	if err != nil {
+		return false, err
+	}
+	on, err := m.queryInt(ctx, addr)
+	return on != 0, err
+}
+
+// SetDCAs assigns the given strip to the set of DCA groups, removing it from
+// the others.
+func (m Mixer) SetDCAs(s Strip, dcas DCASet) error {
+	addr, err := groupAddress(s, "dca")
+	if err != nil {
+		return err
+	}
+	return m.WriteMessage(addr, "i", int(dcas))
+}
+
+// DCAs returns the set of DCA groups the given strip is assigned to.
+func (m Mixer) DCAs(ctx context.Context, s Strip) (DCASet, error) {
+	addr, err := groupAddress(s, "dca")
+	if err != nil {
+		return 0, err
+	}
+	mask, err := m.queryInt(ctx, addr)
+	return DCASet(mask), err
+}
+
+// SetMuteGroups assigns the given strip to the set of mute groups, removing
+// it from the others.
+func (m Mixer) SetMuteGroups(s Strip, groups MuteGroupSet) error {
+	addr, err := groupAddress(s, "mute")
+	if err != nil {
+		return err
+	}
+	if groups>>6 != 0 {
+		return fmt.Errorf("mute group set %#x out of range", uint8(groups))
+	}
+	return m.WriteMessage(addr, "i", int(groups))
+}
+
+// MuteGroups returns the set of mute groups the given strip is assigned to.
+func (m Mixer) MuteGroups(ctx context.Context, s Strip) (MuteGroupSet, error) {
+	addr, err := groupAddress(s, "mute")
+	if err != nil {
+		return 0, err
+	}
+	mask, err := m.queryInt(ctx, addr)
+	return MuteGroupSet(mask), err
+}
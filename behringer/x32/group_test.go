@@ -0,0 +1,113 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGroups(t *testing.T) {
+	dev, mixer := newEchoDevice(t, "/*/*/grp/*", "/config/mute/*")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := mixer.SetMuteGroup(2, true); err != nil {
+		t.Fatalf("error muting group: %s", err)
+	}
+	dev.ExpectMessage("/config/mute/2", int32(1))
+	if muted, err := mixer.MuteGroupMuted(ctx, 2); err != nil || !muted {
+		t.Errorf("mute group 2 muted = %t, %v", muted, err)
+	}
+	dev.ExpectMessage("/config/mute/2")
+
+	var tests = []struct {
+		strip  Strip
+		prefix string
+		dcas   DCASet
+		groups MuteGroupSet
+	}{
+		{Channel(12), "/ch/12/grp", NewDCASet(1, 8), NewMuteGroupSet(2)},
+		{AuxIn(3), "/auxin/03/grp", NewDCASet(4), 0},
+		{FXReturn(8), "/fxrtn/08/grp", 0, NewMuteGroupSet(1, 6)},
+		{Bus(16), "/bus/16/grp", NewDCASet(2, 3), NewMuteGroupSet(3, 4, 5)},
+	}
+	for _, test := range tests {
+		if err := mixer.SetDCAs(test.strip, test.dcas); err != nil {
+			t.Fatalf("%s: error setting DCAs: %s", test.strip, err)
+		}
+		dev.ExpectMessage(test.prefix+"/dca", int32(test.dcas))
+		if err := mixer.SetMuteGroups(test.strip, test.groups); err != nil {
+			t.Fatalf("%s: error setting mute groups: %s", test.strip, err)
+		}
+		dev.ExpectMessage(test.prefix+"/mute", int32(test.groups))
+		if dcas, err := mixer.DCAs(ctx, test.strip); err != nil || dcas != test.dcas {
+			t.Errorf("%s: DCAs = %s, %v", test.strip, dcas, err)
+		}
+		dev.ExpectMessage(test.prefix + "/dca")
+		if groups, err := mixer.MuteGroups(ctx, test.strip); err != nil || groups != test.groups {
+			t.Errorf("%s: mute groups = %s, %v", test.strip, groups, err)
+		}
+		dev.ExpectMessage(test.prefix + "/mute")
+	}
+}
+
+func TestGroupErrors(t *testing.T) {
+	var b packetBuffer
+	mixer := NewMixer(&b)
+	for _, err := range []error{
+		mixer.SetMuteGroup(0, true),
+		mixer.SetMuteGroup(7, true),
+		mixer.SetDCAs(Matrix(1), NewDCASet(1)),
+		mixer.SetDCAs(DCA(1), NewDCASet(1)),
+		mixer.SetMuteGroups(Main, NewMuteGroupSet(1)),
+		mixer.SetMuteGroups(Channel(33), NewMuteGroupSet(1)),
+		mixer.SetMuteGroups(Channel(1), MuteGroupSet(0x40)),
+	} {
+		if err == nil {
+			t.Error("expected error")
+		}
+	}
+	if b.Len() != 0 {
+		t.Errorf("unexpected packets written %q", b.String())
+	}
+}
+
+func TestDCASet(t *testing.T) {
+	s := NewDCASet(1, 3, 9, 0)
+	if s != 0x05 {
+		t.Errorf("\t got = %#x\n\t\t\twant = 0x5", uint8(s))
+	}
+	s = s.Add(8).Remove(1, 2)
+	if !s.Contains(3) || !s.Contains(8) || s.Contains(1) || s.Contains(9) {
+		t.Errorf("unexpected set %s", s)
+	}
+	if got, want := s.DCAs(), []DCA{3, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", got, want)
+	}
+	if got, want := s.String(), "[DCA 3 DCA 8]"; got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+}
+
+func TestMuteGroupSet(t *testing.T) {
+	s := NewMuteGroupSet(2, 6, 7)
+	if s != 0x22 {
+		t.Errorf("\t got = %#x\n\t\t\twant = 0x22", uint8(s))
+	}
+	s = s.Add(1).Remove(6)
+	if got, want := s.MuteGroups(), []MuteGroup{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", got, want)
+	}
+	if got, want := s.String(), "[mute group 1 mute group 2]"; got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+	if MuteGroupSet(0).MuteGroups() != nil {
+		t.Error("expected empty set")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// DefaultTimeout is how long the methods querying the mixer wait for its reply
+// if their context has no deadline.
+const DefaultTimeout = time.Second
+
+// Info models the info received back from the mixer for /info.
+type Info struct {
+	ServerVersion  string
+	ServerName     string
+	ConsoleModel   string
+	ConsoleVersion string
+}
+
+// XInfo models the info received back from the mixer for /xinfo.
+type XInfo struct {
+	ConsoleIP      string
+	ConsoleName    string
+	ConsoleModel   string
+	ConsoleVersion string
+}
+
+// Status models the status received back from the mixer for /status.
+type Status struct {
+	// State is "active" when the mixer is running.
+	State       string
+	ConsoleIP   string
+	ConsoleName string
+}
+
+// Info returns information about the X32 Mixer's OSC server and console.
+func (m Mixer) Info(ctx context.Context) (Info, error) {
+	var info Info
+	err := m.queryStrings(ctx, "/info", &info.ServerVersion, &info.ServerName, &info.ConsoleModel, &info.ConsoleVersion)
+	return info, err
+}
+
+// XInfo returns the network address, name, model and firmware version of the
+// X32 Mixer.
+func (m Mixer) XInfo(ctx context.Context) (XInfo, error) {
+	var info XInfo
+	err := m.queryStrings(ctx, "/xinfo", &info.ConsoleIP, &info.ConsoleName, &info.ConsoleModel, &info.ConsoleVersion)
+	return info, err
+}
+
+// Status returns the status of the X32 Mixer.
+func (m Mixer) Status(ctx context.Context) (Status, error) {
+	var status Status
+	err := m.queryStrings(ctx, "/status", &status.State, &status.ConsoleIP, &status.ConsoleName)
+	return status, err
+}
+
+// queryStrings queries the address and stores the string arguments of the
+// reply.
+func (m Mixer) queryStrings(ctx context.Context, addr string, dst ...*string) error {
+	reply, err := m.query(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if len(reply.Args) != len(dst) {
+		return fmt.Errorf("unexpected reply %s", reply)
+	}
+	for i, arg := range reply.Args {
+		s, ok := arg.(string)
+		if !ok {
+			return fmt.Errorf("unexpected reply %s", reply)
+		}
+		*dst[i] = s
+	}
+	return nil
+}
+
+// queryFloat queries the address and returns the float argument of the reply.
+func (m Mixer) queryFloat(ctx context.Context, addr string) (float64, error) {
+	reply, err := m.query(ctx, addr)
+	if err != nil {
+		return 0, err
+	}
+	if reply.TypeTag != "f" {
+		return 0, fmt.Errorf("unexpected reply %s", reply)
+	}
+	return float64(reply.Args[0].(float32)), nil
+}
+
+// queryInt queries the address and returns the int argument of the reply.
+func (m Mixer) queryInt(ctx context.Context, addr string) (int, error) {
+	reply, err := m.query(ctx, addr)
+	if err != nil {
+		return 0, err
+	}
+	if reply.TypeTag != "i" {
+		return 0, fmt.Errorf("unexpected reply %s", reply)
+	}
+	return int(reply.Args[0].(int32)), nil
+}
+
+// queryString queries the address and returns the string argument of the
+// reply.
+func (m Mixer) queryString(ctx context.Context, addr string) (string, error) {
+	var s string
+	err := m.queryStrings(ctx, addr, &s)
+	return s, err
+}
+
+// query sends a message with no arguments to the address and returns the
+// mixer's reply, skipping the other packets received until the context is
+// done, or for DefaultTimeout if it has no deadline.
+func (m Mixer) query(ctx context.Context, addr string) (*osc.Msg, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	r := m.reader
+	if r == nil {
+		return nil, errNoReader
+	}
+	c := r.wait(addr)
+	defer r.cancel(addr, c)
+	u := r.attach("", nil)
+	defer r.detach(u)
+	if err := m.WriteMessage(addr, ""); err != nil {
+		return nil, err
+	}
+	select {
+	case reply := <-c:
+		return reply, nil
+	case err := <-u.errc:
+		return nil, fmt.Errorf("querying %s: %w", addr, err)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("querying %s: %w", addr, ctx.Err())
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+	"github.com/goaudiovideo/osc/osctest"
+)
+
+// stringMsg returns a message with string arguments.
+func stringMsg(addr string, args ...interface{}) *osc.Msg {
+	m := &osc.Msg{Address: addr, Args: args}
+	for range args {
+		m.TypeTag += "s"
+	}
+	return m
+}
+
+func TestInfo(t *testing.T) {
+	dev, conn := osctest.NewDevice(t)
+	// Unrelated packets received before the reply are skipped.
+	dev.Reply("/info",
+		stringMsg("/ch/01/config/name", "Kick"),
+		stringMsg("/info", "V2.05", "osc-server", "X32", "4.06"))
+	dev.Reply("/xinfo", stringMsg("/xinfo", "192.168.0.64", "X32-02-4A-53", "X32", "4.06"))
+	dev.Reply("/status", stringMsg("/status", "active", "192.168.0.64", "X32-02-4A-53"))
+	mixer := NewMixer(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	info, err := mixer.Info(ctx)
+	if err != nil {
+		t.Fatalf("error getting info: %s", err)
+	}
+	dev.ExpectMessage("/info")
+	if want := (Info{"V2.05", "osc-server", "X32", "4.06"}); info != want {
+		t.Errorf("\t got = %+v\n\t\t\twant = %+v", info, want)
+	}
+
+	xinfo, err := mixer.XInfo(ctx)
+	if err != nil {
+		t.Fatalf("error getting xinfo: %s", err)
+	}
+	if want := (XInfo{"192.168.0.64", "X32-02-4A-53", "X32", "4.06"}); xinfo != want {
+		t.Errorf("\t got = %+v\n\t\t\twant = %+v", xinfo, want)
+	}
+
+	status, err := mixer.Status(ctx)
+	if err != nil {
+		t.Fatalf("error getting status: %s", err)
+	}
+	if want := (Status{"active", "192.168.0.64", "X32-02-4A-53"}); status != want {
+		t.Errorf("\t got = %+v\n\t\t\twant = %+v", status, want)
+	}
+}
+
+func TestInfoErrors(t *testing.T) {
+	dev, conn := osctest.NewDevice(t)
+	dev.Reply("/info", &osc.Msg{Address: "/info", TypeTag: "si", Args: []interface{}{"V2.05", int32(1)}})
+	mixer := NewMixer(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := mixer.Info(ctx); err == nil {
+		t.Error("expected error decoding malformed reply")
+	}
+
+	// The mixer does not reply to /status.
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := mixer.Status(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := mixer.XInfo(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// MaxMeterBank is the highest meter bank of the mixer.
+const MaxMeterBank = 16
+
+// Level is a meter level, linear from 0 to 1 for full scale.
+type Level float32
+
+// DBFS returns the level in dB relative to full scale, or -Inf for silence.
+func (l Level) DBFS() float64 {
+	return 20 * math.Log10(float64(l))
+}
+
+// Meters holds the levels of a meter bank. The levels of /meters/0 are, in
+// order, those of the 32 input channels, the 8 aux returns, the 4 stereo FX
+// returns, the 16 mix buses and the 6 matrices. Those of /meters/2 are the
+// levels of the 16 mix buses, the 6 matrices, main L and R and mono, then the
+// gain reductions of their dynamics.
+type Meters struct {
+	Bank   int
+	Levels []Level
+}
+
+// ParseMeters decodes the blob of the message pushed by the mixer for a
+// meter bank: a little-endian int32 count followed by as many little-endian
+// float32 levels.
+func ParseMeters(m *osc.Msg) (*Meters, error) {
+	bank, ok := meterBank(m.Address)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a meter bank", m.Address)
+	}
+	if m.TypeTag != "b" || len(m.Args) != 1 {
+		return nil, fmt.Errorf("unexpected meters %s", m)
+	}
+	blob, ok := m.Args[0].([]byte)
+	if !ok || len(blob) < 4 {
+		return nil, fmt.Errorf("unexpected meters %s", m)
+	}
+	n := int(binary.LittleEndian.Uint32(blob))
+	blob = blob[4:]
+	if n < 0 || len(blob) < 4*n {
+		return nil, fmt.Errorf("%s holds %d bytes for %d levels", m.Address, len(blob), n)
+	}
+	meters := &Meters{Bank: bank, Levels: make([]Level, n)}
+	for i := range meters.Levels {
+		meters.Levels[i] = Level(math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:])))
+	}
+	return meters, nil
+}
+
+// meterBank returns the bank of a /meters/N address.
+func meterBank(addr string) (int, bool) {
+	if !strings.HasPrefix(addr, "/meters/") {
+		return 0, false
+	}
+	bank, err := strconv.Atoi(addr[len("/meters/"):])
+	if err != nil || bank < 0 || bank > MaxMeterBank {
+		return 0, false
+	}
+	return bank, true
+}
+
+// Meters requests the meter bank from 0 to MaxMeterBank, renewing the request
+// every XRemoteInterval, and sends the levels pushed by the mixer on the
+// returned channel until the context is done or reading fails, then closes
+// it. The arguments follow the bank in the request: the channel for the banks
+// of a single channel, then the time factor, which sets the interval between
+// updates to 50 ms times the factor.
+//
+// Only the latest levels are kept for a receiver that is slower than the
+// mixer, so that UI meters do not lag behind.
+func (m Mixer) Meters(ctx context.Context, bank int, args ...int) (<-chan *Meters, error) {
+	if bank < 0 || bank > MaxMeterBank {
+		return nil, fmt.Errorf("meter bank %d out of range 0-%d", bank, MaxMeterBank)
+	}
+	if m.reader == nil {
+		return nil, errNoReader
+	}
+	addr := fmt.Sprintf("/meters/%d", bank)
+	typeTag := "s" + strings.Repeat("i", len(args))
+	request := []interface{}{addr}
+	for _, arg := range args {
+		request = append(request, arg)
+	}
+
+	latest := make(chan *Meters, 1)
+	u := m.reader.attach(addr, osc.HandlerFunc(func(p osc.Packet, from net.Addr) {
+		meters, err := ParseMeters(p.(*osc.Msg))
+		if err != nil {
+			return
+		}
+		select {
+		case <-latest:
+		default:
+		}
+		latest <- meters
+	}))
+	if err := m.WriteMessage("/meters", typeTag, request...); err != nil {
+		m.reader.detach(u)
+		return nil, err
+	}
+
+	c := make(chan *Meters)
+	go func() {
+		defer close(c)
+		defer m.reader.detach(u)
+		renewed := make(chan error, 1)
+		go func() {
+			renewed <- m.renew(ctx, u, "/meters", typeTag, request...)
+		}()
+		var pending *Meters
+		for {
+			var out chan *Meters
+			if pending != nil {
+				out = c
+			}
+			select {
+			case pending = <-latest:
+			case out <- pending:
+				pending = nil
+			case <-renewed:
+				return
+			}
+		}
+	}()
+	return c, nil
+}
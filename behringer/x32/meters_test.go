@@ -0,0 +1,114 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+	"github.com/goaudiovideo/osc/osctest"
+)
+
+// metersMsg returns the message pushed by the mixer for the meter bank.
+func metersMsg(bank int, levels ...float32) *osc.Msg {
+	blob := make([]byte, 4+4*len(levels))
+	binary.LittleEndian.PutUint32(blob, uint32(len(levels)))
+	for i, l := range levels {
+		binary.LittleEndian.PutUint32(blob[4+4*i:], math.Float32bits(l))
+	}
+	return &osc.Msg{Address: "/meters/" + string(rune('0'+bank)), TypeTag: "b", Args: []interface{}{blob}}
+}
+
+func TestParseMeters(t *testing.T) {
+	meters, err := ParseMeters(metersMsg(2, 1, 0.5, 0))
+	if err != nil {
+		t.Fatalf("error parsing meters: %s", err)
+	}
+	want := &Meters{Bank: 2, Levels: []Level{1, 0.5, 0}}
+	if !reflect.DeepEqual(meters, want) {
+		t.Errorf("\t got = %+v\n\t\t\twant = %+v", meters, want)
+	}
+	var tests = []struct {
+		level Level
+		dbfs  float64
+	}{
+		{1, 0},
+		{0.5, -6.0206},
+		{0.001, -60},
+		{0, math.Inf(-1)},
+	}
+	for _, test := range tests {
+		if got := test.level.DBFS(); math.Abs(got-test.dbfs) > 1e-4 && got != test.dbfs {
+			t.Errorf("%g:\t got = %g\n\t\t\twant = %g", test.level, got, test.dbfs)
+		}
+	}
+
+	short := metersMsg(0, 1, 1)
+	short.Args[0] = short.Args[0].([]byte)[:8]
+	for _, m := range []*osc.Msg{
+		short,
+		{Address: "/meters/17", TypeTag: "b", Args: []interface{}{[]byte{0, 0, 0, 0}}},
+		{Address: "/ch/01/mix/fader", TypeTag: "b", Args: []interface{}{[]byte{0, 0, 0, 0}}},
+		{Address: "/meters/1", TypeTag: "f", Args: []interface{}{float32(1)}},
+	} {
+		if _, err := ParseMeters(m); err == nil {
+			t.Errorf("%s: expected error", m)
+		}
+	}
+}
+
+func TestMeters(t *testing.T) {
+	renewInterval = 20 * time.Millisecond
+	defer func() { renewInterval = XRemoteInterval }()
+
+	dev, conn := osctest.NewDevice(t)
+	mixer := NewMixer(conn)
+	if _, err := mixer.Meters(context.Background(), 17); err == nil {
+		t.Error("expected error for bank 17")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, err := mixer.Meters(ctx, 6, 1, 2)
+	if err != nil {
+		t.Fatalf("error requesting meters: %s", err)
+	}
+	dev.ExpectMessage("/meters", "/meters/6", 1, 2)
+	dev.ExpectMessage("/meters", "/meters/6", 1, 2)
+	dev.Send(metersMsg(6, 0.25, 0.5, 0.75, 1))
+	select {
+	case meters := <-c:
+		want := &Meters{Bank: 6, Levels: []Level{0.25, 0.5, 0.75, 1}}
+		if !reflect.DeepEqual(meters, want) {
+			t.Errorf("\t got = %+v\n\t\t\twant = %+v", meters, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no meters received")
+	}
+
+	// Only the latest levels are kept.
+	dev.Send(metersMsg(6, 0.1))
+	dev.Send(metersMsg(6, 0.2))
+	time.Sleep(20 * time.Millisecond)
+	if meters := <-c; meters.Levels[0] != 0.2 {
+		t.Errorf("\t got = %+v\n\t\t\twant = 0.2", meters.Levels)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-c:
+		if ok {
+			t.Error("unexpected meters after cancellation")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("meters channel not closed after cancellation")
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// param binds a parameter of the mixer to a field of a Go value, converting
+// between the field and the OSC argument of the parameter.
+type param struct {
+	// addr is relative to the address of the strip, e.g. "gate/thr".
+	addr   string
+	encode func() (typeTag string, arg interface{}, err error)
+	decode func(m *osc.Msg) error
+}
+
+// onOff binds an OFF/ON parameter.
+func onOff(addr string, v *bool) param {
+	return param{
+		addr: addr,
+		encode: func() (string, interface{}, error) {
+			if *v {
+				return "i", 1, nil
+			}
+			return "i", 0, nil
+		},
+		decode: func(m *osc.Msg) error {
+			i, err := intArg(m)
+			*v = i != 0
+			return err
+		},
+	}
+}
+
+// text binds a string parameter.
+func text(addr string, v *string) param {
+	return param{
+		addr: addr,
+		encode: func() (string, interface{}, error) {
+			return "s", *v, nil
+		},
+		decode: func(m *osc.Msg) error {
+			if m.TypeTag != "s" {
+				return fmt.Errorf("unexpected reply %s", m)
+			}
+			*v = m.Args[0].(string)
+			return nil
+		},
+	}
+}
+
+// enum binds a parameter whose values are enumerated from 0 to n-1.
+func enum(addr string, v *int, n int) param {
+	return intRange(addr, v, 0, n-1)
+}
+
+// intRange binds an int parameter from min to max.
+func intRange(addr string, v *int, min, max int) param {
+	return param{
+		addr: addr,
+		encode: func() (string, interface{}, error) {
+			if *v < min || *v > max {
+				return "", nil, fmt.Errorf("%s %d out of range %d-%d", addr, *v, min, max)
+			}
+			return "i", *v, nil
+		},
+		decode: func(m *osc.Msg) error {
+			i, err := intArg(m)
+			*v = i
+			return err
+		},
+	}
+}
+
+// linf binds a float parameter with a linear scale from min to max by step.
+// Values are clamped to the range and rounded to the step.
+func linf(addr string, v *float64, min, max, step float64) param {
+	n := math.Round((max - min) / step)
+	return param{
+		addr: addr,
+		encode: func() (string, interface{}, error) {
+			i := math.Round((clamp(*v, min, max) - min) / step)
+			return "f", float32(i / n), nil
+		},
+		decode: func(m *osc.Msg) error {
+			f, err := floatArg(m)
+			// Round off the error accumulated by steps such as 0.1.
+			*v = math.Round((min+math.Round(clamp(f, 0, 1)*n)*step)*1e6) / 1e6
+			return err
+		},
+	}
+}
+
+// logf binds a float parameter with a logarithmic scale of steps values from
+// min to max, as used for frequencies and times. Values are clamped to the
+// range and rounded to the nearest value of the scale.
+func logf(addr string, v *float64, min, max float64, steps int) param {
+	n := float64(steps - 1)
+	ratio := math.Log(max / min)
+	return param{
+		addr: addr,
+		encode: func() (string, interface{}, error) {
+			x := clamp(*v, math.Min(min, max), math.Max(min, max))
+			i := math.Round(math.Log(x/min) / ratio * n)
+			return "f", float32(i / n), nil
+		},
+		decode: func(m *osc.Msg) error {
+			f, err := floatArg(m)
+			*v = roundSignificant(min * math.Exp(math.Round(clamp(f, 0, 1)*n)/n*ratio))
+			return err
+		},
+	}
+}
+
+// level binds a level in dB on the scale of a fader.
+func level(addr string, v *float64) param {
+	return param{
+		addr: addr,
+		encode: func() (string, interface{}, error) {
+			return "f", faderValue(*v), nil
+		},
+		decode: func(m *osc.Msg) error {
+			f, err := floatArg(m)
+			*v = decimalToDBLevel(f)
+			return err
+		},
+	}
+}
+
+// choice binds a parameter enumerating the values. The nearest value is set.
+func choice(addr string, v *float64, values []float64) param {
+	return param{
+		addr: addr,
+		encode: func() (string, interface{}, error) {
+			best := 0
+			for i, x := range values {
+				if math.Abs(x-*v) < math.Abs(values[best]-*v) {
+					best = i
+				}
+			}
+			return "i", best, nil
+		},
+		decode: func(m *osc.Msg) error {
+			i, err := intArg(m)
+			if err != nil {
+				return err
+			}
+			if i < 0 || i >= len(values) {
+				return fmt.Errorf("unexpected reply %s", m)
+			}
+			*v = values[i]
+			return nil
+		},
+	}
+}
+
+func clamp(x, min, max float64) float64 {
+	return math.Max(min, math.Min(max, x))
+}
+
+// roundSignificant rounds a value of a logarithmic scale to 4 significant
+// digits, which keeps it well within its step of the scale.
+func roundSignificant(x float64) float64 {
+	if x == 0 {
+		return 0
+	}
+	scale := math.Pow(10, 3-math.Floor(math.Log10(math.Abs(x))))
+	return math.Round(x*scale) / scale
+}
+
+func intArg(m *osc.Msg) (int, error) {
+	if m.TypeTag != "i" {
+		return 0, fmt.Errorf("unexpected reply %s", m)
+	}
+	return int(m.Args[0].(int32)), nil
+}
+
+func floatArg(m *osc.Msg) (float64, error) {
+	if m.TypeTag != "f" {
+		return 0, fmt.Errorf("unexpected reply %s", m)
+	}
+	return float64(m.Args[0].(float32)), nil
+}
+
+// setParams sets the parameters at the addresses relative to the prefix. No
+// parameter is set if any of them is out of range.
+func (m Mixer) setParams(prefix string, params []param) error {
+	msgs := make([][]byte, len(params))
+	for i, p := range params {
+		typeTag, arg, err := p.encode()
+		if err != nil {
+			return err
+		}
+		if msgs[i], err = osc.Message(prefix+p.addr, typeTag, arg); err != nil {
+			return err
+		}
+	}
+	for _, msg := range msgs {
+		if _, err := m.Write(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxQueries is the maximum number of queries that getParams waits for at
+// once, so that the mixer's replies are not dropped.
+const maxQueries = 8
+
+// getParams queries the parameters at the addresses relative to the prefix,
+// up to maxQueries at once, and decodes the replies.
+func (m Mixer) getParams(ctx context.Context, prefix string, params []param) error {
+	errc := make(chan error, len(params))
+	sem := make(chan struct{}, maxQueries)
+	for _, p := range params {
+		go func(p param) {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			reply, err := m.query(ctx, prefix+p.addr)
+			if err == nil {
+				err = p.decode(reply)
+			}
+			errc <- err
+		}(p)
+	}
+	var first error
+	for range params {
+		if err := <-errc; err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
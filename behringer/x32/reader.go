@@ -0,0 +1,210 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// errNoReader is returned by the methods that read from a Mixer that was not
+// created by NewMixer.
+var errNoReader = errors.New("mixer not created by NewMixer")
+
+// reader reads the packets from the mixer's connection while it is used by
+// queries, subscriptions or meter streams. A single reader is shared by the
+// copies of a Mixer, so that they do not compete for the packets.
+//
+// The read loop outlives the expiry of the connection's read deadline, which
+// it clears, and sets the deadline to stop. When the loop stops, it restores
+// the deadline set with Mixer.SetReadDeadline.
+type reader struct {
+	conn osc.Conn
+
+	mu       sync.Mutex
+	users    map[*user]struct{}
+	waiters  map[string][]chan *osc.Msg
+	stopped  chan struct{} // closed when the read loop returns, nil if not running
+	stopping bool
+	xremote  bool      // whether Subscribe is renewing /xremote
+	deadline time.Time // set with Mixer.SetReadDeadline
+}
+
+// user uses the reader until it is detached.
+type user struct {
+	// addr, if not empty, is the address of the only messages passed to h.
+	// The messages claimed by such users are not passed to the others.
+	addr string
+	h    osc.Handler
+
+	// errc receives the fatal read error that stopped the reader.
+	errc chan error
+}
+
+func newReader(conn osc.Conn) *reader {
+	return &reader{conn: conn}
+}
+
+// attach adds a user of the reader, starting the read loop if needed. The
+// handler may be nil.
+func (r *reader) attach(addr string, h osc.Handler) *user {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for r.stopping {
+		stopped := r.stopped
+		r.mu.Unlock()
+		<-stopped
+		r.mu.Lock()
+	}
+	u := &user{addr: addr, h: h, errc: make(chan error, 1)}
+	if r.users == nil {
+		r.users = make(map[*user]struct{})
+	}
+	r.users[u] = struct{}{}
+	if r.stopped == nil {
+		r.stopped = make(chan struct{})
+		go r.loop(r.stopped)
+	}
+	return u
+}
+
+// detach removes a user of the reader, stopping the read loop if it was the
+// last one.
+func (r *reader) detach(u *user) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[u]; !ok {
+		return
+	}
+	delete(r.users, u)
+	if len(r.users) == 0 && r.stopped != nil {
+		r.stopping = true
+		r.conn.SetReadDeadline(time.Unix(1, 0))
+	}
+}
+
+func (r *reader) loop(stopped chan struct{}) {
+	defer close(stopped)
+	for {
+		b, from, err := r.conn.ReadPacket()
+		if err != nil {
+			r.mu.Lock()
+			if !r.stopping && !fatal(err) {
+				if isTimeout(err) {
+					r.conn.SetReadDeadline(time.Time{})
+				}
+				r.mu.Unlock()
+				continue
+			}
+			if !r.stopping {
+				for u := range r.users {
+					u.errc <- err
+				}
+				r.users = nil
+			}
+			r.conn.SetReadDeadline(r.deadline)
+			r.stopping, r.stopped = false, nil
+			r.mu.Unlock()
+			return
+		}
+		p, err := osc.ParsePacket(b)
+		if err != nil {
+			continue
+		}
+		for _, h := range r.handlers(p) {
+			h.ServeOSC(p, from)
+		}
+	}
+}
+
+// fatal reports whether a read error ends the read loop. A timeout only means
+// that the read deadline expired, and a UDP connection reports that the mixer
+// refused a packet sent to it, e.g. while it starts up.
+func fatal(err error) bool {
+	return !isTimeout(err) && !errors.Is(err, syscall.ECONNREFUSED)
+}
+
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// setReadDeadline sets the read deadline of the connection, or records it
+// until the read loop stops if it is running.
+func (r *reader) setReadDeadline(t time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deadline = t
+	if r.stopped != nil {
+		return nil
+	}
+	return r.conn.SetReadDeadline(t)
+}
+
+// handlers passes the replies to the waiting queries and returns the handlers
+// of the users of the packet.
+func (r *reader) handlers(p osc.Packet) []osc.Handler {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	msg, _ := p.(*osc.Msg)
+	if msg != nil {
+		if waiters := r.waiters[msg.Address]; len(waiters) > 0 {
+			for _, c := range waiters {
+				c <- msg
+			}
+			delete(r.waiters, msg.Address)
+			return nil
+		}
+	}
+	var claimed, others []osc.Handler
+	for u := range r.users {
+		switch {
+		case u.h == nil:
+		case u.addr == "":
+			others = append(others, u.h)
+		case msg != nil && msg.Address == u.addr:
+			claimed = append(claimed, u.h)
+		}
+	}
+	if len(claimed) > 0 {
+		return claimed
+	}
+	return others
+}
+
+// wait registers a query waiting for a reply from the address and returns the
+// channel on which the reply is sent.
+func (r *reader) wait(addr string) chan *osc.Msg {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.waiters == nil {
+		r.waiters = make(map[string][]chan *osc.Msg)
+	}
+	c := make(chan *osc.Msg, 1)
+	r.waiters[addr] = append(r.waiters[addr], c)
+	return c
+}
+
+// cancel unregisters a query that no longer waits for a reply.
+func (r *reader) cancel(addr string, c chan *osc.Msg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	waiters := r.waiters[addr]
+	for i, x := range waiters {
+		if x == c {
+			r.waiters[addr] = append(waiters[:i:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(r.waiters[addr]) == 0 {
+		delete(r.waiters, addr)
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// MaxScenes and MaxSnippets are the number of scene and snippet slots of the
+// show, numbered from 0.
+const (
+	MaxScenes   = 100
+	MaxSnippets = 100
+)
+
+// EmptySlotError is the error returned for a scene or snippet slot that holds
+// no data.
+type EmptySlotError struct {
+	// Kind is "scene" or "snippet".
+	Kind  string
+	Index int
+}
+
+func (e *EmptySlotError) Error() string {
+	return fmt.Sprintf("%s %d is empty", e.Kind, e.Index)
+}
+
+// SceneSafe provides an enumeration for the parameter groups that can be made
+// safe when a scene is recalled, in the order of the parameter safe page of
+// the console.
+type SceneSafe int
+
+// Enum for the scene safes, numbered by their bit in the mixer's bitmask.
+const (
+	PreampSafe SceneSafe = iota
+	ConfigSafe
+	EQSafe
+	GateSafe
+	DynamicsSafe
+	InsertSafe
+	GroupsSafe
+	FaderPanSafe
+	MuteSafe
+)
+
+// String implements the Stringer interface for SceneSafe.
+func (safe SceneSafe) String() string {
+	if s, ok := sceneSafeDescription[safe]; ok {
+		return s
+	}
+	return fmt.Sprintf("SceneSafe(%d)", int(safe))
+}
+
+var sceneSafeDescription = map[SceneSafe]string{
+	PreampSafe:   "Preamp",
+	ConfigSafe:   "Config",
+	EQSafe:       "EQ",
+	GateSafe:     "Gate",
+	DynamicsSafe: "Dyn",
+	InsertSafe:   "Insert",
+	GroupsSafe:   "Groups",
+	FaderPanSafe: "Fader/Pan",
+	MuteSafe:     "Mute",
+}
+
+// SceneSafes is the set of the parameter groups that recalling a scene leaves
+// unchanged, stored as the bitmask used by the mixer: bit 0 for PreampSafe up
+// to bit 8 for MuteSafe. The zero value is the empty set.
+type SceneSafes uint16
+
+// NewSceneSafes returns the set of the given safes.
+func NewSceneSafes(safes ...SceneSafe) SceneSafes {
+	return SceneSafes(0).Add(safes...)
+}
+
+// safeBit returns the bit of the safe, or 0 if it is out of range.
+func safeBit(safe SceneSafe) SceneSafes {
+	if safe < PreampSafe || safe > MuteSafe {
+		return 0
+	}
+	return 1 << uint(safe)
+}
+
+// Add returns the set with the given safes added. Safes out of range are
+// ignored.
+func (s SceneSafes) Add(safes ...SceneSafe) SceneSafes {
+	for _, safe := range safes {
+		s |= safeBit(safe)
+	}
+	return s
+}
+
+// Remove returns the set with the given safes removed.
+func (s SceneSafes) Remove(safes ...SceneSafe) SceneSafes {
+	for _, safe := range safes {
+		s &^= safeBit(safe)
+	}
+	return s
+}
+
+// Contains reports whether the set contains the safe.
+func (s SceneSafes) Contains(safe SceneSafe) bool {
+	bit := safeBit(safe)
+	return bit != 0 && s&bit != 0
+}
+
+// Safes returns the safes of the set in order.
+func (s SceneSafes) Safes() []SceneSafe {
+	var safes []SceneSafe
+	for safe := PreampSafe; safe <= MuteSafe; safe++ {
+		if s.Contains(safe) {
+			safes = append(safes, safe)
+		}
+	}
+	return safes
+}
+
+// String implements the Stringer interface for SceneSafes.
+func (s SceneSafes) String() string {
+	return fmt.Sprint(s.Safes())
+}
+
+// sceneSafes binds the safes parameter of a scene.
+func sceneSafes(addr string, v *SceneSafes) param {
+	return param{
+		addr: addr,
+		encode: func() (string, interface{}, error) {
+			if *v>>(MuteSafe+1) != 0 {
+				return "", nil, fmt.Errorf("scene safes %#x out of range", uint16(*v))
+			}
+			return "i", int(*v), nil
+		},
+		decode: func(m *osc.Msg) error {
+			i, err := intArg(m)
+			*v = SceneSafes(i)
+			return err
+		},
+	}
+}
+
+// Scene models a scene stored in the show of the mixer.
+type Scene struct {
+	Index int
+	Name  string
+	Note  string
+	Safes SceneSafes
+}
+
+// Snippet models a snippet stored in the show of the mixer.
+type Snippet struct {
+	Index int
+	Name  string
+}
+
+// params returns the parameters of the scene relative to its slot.
+func (s *Scene) params() []param {
+	return []param{
+		text("name", &s.Name),
+		text("notes", &s.Note),
+		sceneSafes("safes", &s.Safes),
+	}
+}
+
+// slotAddress returns the address of the scene or snippet slot, checking
+// that it is in range.
+func slotAddress(kind string, n, count int) (string, error) {
+	if n < 0 || n >= count {
+		return "", fmt.Errorf("%s %d out of range 0-%d", kind, n, count-1)
+	}
+	return fmt.Sprintf("/-show/showfile/%s/%03d/", kind, n), nil
+}
+
+// slotsWithData returns the indexes of the scene or snippet slots holding
+// data, in order. Like all the queries of getParams, the queries of the slots
+// are limited to maxQueries at once.
+func (m Mixer) slotsWithData(ctx context.Context, kind string, count int) ([]int, error) {
+	hasData := make([]bool, count)
+	params := make([]param, count)
+	for i := range params {
+		params[i] = onOff(fmt.Sprintf("%03d/hasdata", i), &hasData[i])
+	}
+	if err := m.getParams(ctx, "/-show/showfile/"+kind+"/", params); err != nil {
+		return nil, err
+	}
+	var slots []int
+	for i, ok := range hasData {
+		if ok {
+			slots = append(slots, i)
+		}
+	}
+	return slots, nil
+}
+
+// checkSlot returns the address of the scene or snippet slot, or an
+// *EmptySlotError if it holds no data.
+func (m Mixer) checkSlot(ctx context.Context, kind string, n, count int) (string, error) {
+	addr, err := slotAddress(kind, n, count)
+	if err != nil {
+		return "", err
+	}
+	hasData, err := m.queryInt(ctx, addr+"hasdata")
+	if err != nil {
+		return "", err
+	}
+	if hasData == 0 {
+		return "", &EmptySlotError{Kind: kind, Index: n}
+	}
+	return addr, nil
+}
+
+// Scenes returns the scenes stored in the show, skipping the empty slots.
+func (m Mixer) Scenes(ctx context.Context) ([]Scene, error) {
+	slots, err := m.slotsWithData(ctx, "scene", MaxScenes)
+	if err != nil {
+		return nil, err
+	}
+	scenes := make([]Scene, len(slots))
+	var params []param
+	for i, n := range slots {
+		scenes[i].Index = n
+		for _, p := range scenes[i].params() {
+			p.addr = fmt.Sprintf("%03d/%s", n, p.addr)
+			params = append(params, p)
+		}
+	}
+	if err := m.getParams(ctx, "/-show/showfile/scene/", params); err != nil {
+		return nil, err
+	}
+	return scenes, nil
+}
+
+// Scene returns the scene stored in the given slot, or an *EmptySlotError if
+// the slot is empty.
+func (m Mixer) Scene(ctx context.Context, n int) (*Scene, error) {
+	addr, err := m.checkSlot(ctx, "scene", n, MaxScenes)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scene{Index: n}
+	if err := m.getParams(ctx, addr, s.params()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// CurrentScene returns the slot of the scene last recalled or saved.
+func (m Mixer) CurrentScene(ctx context.Context) (int, error) {
+	return m.queryInt(ctx, "/-show/prepos/current")
+}
+
+// RecallScene recalls the scene stored in the given slot, or returns an
+// *EmptySlotError if the slot is empty.
+func (m Mixer) RecallScene(ctx context.Context, n int) error {
+	if _, err := m.checkSlot(ctx, "scene", n, MaxScenes); err != nil {
+		return err
+	}
+	return m.WriteMessage("/-action/goscene", "i", n)
+}
+
+// SaveScene saves the current state of the mixer into the given slot with
+// the name and note, replacing the scene stored in the slot. The name and note
+// are only set once a query, which the context bounds, confirms that the slot
+// holds data.
+func (m Mixer) SaveScene(ctx context.Context, n int, name, note string) error {
+	if _, err := slotAddress("scene", n, MaxScenes); err != nil {
+		return err
+	}
+	if err := m.WriteMessage("/-snap/save", "i", n); err != nil {
+		return err
+	}
+	addr, err := m.checkSlot(ctx, "scene", n, MaxScenes)
+	if err != nil {
+		return fmt.Errorf("saving scene %d: %w", n, err)
+	}
+	return m.setParams(addr, []param{
+		text("name", &name),
+		text("notes", &note),
+	})
+}
+
+// DeleteScene empties the given slot.
+func (m Mixer) DeleteScene(n int) error {
+	if _, err := slotAddress("scene", n, MaxScenes); err != nil {
+		return err
+	}
+	return m.WriteMessage("/-snap/delete", "i", n)
+}
+
+// SetSceneSafes sets the safes of the scene stored in the given slot, or
+// returns an *EmptySlotError if the slot is empty.
+func (m Mixer) SetSceneSafes(ctx context.Context, n int, safes SceneSafes) error {
+	addr, err := m.checkSlot(ctx, "scene", n, MaxScenes)
+	if err != nil {
+		return err
+	}
+	return m.setParams(addr, []param{sceneSafes("safes", &safes)})
+}
+
+// Snippets returns the snippets stored in the show, skipping the empty slots.
+func (m Mixer) Snippets(ctx context.Context) ([]Snippet, error) {
+	slots, err := m.slotsWithData(ctx, "snippet", MaxSnippets)
+	if err != nil {
+		return nil, err
+	}
+	snippets := make([]Snippet, len(slots))
+	params := make([]param, len(slots))
+	for i, n := range slots {
+		snippets[i].Index = n
+		params[i] = text(fmt.Sprintf("%03d/name", n), &snippets[i].Name)
+	}
+	if err := m.getParams(ctx, "/-show/showfile/snippet/", params); err != nil {
+		return nil, err
+	}
+	return snippets, nil
+}
+
+// RecallSnippet recalls the snippet stored in the given slot, or returns an
+// *EmptySlotError if the slot is empty.
+func (m Mixer) RecallSnippet(ctx context.Context, n int) error {
+	if _, err := m.checkSlot(ctx, "snippet", n, MaxSnippets); err != nil {
+		return err
+	}
+	return m.WriteMessage("/-action/gosnippet", "i", n)
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+	"github.com/goaudiovideo/osc/osctest"
+)
+
+// newShowDevice returns a fake mixer with an empty show. Saving a scene fills
+// its slot and recalling it makes it the current scene.
+func newShowDevice(t *testing.T) (*osctest.Device, Mixer) {
+	dev, conn := osctest.NewDevice(t)
+	state := make(map[string]*osc.Msg)
+	set := func(addr, typeTag string, arg interface{}) {
+		state[addr] = &osc.Msg{Address: addr, TypeTag: typeTag, Args: []interface{}{arg}}
+	}
+	set("/-show/prepos/current", "i", int32(0))
+	reply := func(m *osc.Msg) []osc.Packet {
+		if len(m.Args) > 0 {
+			state[m.Address] = m
+			return nil
+		}
+		if v, ok := state[m.Address]; ok {
+			return []osc.Packet{v}
+		}
+		switch path.Base(m.Address) {
+		case "hasdata", "safes":
+			return []osc.Packet{&osc.Msg{Address: m.Address, TypeTag: "i", Args: []interface{}{int32(0)}}}
+		}
+		return []osc.Packet{&osc.Msg{Address: m.Address, TypeTag: "s", Args: []interface{}{""}}}
+	}
+	dev.ReplyFunc("/-show/showfile/*/*/*", reply)
+	dev.ReplyFunc("/-show/prepos/current", reply)
+	dev.ReplyFunc("/-snap/save", func(m *osc.Msg) []osc.Packet {
+		set(fmt.Sprintf("/-show/showfile/scene/%03d/hasdata", m.Args[0]), "i", int32(1))
+		set("/-show/prepos/current", "i", m.Args[0])
+		return nil
+	})
+	dev.ReplyFunc("/-action/goscene", func(m *osc.Msg) []osc.Packet {
+		set("/-show/prepos/current", "i", m.Args[0])
+		return nil
+	})
+	return dev, NewMixer(conn)
+}
+
+func TestScenes(t *testing.T) {
+	dev, mixer := newShowDevice(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := mixer.SaveScene(ctx, 3, "Act 1", "Open"); err != nil {
+		t.Fatalf("error saving scene: %s", err)
+	}
+	// The slot is checked before the name is set.
+	dev.ExpectMessage("/-snap/save", 3)
+	dev.ExpectMessage("/-show/showfile/scene/003/hasdata")
+	dev.ExpectMessage("/-show/showfile/scene/003/name", "Act 1")
+	dev.ExpectMessage("/-show/showfile/scene/003/notes", "Open")
+	if err := mixer.SaveScene(ctx, 42, "Act 2", ""); err != nil {
+		t.Fatalf("error saving scene: %s", err)
+	}
+	if err := mixer.SetSceneSafes(ctx, 42, NewSceneSafes(PreampSafe, EQSafe)); err != nil {
+		t.Fatalf("error setting scene safes: %s", err)
+	}
+	dev.ExpectMessage("/-snap/save", 42)
+	dev.ExpectMessage("/-show/showfile/scene/042/hasdata")
+	dev.ExpectMessage("/-show/showfile/scene/042/name", "Act 2")
+	dev.ExpectMessage("/-show/showfile/scene/042/notes", "")
+	dev.ExpectMessage("/-show/showfile/scene/042/hasdata")
+	dev.ExpectMessage("/-show/showfile/scene/042/safes", 5)
+	scenes, err := mixer.Scenes(ctx)
+	if err != nil {
+		t.Fatalf("error listing scenes: %s", err)
+	}
+	want := []Scene{{3, "Act 1", "Open", 0}, {42, "Act 2", "", 5}}
+	if got := scenes[1].Safes.String(); got != "[Preamp EQ]" {
+		t.Errorf("\t got = %s\n\t\t\twant = [Preamp EQ]", got)
+	}
+	if !reflect.DeepEqual(scenes, want) {
+		t.Errorf("\t got = %+v\n\t\t\twant = %+v", scenes, want)
+	}
+	scene, err := mixer.Scene(ctx, 3)
+	if err != nil || *scene != want[0] {
+		t.Errorf("scene 3 = %+v, %v", scene, err)
+	}
+
+	if err := mixer.RecallScene(ctx, 3); err != nil {
+		t.Fatalf("error recalling scene: %s", err)
+	}
+	if n, err := mixer.CurrentScene(ctx); err != nil || n != 3 {
+		t.Errorf("current scene = %d, %v", n, err)
+	}
+
+	// Empty slots.
+	var empty *EmptySlotError
+	if _, err := mixer.Scene(ctx, 4); !errors.As(err, &empty) || *empty != (EmptySlotError{"scene", 4}) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := mixer.RecallScene(ctx, 99); !errors.As(err, &empty) || empty.Index != 99 {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := mixer.SetSceneSafes(ctx, 0, 1); !errors.As(err, &empty) {
+		t.Errorf("unexpected error %v", err)
+	}
+	for _, msg := range dev.Messages() {
+		if msg.Address == "/-action/goscene" && msg.Args[0] != int32(3) {
+			t.Errorf("unexpected message %s", msg)
+		}
+	}
+}
+
+func TestSaveSceneUnconfirmed(t *testing.T) {
+	dev, conn := osctest.NewDevice(t)
+	dev.ReplyFunc("/-show/showfile/scene/*/hasdata", func(m *osc.Msg) []osc.Packet {
+		return []osc.Packet{&osc.Msg{Address: m.Address, TypeTag: "i", Args: []interface{}{int32(0)}}}
+	})
+	mixer := NewMixer(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// The mixer did not save the scene, so its name is not set.
+	var empty *EmptySlotError
+	if err := mixer.SaveScene(ctx, 5, "Act 3", ""); !errors.As(err, &empty) || empty.Index != 5 {
+		t.Errorf("unexpected error %v", err)
+	}
+	dev.ExpectMessage("/-snap/save", 5)
+	dev.ExpectMessage("/-show/showfile/scene/005/hasdata")
+	dev.ExpectNoMessage(50 * time.Millisecond)
+}
+
+func TestSceneSafes(t *testing.T) {
+	s := NewSceneSafes(PreampSafe, MuteSafe, MuteSafe+1)
+	if s != 0x101 {
+		t.Errorf("\t got = %#x\n\t\t\twant = 0x101", uint16(s))
+	}
+	s = s.Add(GateSafe).Remove(PreampSafe)
+	if !s.Contains(GateSafe) || s.Contains(PreampSafe) || s.Contains(-1) {
+		t.Errorf("unexpected set %s", s)
+	}
+	if got := s.String(); got != "[Gate Mute]" {
+		t.Errorf("\t got = %s\n\t\t\twant = [Gate Mute]", got)
+	}
+
+	// The mask reported by the mixer decodes to the named safes.
+	_, mixer := newShowDevice(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, err := range []error{
+		mixer.WriteMessage("/-show/showfile/scene/007/hasdata", "i", 1),
+		mixer.WriteMessage("/-show/showfile/scene/007/safes", "i", 0x84),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	scene, err := mixer.Scene(ctx, 7)
+	if err != nil {
+		t.Fatalf("error getting scene: %s", err)
+	}
+	if want := NewSceneSafes(EQSafe, FaderPanSafe); scene.Safes != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", scene.Safes, want)
+	}
+	if err := mixer.SetSceneSafes(ctx, 7, 1<<9); err == nil {
+		t.Error("expected error for safes out of range")
+	}
+}
+
+func TestSnippets(t *testing.T) {
+	dev, mixer := newShowDevice(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, err := range []error{
+		mixer.WriteMessage("/-show/showfile/snippet/007/hasdata", "i", 1),
+		mixer.WriteMessage("/-show/showfile/snippet/007/name", "s", "Intro"),
+		mixer.WriteMessage("/-show/showfile/snippet/080/hasdata", "i", 1),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	snippets, err := mixer.Snippets(ctx)
+	if err != nil {
+		t.Fatalf("error listing snippets: %s", err)
+	}
+	want := []Snippet{{7, "Intro"}, {80, ""}}
+	if !reflect.DeepEqual(snippets, want) {
+		t.Errorf("\t got = %+v\n\t\t\twant = %+v", snippets, want)
+	}
+	if err := mixer.RecallSnippet(ctx, 7); err != nil {
+		t.Fatalf("error recalling snippet: %s", err)
+	}
+	var empty *EmptySlotError
+	if err := mixer.RecallSnippet(ctx, 8); !errors.As(err, &empty) || *empty != (EmptySlotError{"snippet", 8}) {
+		t.Errorf("unexpected error %v", err)
+	}
+	var recalled []interface{}
+	for _, msg := range dev.Messages() {
+		if msg.Address == "/-action/gosnippet" {
+			recalled = append(recalled, msg.Args[0])
+		}
+	}
+	if !reflect.DeepEqual(recalled, []interface{}{int32(7)}) {
+		t.Errorf("snippets recalled %v", recalled)
+	}
+}
+
+func TestSceneRanges(t *testing.T) {
+	var b packetBuffer
+	mixer := NewMixer(&b)
+	ctx := context.Background()
+	if _, err := mixer.Scene(ctx, MaxScenes); err == nil {
+		t.Error("expected error for scene 100")
+	}
+	if err := mixer.RecallSnippet(ctx, -1); err == nil {
+		t.Error("expected error for snippet -1")
+	}
+	if err := mixer.SaveScene(ctx, 100, "foo", ""); err == nil {
+		t.Error("expected error for scene 100")
+	}
+	if err := mixer.DeleteScene(-1); err == nil {
+		t.Error("expected error for scene -1")
+	}
+	if b.Len() != 0 {
+		t.Errorf("unexpected packets written %q", b.String())
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"fmt"
+)
+
+// SendTap provides an enumeration for the points of a strip a send is tapped
+// from.
+type SendTap int
+
+// Enum for the send taps. SubgroupTap is only available for the sends to mix
+// buses.
+const (
+	InputTap SendTap = iota
+	PreEQTap
+	PostEQTap
+	PreFaderTap
+	PostFaderTap
+	SubgroupTap
+)
+
+// String implements the Stringer interface for SendTap.
+func (tap SendTap) String() string {
+	return sendTapDescription[tap]
+}
+
+var sendTapDescription = map[SendTap]string{
+	InputTap:     "IN/LC",
+	PreEQTap:     "<-EQ",
+	PostEQTap:    "EQ->",
+	PreFaderTap:  "PRE",
+	PostFaderTap: "POST",
+	SubgroupTap:  "GRP",
+}
+
+// Send models a send from a strip to a mix bus or matrix: from a Channel,
+// AuxIn or FXReturn to a Bus, or from a Bus, Main or Mono to a Matrix.
+//
+// The mix buses and matrices are paired odd/even, and a linked pair is fed
+// by a single stereo send: the send of the odd bus or matrix, which is used
+// for either of them.
+type Send struct {
+	On bool
+	// Level is from -90 (off) to +10 dB, on the scale of a fader.
+	Level float64
+	// Pan, from -100 (left) to +100 (right), applies to a linked pair.
+	Pan float64
+	// Tap is shared by the sends to both buses or matrices of a pair.
+	Tap SendTap
+
+	// Linked reports whether the destination is linked with the other bus
+	// or matrix of its pair. It is ignored by SetSend.
+	Linked bool
+}
+
+// params returns the parameters of the send relative to the address of the
+// send to the destination and to the odd destination of its pair.
+func (s *Send) params(to Strip) (send, pair []param) {
+	taps := len(sendTapDescription)
+	if _, ok := to.(Matrix); ok {
+		taps--
+	}
+	send = []param{
+		onOff("on", &s.On),
+		level("level", &s.Level),
+	}
+	pair = []param{enum("type", (*int)(&s.Tap), taps)}
+	if s.Linked {
+		pair = append(pair, linf("pan", &s.Pan, -100, 100, 2))
+	}
+	return send, pair
+}
+
+// Formats of the addresses of the links of the pairs of mix buses and
+// matrices.
+const (
+	busLink    = "/config/buslink/%d-%d"
+	matrixLink = "/config/mtxlink/%d-%d"
+)
+
+// sendRoute returns the number of the destination of a send and the format of
+// the address of the link of its pair, checking that the strips have a send.
+func sendRoute(from, to Strip) (int, string, error) {
+	if _, err := from.address(); err != nil {
+		return 0, "", err
+	}
+	if _, err := to.address(); err != nil {
+		return 0, "", err
+	}
+	switch to := to.(type) {
+	case Bus:
+		switch from.(type) {
+		case Channel, AuxIn, FXReturn:
+			return int(to), busLink, nil
+		}
+	case Matrix:
+		switch from.(type) {
+		case Bus, MainBus:
+			return int(to), matrixLink, nil
+		}
+	}
+	return 0, "", fmt.Errorf("no send from %s to %s", from, to)
+}
+
+// linkAddress returns the address of the link of the pair of the destination
+// numbered n.
+func linkAddress(format string, n int) string {
+	odd := n - (n-1)%2
+	return fmt.Sprintf(format, odd, odd+1)
+}
+
+// sendAddresses returns the addresses of the send from the strip to the
+// destination, and of the send to the odd destination of its pair, taking
+// into account whether the pair is linked.
+func (m Mixer) sendAddresses(ctx context.Context, from, to Strip) (send, pair string, linked bool, err error) {
+	n, link, err := sendRoute(from, to)
+	if err != nil {
+		return "", "", false, err
+	}
+	on, err := m.queryInt(ctx, linkAddress(link, n))
+	if err != nil {
+		return "", "", false, err
+	}
+	linked = on != 0
+	src, _ := from.address()
+	odd := n - (n-1)%2
+	pair = fmt.Sprintf("%s/mix/%02d/", src, odd)
+	if linked {
+		return pair, pair, true, nil
+	}
+	return fmt.Sprintf("%s/mix/%02d/", src, n), pair, false, nil
+}
+
+// SetSend sets the send from the strip to the bus or matrix. The pan is only
+// set if the destination is linked, which the context bounds the query of.
+func (m Mixer) SetSend(ctx context.Context, from, to Strip, s *Send) error {
+	send, pair, linked, err := m.sendAddresses(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	v := *s
+	v.Linked = linked
+	sendParams, pairParams := v.params(to)
+	// Check the pair's parameters before setting any of them.
+	for _, p := range pairParams {
+		if _, _, err := p.encode(); err != nil {
+			return err
+		}
+	}
+	if err := m.setParams(send, sendParams); err != nil {
+		return err
+	}
+	return m.setParams(pair, pairParams)
+}
+
+// Send returns the send from the strip to the bus or matrix.
+func (m Mixer) Send(ctx context.Context, from, to Strip) (*Send, error) {
+	send, pair, linked, err := m.sendAddresses(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	s := &Send{Linked: linked}
+	sendParams, pairParams := s.params(to)
+	if err := m.getParams(ctx, send, sendParams); err != nil {
+		return nil, err
+	}
+	if err := m.getParams(ctx, pair, pairParams); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetSendLevel sets the level in dB of the send from the strip to the bus or
+// matrix.
+func (m Mixer) SetSendLevel(ctx context.Context, from, to Strip, db float64) error {
+	send, _, _, err := m.sendAddresses(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	return m.setParams(send, []param{level("level", &db)})
+}
+
+// SendLevel returns the level in dB of the send from the strip to the bus or
+// matrix.
+func (m Mixer) SendLevel(ctx context.Context, from, to Strip) (float64, error) {
+	send, _, _, err := m.sendAddresses(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	var db float64
+	err = m.getParams(ctx, send, []param{level("level", &db)})
+	return db, err
+}
+
+// SetSendOn turns the send from the strip to the bus or matrix on or off.
+func (m Mixer) SetSendOn(ctx context.Context, from, to Strip, on bool) error {
+	send, _, _, err := m.sendAddresses(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	return m.setParams(send, []param{onOff("on", &on)})
+}
+
+// SetBusLink links or unlinks the given mix bus with the other bus of its
+// odd/even pair.
+func (m Mixer) SetBusLink(bus Bus, linked bool) error {
+	if _, err := bus.address(); err != nil {
+		return err
+	}
+	return m.setParams("", []param{onOff(linkAddress(busLink, int(bus)), &linked)})
+}
+
+// BusLinked reports whether the given mix bus is linked with the other bus of
+// its odd/even pair.
+func (m Mixer) BusLinked(ctx context.Context, bus Bus) (bool, error) {
+	if _, err := bus.address(); err != nil {
+		return false, err
+	}
+	on, err := m.queryInt(ctx, linkAddress(busLink, int(bus)))
+	return on != 0, err
+}
+
+// SetMatrixLink links or unlinks the given matrix with the other matrix of
+// its odd/even pair.
+func (m Mixer) SetMatrixLink(mtx Matrix, linked bool) error {
+	if _, err := mtx.address(); err != nil {
+		return err
+	}
+	return m.setParams("", []param{onOff(linkAddress(matrixLink, int(mtx)), &linked)})
+}
+
+// MatrixLinked reports whether the given matrix is linked with the other
+// matrix of its odd/even pair.
+func (m Mixer) MatrixLinked(ctx context.Context, mtx Matrix) (bool, error) {
+	if _, err := mtx.address(); err != nil {
+		return false, err
+	}
+	on, err := m.queryInt(ctx, linkAddress(matrixLink, int(mtx)))
+	return on != 0, err
+}
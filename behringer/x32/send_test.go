@@ -0,0 +1,130 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSends(t *testing.T) {
+	dev, mixer := newEchoDevice(t, "/*/*/mix/*/*", "/config/*/*")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Buses 3-4 and matrices 5-6 are linked.
+	for _, err := range []error{
+		mixer.SetBusLink(2, false),
+		mixer.SetBusLink(4, true),
+		mixer.SetMatrixLink(1, false),
+		mixer.SetMatrixLink(6, true),
+	} {
+		if err != nil {
+			t.Fatalf("error linking: %s", err)
+		}
+	}
+	dev.ExpectMessage("/config/buslink/1-2", int32(0))
+	dev.ExpectMessage("/config/buslink/3-4", int32(1))
+	dev.ExpectMessage("/config/mtxlink/1-2", int32(0))
+	dev.ExpectMessage("/config/mtxlink/5-6", int32(1))
+
+	// The tap of an unlinked send is set on the odd bus, and its pan is left
+	// unchanged.
+	err := mixer.SetSend(ctx, Channel(5), Bus(2), &Send{On: true, Level: -10, Pan: 50, Tap: PreFaderTap})
+	if err != nil {
+		t.Fatalf("error setting send: %s", err)
+	}
+	dev.ExpectMessage("/config/buslink/1-2")
+	dev.ExpectMessage("/ch/05/mix/02/on", int32(1))
+	dev.ExpectMessage("/ch/05/mix/02/level", float64(faderValue(-10)))
+	dev.ExpectMessage("/ch/05/mix/01/type", int32(PreFaderTap))
+
+	// A linked pair is fed by the send to its odd bus.
+	err = mixer.SetSend(ctx, Channel(5), Bus(4), &Send{On: true, Level: 0, Pan: -30, Tap: PostFaderTap})
+	if err != nil {
+		t.Fatalf("error setting send: %s", err)
+	}
+	dev.ExpectMessage("/config/buslink/3-4")
+	dev.ExpectMessage("/ch/05/mix/03/on", int32(1))
+	dev.ExpectMessage("/ch/05/mix/03/level", float64(faderValue(0)))
+	dev.ExpectMessage("/ch/05/mix/03/type", int32(PostFaderTap))
+	dev.ExpectMessage("/ch/05/mix/03/pan", float64(float32(0.35)))
+
+	err = mixer.SetSend(ctx, Main, Matrix(5), &Send{Level: -90, Pan: 20, Tap: InputTap})
+	if err != nil {
+		t.Fatalf("error setting send: %s", err)
+	}
+	dev.ExpectMessage("/config/mtxlink/5-6")
+	dev.ExpectMessage("/main/st/mix/05/on", int32(0))
+	dev.ExpectMessage("/main/st/mix/05/level", float64(0))
+	dev.ExpectMessage("/main/st/mix/05/type", int32(InputTap))
+	dev.ExpectMessage("/main/st/mix/05/pan", float64(float32(0.6)))
+
+	if err := mixer.SetSendLevel(ctx, Bus(16), Matrix(2), 3); err != nil {
+		t.Fatalf("error setting send level: %s", err)
+	}
+	dev.ExpectMessage("/config/mtxlink/1-2")
+	dev.ExpectMessage("/bus/16/mix/02/level", float64(faderValue(3)))
+	if err := mixer.SetSendOn(ctx, AuxIn(1), Bus(4), true); err != nil {
+		t.Fatalf("error setting send on: %s", err)
+	}
+	dev.ExpectMessage("/config/buslink/3-4")
+	dev.ExpectMessage("/auxin/01/mix/03/on", int32(1))
+
+	var tests = []struct {
+		from, to Strip
+		want     Send
+	}{
+		{Channel(5), Bus(2), Send{On: true, Level: QuantizeFader(-10), Tap: PreFaderTap}},
+		{Channel(5), Bus(3), Send{On: true, Level: QuantizeFader(0), Pan: -30, Tap: PostFaderTap, Linked: true}},
+		{Channel(5), Bus(4), Send{On: true, Level: QuantizeFader(0), Pan: -30, Tap: PostFaderTap, Linked: true}},
+		{Main, Matrix(6), Send{Level: -90, Pan: 20, Tap: InputTap, Linked: true}},
+	}
+	for _, test := range tests {
+		got, err := mixer.Send(ctx, test.from, test.to)
+		if err != nil {
+			t.Fatalf("error getting send from %s to %s: %s", test.from, test.to, err)
+		}
+		if *got != test.want {
+			t.Errorf("%s to %s:\t got = %+v\n\t\t\twant = %+v", test.from, test.to, *got, test.want)
+		}
+	}
+	if db, err := mixer.SendLevel(ctx, Bus(16), Matrix(2)); err != nil || db != QuantizeFader(3) {
+		t.Errorf("send level = %g, %v", db, err)
+	}
+	if linked, err := mixer.BusLinked(ctx, 3); err != nil || !linked {
+		t.Errorf("bus 3 linked = %t, %v", linked, err)
+	}
+	if linked, err := mixer.MatrixLinked(ctx, 2); err != nil || linked {
+		t.Errorf("matrix 2 linked = %t, %v", linked, err)
+	}
+}
+
+func TestSendRoutes(t *testing.T) {
+	var b packetBuffer
+	mixer := NewMixer(&b)
+	ctx := context.Background()
+	for _, test := range []struct{ from, to Strip }{
+		{Channel(1), Matrix(1)},
+		{Bus(1), Bus(2)},
+		{Main, Bus(1)},
+		{DCA(1), Bus(1)},
+		{Channel(33), Bus(1)},
+		{Channel(1), Bus(17)},
+		{Bus(1), Matrix(7)},
+	} {
+		if err := mixer.SetSendOn(ctx, test.from, test.to, true); err == nil {
+			t.Errorf("%s to %s: expected error", test.from, test.to)
+		}
+	}
+	if err := mixer.SetBusLink(17, true); err == nil {
+		t.Error("expected error linking bus 17")
+	}
+	if b.Len() != 0 {
+		t.Errorf("unexpected packets written %q", b.String())
+	}
+}
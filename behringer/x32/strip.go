@@ -0,0 +1,246 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"fmt"
+)
+
+// Strip is a strip of the mixer that has a name, color, icon, mute and fader:
+// a Channel, AuxIn, FXReturn, Bus, Matrix, DCA, or the Main or Mono bus.
+type Strip interface {
+	fmt.Stringer
+
+	// address returns the address of the strip, e.g. "/bus/03", or an error
+	// if its number is out of range.
+	address() (string, error)
+}
+
+// Channel is an input channel, numbered from 1 to 32.
+type Channel int
+
+// AuxIn is an aux input, numbered from 1 to 8.
+type AuxIn int
+
+// FXReturn is an FX return, numbered from 1 to 8.
+type FXReturn int
+
+// Bus is a mix bus, numbered from 1 to 16.
+type Bus int
+
+// Matrix is a matrix, numbered from 1 to 6.
+type Matrix int
+
+// DCA is a DCA group, numbered from 1 to 8.
+type DCA int
+
+// MainBus provides an enumeration for the main buses.
+type MainBus int
+
+// Enum for the main buses.
+const (
+	Main MainBus = iota
+	Mono
+)
+
+// String implements the Stringer interface for Channel.
+func (ch Channel) String() string { return fmt.Sprintf("channel %d", int(ch)) }
+
+// String implements the Stringer interface for AuxIn.
+func (aux AuxIn) String() string { return fmt.Sprintf("aux input %d", int(aux)) }
+
+// String implements the Stringer interface for FXReturn.
+func (fx FXReturn) String() string { return fmt.Sprintf("FX return %d", int(fx)) }
+
+// String implements the Stringer interface for Bus.
+func (bus Bus) String() string { return fmt.Sprintf("bus %d", int(bus)) }
+
+// String implements the Stringer interface for Matrix.
+func (mtx Matrix) String() string { return fmt.Sprintf("matrix %d", int(mtx)) }
+
+// String implements the Stringer interface for DCA.
+func (dca DCA) String() string { return fmt.Sprintf("DCA %d", int(dca)) }
+
+// String implements the Stringer interface for MainBus.
+func (bus MainBus) String() string {
+	switch bus {
+	case Main:
+		return "main"
+	case Mono:
+		return "mono"
+	}
+	return fmt.Sprintf("MainBus(%d)", int(bus))
+}
+
+func (ch Channel) address() (string, error) {
+	return stripAddress("/ch/%02d", "channel", int(ch), 32)
+}
+
+func (aux AuxIn) address() (string, error) {
+	return stripAddress("/auxin/%02d", "aux input", int(aux), 8)
+}
+
+func (fx FXReturn) address() (string, error) {
+	return stripAddress("/fxrtn/%02d", "FX return", int(fx), 8)
+}
+
+func (bus Bus) address() (string, error) {
+	return stripAddress("/bus/%02d", "bus", int(bus), 16)
+}
+
+func (mtx Matrix) address() (string, error) {
+	return stripAddress("/mtx/%02d", "matrix", int(mtx), 6)
+}
+
+func (dca DCA) address() (string, error) {
+	return stripAddress("/dca/%d", "DCA", int(dca), 8)
+}
+
+func (bus MainBus) address() (string, error) {
+	switch bus {
+	case Main:
+		return "/main/st", nil
+	case Mono:
+		return "/main/m", nil
+	}
+	return "", fmt.Errorf("unknown %s", bus)
+}
+
+// stripAddress returns the address formatted with the number of a strip,
+// checking that it is in range.
+func stripAddress(format, kind string, n, count int) (string, error) {
+	if n < 1 || n > count {
+		return "", fmt.Errorf("%s %d out of range 1-%d", kind, n, count)
+	}
+	return fmt.Sprintf(format, n), nil
+}
+
+// configAddress returns the address of a config parameter of the strip.
+func configAddress(s Strip, param string) (string, error) {
+	addr, err := s.address()
+	return addr + "/config/" + param, err
+}
+
+// mixAddress returns the address of a mix parameter of the strip. A DCA group
+// has its mix parameters at the top of its address.
+func mixAddress(s Strip, param string) (string, error) {
+	addr, err := s.address()
+	if _, ok := s.(DCA); ok {
+		return addr + "/" + param, err
+	}
+	return addr + "/mix/" + param, err
+}
+
+// SetName sets the name of the given strip. The name can only be up to 12
+// characters.
+func (m Mixer) SetName(s Strip, name string) error {
+	addr, err := configAddress(s, "name")
+	if err != nil {
+		return err
+	}
+	if len(name) > 12 {
+		return fmt.Errorf("%s name %s too long (12 char limit)", s, name)
+	}
+	return m.WriteMessage(addr, "s", name)
+}
+
+// Name returns the name of the given strip.
+func (m Mixer) Name(ctx context.Context, s Strip) (string, error) {
+	addr, err := configAddress(s, "name")
+	if err != nil {
+		return "", err
+	}
+	return m.queryString(ctx, addr)
+}
+
+// SetColor sets the color of the given strip.
+func (m Mixer) SetColor(s Strip, color Color) error {
+	addr, err := configAddress(s, "color")
+	if err != nil {
+		return err
+	}
+	return m.WriteMessage(addr, "i", int(color))
+}
+
+// Color returns the color of the given strip.
+func (m Mixer) Color(ctx context.Context, s Strip) (Color, error) {
+	addr, err := configAddress(s, "color")
+	if err != nil {
+		return 0, err
+	}
+	color, err := m.queryInt(ctx, addr)
+	return Color(color), err
+}
+
+// SetIcon sets the icon of the given strip.
+func (m Mixer) SetIcon(s Strip, icon Icon) error {
+	addr, err := configAddress(s, "icon")
+	if err != nil {
+		return err
+	}
+	return m.WriteMessage(addr, "i", int(icon))
+}
+
+// Icon returns the icon of the given strip.
+func (m Mixer) Icon(ctx context.Context, s Strip) (Icon, error) {
+	addr, err := configAddress(s, "icon")
+	if err != nil {
+		return 0, err
+	}
+	icon, err := m.queryInt(ctx, addr)
+	return Icon(icon), err
+}
+
+// Mute mutes the given strip.
+func (m Mixer) Mute(s Strip) error {
+	addr, err := mixAddress(s, "on")
+	if err != nil {
+		return err
+	}
+	return m.WriteMessage(addr, "i", 0)
+}
+
+// Unmute unmutes the given strip.
+func (m Mixer) Unmute(s Strip) error {
+	addr, err := mixAddress(s, "on")
+	if err != nil {
+		return err
+	}
+	return m.WriteMessage(addr, "i", 1)
+}
+
+// Muted reports whether the given strip is muted.
+func (m Mixer) Muted(ctx context.Context, s Strip) (bool, error) {
+	addr, err := mixAddress(s, "on")
+	if err != nil {
+		return false, err
+	}
+	on, err := m.queryInt(ctx, addr)
+	return on == 0, err
+}
+
+// SetFader sets the fader of the given strip to the level in dB.
+func (m Mixer) SetFader(s Strip, db float64) error {
+	addr, err := mixAddress(s, "fader")
+	if err != nil {
+		return err
+	}
+	return m.WriteMessage(addr, "f", faderValue(db))
+}
+
+// Fader returns the level in dB of the fader of the given strip.
+func (m Mixer) Fader(ctx context.Context, s Strip) (float64, error) {
+	addr, err := mixAddress(s, "fader")
+	if err != nil {
+		return 0, err
+	}
+	f, err := m.queryFloat(ctx, addr)
+	if err != nil {
+		return 0, err
+	}
+	return decimalToDBLevel(f), nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStrips(t *testing.T) {
+	dev, mixer := newEchoDevice(t, "/*/*/config/*", "/*/*/mix/*", "/dca/*/*")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var tests = []struct {
+		strip  Strip
+		prefix string
+		mix    string
+	}{
+		{Channel(7), "/ch/07", "/ch/07/mix"},
+		{AuxIn(8), "/auxin/08", "/auxin/08/mix"},
+		{FXReturn(1), "/fxrtn/01", "/fxrtn/01/mix"},
+		{Bus(16), "/bus/16", "/bus/16/mix"},
+		{Matrix(6), "/mtx/06", "/mtx/06/mix"},
+		{DCA(3), "/dca/3", "/dca/3"},
+		{Main, "/main/st", "/main/st/mix"},
+		{Mono, "/main/m", "/main/m/mix"},
+	}
+	for _, test := range tests {
+		if err := mixer.SetName(test.strip, "Monitor"); err != nil {
+			t.Fatalf("%s: error setting name: %s", test.strip, err)
+		}
+		dev.ExpectMessage(test.prefix+"/config/name", "Monitor")
+		if err := mixer.SetColor(test.strip, BlueBackground); err != nil {
+			t.Fatalf("%s: error setting color: %s", test.strip, err)
+		}
+		dev.ExpectMessage(test.prefix+"/config/color", int32(BlueBackground))
+		if err := mixer.SetIcon(test.strip, Laptop); err != nil {
+			t.Fatalf("%s: error setting icon: %s", test.strip, err)
+		}
+		dev.ExpectMessage(test.prefix+"/config/icon", int32(Laptop))
+		if err := mixer.Mute(test.strip); err != nil {
+			t.Fatalf("%s: error muting: %s", test.strip, err)
+		}
+		dev.ExpectMessage(test.mix+"/on", int32(0))
+		if err := mixer.SetFader(test.strip, -10); err != nil {
+			t.Fatalf("%s: error setting fader: %s", test.strip, err)
+		}
+		dev.ExpectMessage(test.mix+"/fader", float64(faderValue(-10)))
+
+		if name, err := mixer.Name(ctx, test.strip); err != nil || name != "Monitor" {
+			t.Errorf("%s: name = %q, %v", test.strip, name, err)
+		}
+		dev.ExpectMessage(test.prefix + "/config/name")
+		if color, err := mixer.Color(ctx, test.strip); err != nil || color != BlueBackground {
+			t.Errorf("%s: color = %s, %v", test.strip, color, err)
+		}
+		dev.ExpectMessage(test.prefix + "/config/color")
+		if icon, err := mixer.Icon(ctx, test.strip); err != nil || icon != Laptop {
+			t.Errorf("%s: icon = %s, %v", test.strip, icon, err)
+		}
+		dev.ExpectMessage(test.prefix + "/config/icon")
+		if muted, err := mixer.Muted(ctx, test.strip); err != nil || !muted {
+			t.Errorf("%s: muted = %t, %v", test.strip, muted, err)
+		}
+		dev.ExpectMessage(test.mix + "/on")
+		if db, err := mixer.Fader(ctx, test.strip); err != nil || db != QuantizeFader(-10) {
+			t.Errorf("%s: fader = %g, %v", test.strip, db, err)
+		}
+		dev.ExpectMessage(test.mix + "/fader")
+
+		if err := mixer.Unmute(test.strip); err != nil {
+			t.Fatalf("%s: error unmuting: %s", test.strip, err)
+		}
+		dev.ExpectMessage(test.mix+"/on", int32(1))
+		if muted, err := mixer.Muted(ctx, test.strip); err != nil || muted {
+			t.Errorf("%s: muted = %t, %v", test.strip, muted, err)
+		}
+		dev.ExpectMessage(test.mix + "/on")
+	}
+}
+
+func TestStripRanges(t *testing.T) {
+	var b packetBuffer
+	mixer := NewMixer(&b)
+	for _, s := range []Strip{
+		Channel(0), Channel(33), AuxIn(9), FXReturn(0), Bus(17),
+		Matrix(7), DCA(9), MainBus(2),
+	} {
+		if err := mixer.Mute(s); err == nil {
+			t.Errorf("%s: expected out of range error", s)
+		}
+		if err := mixer.SetName(s, "foo"); err == nil {
+			t.Errorf("%s: expected out of range error", s)
+		}
+	}
+	if err := mixer.SetName(Bus(1), "badTooLongName"); err == nil {
+		t.Error("expected error for name too long")
+	}
+	if b.Len() != 0 {
+		t.Errorf("unexpected packets written %q", b.String())
+	}
+}
+
+func TestStripString(t *testing.T) {
+	for s, want := range map[Strip]string{
+		Channel(1):  "channel 1",
+		AuxIn(2):    "aux input 2",
+		FXReturn(3): "FX return 3",
+		Bus(16):     "bus 16",
+		Matrix(6):   "matrix 6",
+		DCA(8):      "DCA 8",
+		Main:        "main",
+		Mono:        "mono",
+		MainBus(2):  "MainBus(2)",
+	} {
+		if got := s.String(); got != want {
+			t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+		}
+	}
+}
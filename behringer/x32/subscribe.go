@@ -0,0 +1,86 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// XRemoteInterval is how often Subscribe renews /xremote. The mixer stops
+// pushing updates to a client 10 seconds after its last renewal.
+const XRemoteInterval = 9 * time.Second
+
+// renewInterval is XRemoteInterval, shortened by the tests.
+var renewInterval = XRemoteInterval
+
+// ErrSubscribed is returned by Subscribe if the mixer is already subscribed.
+var ErrSubscribed = errors.New("already subscribed to the mixer")
+
+// Subscribe subscribes to the changes of the mixer's state with /xremote,
+// renewing it every XRemoteInterval, and passes the packets pushed by the
+// mixer to the handler, e.g. an osc.Dispatcher, until the context is done or
+// reading fails for good, e.g. because the connection is closed. It returns
+// the context's error or the read error. If the handler is nil, the packets
+// are discarded.
+//
+// The replies to queries such as Info are passed to the queries rather than
+// to the handler. The handler is called from the goroutine reading from the
+// mixer, so it must not query the mixer itself.
+func (m Mixer) Subscribe(ctx context.Context, h osc.Handler) error {
+	r := m.reader
+	if r == nil {
+		return errNoReader
+	}
+	r.mu.Lock()
+	if r.xremote {
+		r.mu.Unlock()
+		return ErrSubscribed
+	}
+	r.xremote = true
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.xremote = false
+		r.mu.Unlock()
+	}()
+
+	if h == nil {
+		h = discardHandler{}
+	}
+	u := r.attach("", h)
+	defer r.detach(u)
+	if err := m.WriteMessage("/xremote", ""); err != nil {
+		return err
+	}
+	return m.renew(ctx, u, "/xremote", "")
+}
+
+// renew sends the message every renewInterval until the context is done or
+// the reader fails, and returns the context's error or the read error.
+func (m Mixer) renew(ctx context.Context, u *user, addr, typeTag string, args ...interface{}) error {
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.WriteMessage(addr, typeTag, args...)
+		case err := <-u.errc:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// discardHandler is an osc.Handler that ignores the packets.
+type discardHandler struct{}
+
+func (discardHandler) ServeOSC(osc.Packet, net.Addr) {}
@@ -0,0 +1,159 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package x32
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+	"github.com/goaudiovideo/osc/osctest"
+)
+
+func TestSubscribe(t *testing.T) {
+	renewInterval = 20 * time.Millisecond
+	defer func() { renewInterval = XRemoteInterval }()
+
+	dev, conn := osctest.NewDevice(t)
+	dev.Reply("/info", stringMsg("/info", "V2.05", "osc-server", "X32", "4.06"))
+	mixer := NewMixer(conn)
+
+	updates := make(chan osc.Packet, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- mixer.Subscribe(ctx, osc.HandlerFunc(func(p osc.Packet, from net.Addr) {
+			updates <- p
+		}))
+	}()
+	dev.ExpectMessage("/xremote")
+	dev.ExpectMessage("/xremote")
+
+	if err := mixer.Subscribe(ctx, nil); err != ErrSubscribed {
+		t.Errorf("expected ErrSubscribed, got %v", err)
+	}
+
+	update := &osc.Msg{Address: "/ch/01/mix/fader", TypeTag: "f", Args: []interface{}{float32(0.75)}}
+	if err := dev.Send(update); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case p := <-updates:
+		if got, want := p.String(), update.String(); got != want {
+			t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no update received")
+	}
+
+	// Replies to queries are not passed to the handler.
+	qctx, qcancel := context.WithTimeout(context.Background(), time.Second)
+	defer qcancel()
+	info, err := mixer.Info(qctx)
+	if err != nil {
+		t.Fatalf("error getting info while subscribed: %s", err)
+	}
+	if info.ConsoleModel != "X32" {
+		t.Errorf("unexpected info %+v", info)
+	}
+	select {
+	case p := <-updates:
+		t.Errorf("unexpected update %s", p)
+	default:
+	}
+
+	cancel()
+	select {
+	case err := <-errc:
+		if err != context.Canceled {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe did not return after cancellation")
+	}
+	n := len(dev.Messages())
+	time.Sleep(3 * renewInterval)
+	if len(dev.Messages()) != n {
+		t.Error("/xremote renewed after cancellation")
+	}
+}
+
+func TestSubscribeReadDeadline(t *testing.T) {
+	dev, conn := osctest.NewDevice(t)
+	mixer := NewMixer(conn)
+
+	// A read deadline expiring while subscribed does not end the subscription.
+	conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	updates := make(chan osc.Packet, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- mixer.Subscribe(ctx, osc.HandlerFunc(func(p osc.Packet, from net.Addr) {
+			updates <- p
+		}))
+	}()
+	dev.ExpectMessage("/xremote")
+	time.Sleep(50 * time.Millisecond)
+	update := &osc.Msg{Address: "/ch/01/mix/on", TypeTag: "i", Args: []interface{}{int32(1)}}
+	if err := dev.Send(update); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-updates:
+	case err := <-errc:
+		t.Fatalf("Subscribe returned %v after the deadline", err)
+	case <-time.After(time.Second):
+		t.Fatal("no update received")
+	}
+
+	// The deadline set with the mixer is restored when the reads stop.
+	if err := mixer.SetReadDeadline(time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	mixer.reader.mu.Lock()
+	stopped := mixer.reader.stopped
+	mixer.reader.mu.Unlock()
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	<-stopped
+	read := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadPacket()
+		read <- err
+	}()
+	select {
+	case err := <-read:
+		if !isTimeout(err) {
+			t.Errorf("expected a timeout, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("read deadline not restored")
+	}
+}
+
+func TestZeroMixer(t *testing.T) {
+	var mixer Mixer
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := mixer.Subscribe(ctx, nil); err != errNoReader {
+		t.Errorf("Subscribe: expected errNoReader, got %v", err)
+	}
+	if _, err := mixer.Info(ctx); err == nil {
+		t.Error("Info: expected error")
+	}
+	if _, err := mixer.Meters(ctx, 0); err != errNoReader {
+		t.Errorf("Meters: expected errNoReader, got %v", err)
+	}
+	if err := mixer.SetReadDeadline(time.Time{}); err != errNoReader {
+		t.Errorf("SetReadDeadline: expected errNoReader, got %v", err)
+	}
+}
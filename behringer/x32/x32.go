@@ -10,37 +10,47 @@ using Open Sound Control (OSC) remote protocol.
 package x32
 
 import (
-	"bufio"
-	"fmt"
-	"io"
+	"context"
+	"time"
 
 	"github.com/goaudiovideo/osc"
 )
 
-// Info models the info received back from the mixer.
-type Info struct {
-	ServerVersion  string
-	ServerName     string
-	ConsoleModel   string
-	ConsoleVersion string
-}
-
 // Mixer models a Behringer X32 mixer that can be controlled using Open Sound
-// Control (OSC).
+// Control (OSC). The methods that query the mixer wait for its reply until
+// their context is done, or for DefaultTimeout if it has no deadline.
 type Mixer struct {
-	rw io.ReadWriter
+	conn   osc.Conn
+	reader *reader
 }
 
-// NewMixer creates a new Mixer using the given writer.
-func NewMixer(rw io.ReadWriter) Mixer {
+// NewMixer creates a new Mixer using the given connection, typically an
+// osc.Client dialed to UDP port 10023 of the mixer.
+func NewMixer(conn osc.Conn) Mixer {
 	return Mixer{
-		rw: rw,
+		conn:   conn,
+		reader: newReader(conn),
 	}
 }
 
-// Write implements the Writer interface for Mixer.
+// Write implements the Writer interface for Mixer. The given byte slice must
+// hold a single encoded OSC packet.
 func (m Mixer) Write(p []byte) (int, error) {
-	return m.rw.Write(p)
+	if err := m.conn.WritePacket(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// SetReadDeadline sets the read deadline of the mixer's connection. Queries,
+// subscriptions and meter streams read from the connection regardless of its
+// deadline, which is set when they stop reading; use SetReadDeadline rather
+// than setting it on the connection, so that it is kept.
+func (m Mixer) SetReadDeadline(t time.Time) error {
+	if m.reader == nil {
+		return errNoReader
+	}
+	return m.reader.setReadDeadline(t)
 }
 
 // WriteMessage writes the OSC message.
@@ -53,84 +63,65 @@ func (m Mixer) WriteMessage(addr, typeTag string, args ...interface{}) error {
 	return err
 }
 
-// Info returns information about the X32 Mixer.
-// FIXME(mdr): Not working! Need to create functions to handle reading OSC
-// messages.
-func (m Mixer) Info() (Info, error) {
-	info := Info{}
-	err := m.WriteMessage("/info", "s")
-	if err != nil {
-		return info, err
-	}
-	p := make([]byte, 128)
-	_, err = bufio.NewReader(m.rw).Read(p)
-	if err != nil {
-		return info, err
-	}
-	return info, nil
+// ChannelColor returns the color of the given channel.
+func (m Mixer) ChannelColor(ctx context.Context, ch int) (Color, error) {
+	return m.Color(ctx, Channel(ch))
+}
+
+// ChannelIcon returns the icon of the given channel.
+func (m Mixer) ChannelIcon(ctx context.Context, ch int) (Icon, error) {
+	return m.Icon(ctx, Channel(ch))
+}
+
+// ChannelMuted reports whether the given channel is muted.
+func (m Mixer) ChannelMuted(ctx context.Context, ch int) (bool, error) {
+	return m.Muted(ctx, Channel(ch))
+}
+
+// ChannelName returns the name of the given channel.
+func (m Mixer) ChannelName(ctx context.Context, ch int) (string, error) {
+	return m.Name(ctx, Channel(ch))
+}
+
+// MainMuted reports whether the main channel is muted.
+func (m Mixer) MainMuted(ctx context.Context) (bool, error) {
+	return m.Muted(ctx, Main)
 }
 
 // MuteChannel mutes the given channel.
 func (m Mixer) MuteChannel(ch int) error {
-	if !validChannelRange(ch) {
-		return fmt.Errorf("channel %d out of range 1-32", ch)
-	}
-	addr := fmt.Sprintf("/ch/%02d/mix/on", ch)
-	return m.WriteMessage(addr, "i", 0)
+	return m.Mute(Channel(ch))
 }
 
 // MuteMain mutes the main channel.
 func (m Mixer) MuteMain() error {
-	return m.WriteMessage("/main/st/mix/on", "i", 0)
+	return m.Mute(Main)
 }
 
 // NameChannel sets the name of the given channel. The name can only be up to
 // 12 characters.
 func (m Mixer) NameChannel(ch int, name string) error {
-	if !validChannelRange(ch) {
-		return fmt.Errorf("channel %d out of range 1-32", ch)
-	}
-	if len(name) > 12 {
-		return fmt.Errorf("channel name %s too long (12 char limit)", name)
-	}
-	addr := fmt.Sprintf("/ch/%02d/config/name", ch)
-	return m.WriteMessage(addr, "s", name)
+	return m.SetName(Channel(ch), name)
 }
 
 // SetChannelColor sets the color for the given channel.
 func (m Mixer) SetChannelColor(ch int, color Color) error {
-	if !validChannelRange(ch) {
-		return fmt.Errorf("channel %d out of range 1-32", ch)
-	}
-	addr := fmt.Sprintf("/ch/%02d/config/color", ch)
-	return m.WriteMessage(addr, "i", int(color))
+	return m.SetColor(Channel(ch), color)
 }
 
 // SetChannelIcon sets the icon for the given channel.
 func (m Mixer) SetChannelIcon(ch int, icon Icon) error {
-	if !validChannelRange(ch) {
-		return fmt.Errorf("channel %d out of range 1-32", ch)
-	}
-	addr := fmt.Sprintf("/ch/%02d/config/icon", ch)
-	return m.WriteMessage(addr, "i", int(icon))
+	return m.SetIcon(Channel(ch), icon)
 }
 
 // UnmuteChannel unmutes the given channel.
 func (m Mixer) UnmuteChannel(ch int) error {
-	if !validChannelRange(ch) {
-		return fmt.Errorf("channel %d out of range 1-32", ch)
-	}
-	addr := fmt.Sprintf("/ch/%02d/mix/on", ch)
-	return m.WriteMessage(addr, "i", 1)
+	return m.Unmute(Channel(ch))
 }
 
 // UnmuteMain unmutes the main channel.
 func (m Mixer) UnmuteMain() error {
-	return m.WriteMessage("/main/st/mix/on", "i", 1)
-}
-
-func validChannelRange(ch int) bool {
-	return ch > 0 && ch < 33
+	return m.Unmute(Main)
 }
 
 // dbLevelToDecimal converts a dB level from -90.0 dB to +10.0 dB to a decimal
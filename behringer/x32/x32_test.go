@@ -7,10 +7,39 @@ package x32
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
 )
 
+// packetBuffer is an osc.Conn that records the packets written to it.
+type packetBuffer struct {
+	bytes.Buffer
+}
+
+func (b *packetBuffer) WritePacket(p []byte) error {
+	_, err := b.Write(p)
+	return err
+}
+
+func (b *packetBuffer) ReadPacket() ([]byte, net.Addr, error) {
+	return nil, nil, io.EOF
+}
+
+func (b *packetBuffer) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (b *packetBuffer) Close() error {
+	return nil
+}
+
 func TestMuteChannel(t *testing.T) {
 	var tests = []struct {
 		channel     int
@@ -26,7 +55,7 @@ func TestMuteChannel(t *testing.T) {
 	for _, test := range tests {
 		name := fmt.Sprintf("ch%2d", test.channel)
 		t.Run(name, func(t *testing.T) {
-			var b bytes.Buffer
+			var b packetBuffer
 			mixer := NewMixer(&b)
 			err := mixer.MuteChannel(test.channel)
 			if test.expectError {
@@ -61,7 +90,7 @@ func TestUnmuteChannel(t *testing.T) {
 	for _, test := range tests {
 		name := fmt.Sprintf("ch%2d", test.channel)
 		t.Run(name, func(t *testing.T) {
-			var b bytes.Buffer
+			var b packetBuffer
 			mixer := NewMixer(&b)
 			err := mixer.UnmuteChannel(test.channel)
 			if test.expectError {
@@ -83,7 +112,7 @@ func TestUnmuteChannel(t *testing.T) {
 
 func TestMuteMain(t *testing.T) {
 	want := "/main/st/mix/on\x00,i\x00\x00\x00\x00\x00\x00"
-	var b bytes.Buffer
+	var b packetBuffer
 	mixer := NewMixer(&b)
 	if err := mixer.MuteMain(); err != nil {
 		t.Errorf("error muting main: %s", err)
@@ -96,7 +125,7 @@ func TestMuteMain(t *testing.T) {
 
 func TestUnmuteMain(t *testing.T) {
 	want := "/main/st/mix/on\x00,i\x00\x00\x00\x00\x00\x01"
-	var b bytes.Buffer
+	var b packetBuffer
 	mixer := NewMixer(&b)
 	if err := mixer.UnmuteMain(); err != nil {
 		t.Errorf("error unmuting main: %s", err)
@@ -123,7 +152,7 @@ func TestNameChannel(t *testing.T) {
 	for _, test := range tests {
 		name := fmt.Sprintf("ch%02d_%s", test.channel, test.name)
 		t.Run(name, func(t *testing.T) {
-			var b bytes.Buffer
+			var b packetBuffer
 			mixer := NewMixer(&b)
 			err := mixer.NameChannel(test.channel, test.name)
 			if test.expectError {
@@ -160,7 +189,7 @@ func TestSetChannelIcon(t *testing.T) {
 	for _, test := range tests {
 		name := fmt.Sprintf("ch%02d_%s", test.channel, test.icon)
 		t.Run(name, func(t *testing.T) {
-			var b bytes.Buffer
+			var b packetBuffer
 			mixer := NewMixer(&b)
 			err := mixer.SetChannelIcon(test.channel, test.icon)
 			if test.expectError {
@@ -195,7 +224,7 @@ func TestSetChannelColor(t *testing.T) {
 	for _, test := range tests {
 		name := fmt.Sprintf("ch%02d_%s", test.channel, test.color)
 		t.Run(name, func(t *testing.T) {
-			var b bytes.Buffer
+			var b packetBuffer
 			mixer := NewMixer(&b)
 			err := mixer.SetChannelColor(test.channel, test.color)
 			if test.expectError {
@@ -268,3 +297,119 @@ func TestConvertDecimalToDB(t *testing.T) {
 		})
 	}
 }
+
+func TestChannelGetters(t *testing.T) {
+	_, mixer := newEchoDevice(t, "/ch/*/config/*", "/*/*/mix/on")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := mixer.NameChannel(3, "Snare"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mixer.SetChannelColor(3, YellowBackground); err != nil {
+		t.Fatal(err)
+	}
+	if err := mixer.SetChannelIcon(3, SnareDrumAboveSticks); err != nil {
+		t.Fatal(err)
+	}
+	if err := mixer.MuteChannel(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := mixer.UnmuteMain(); err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := mixer.ChannelName(ctx, 3)
+	if err != nil || name != "Snare" {
+		t.Errorf("\t got = %q, %v\n\t\t\twant = Snare", name, err)
+	}
+	color, err := mixer.ChannelColor(ctx, 3)
+	if err != nil || color != YellowBackground {
+		t.Errorf("\t got = %s, %v\n\t\t\twant = %s", color, err, Color(YellowBackground))
+	}
+	icon, err := mixer.ChannelIcon(ctx, 3)
+	if err != nil || icon != SnareDrumAboveSticks {
+		t.Errorf("\t got = %s, %v\n\t\t\twant = %s", icon, err, Icon(SnareDrumAboveSticks))
+	}
+	muted, err := mixer.ChannelMuted(ctx, 3)
+	if err != nil || !muted {
+		t.Errorf("\t got = %t, %v\n\t\t\twant = true", muted, err)
+	}
+	muted, err = mixer.MainMuted(ctx)
+	if err != nil || muted {
+		t.Errorf("\t got = %t, %v\n\t\t\twant = false", muted, err)
+	}
+
+	// The color is not an int.
+	if err := mixer.WriteMessage("/ch/04/config/color", "s", "red"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mixer.ChannelColor(ctx, 4); err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected error decoding string color")
+	}
+	if _, err := mixer.ChannelName(ctx, 33); err == nil {
+		t.Error("expected error for channel 33")
+	}
+
+	// The mixer does not know channel 5.
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := mixer.ChannelName(short, 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestGetParamsBound(t *testing.T) {
+	conn, device := osc.Pipe()
+	defer conn.Close()
+	mixer := NewMixer(conn)
+
+	// The device holds the queries until no more arrive, then replies to all
+	// of them at once.
+	maxPending := make(chan int, 1)
+	go func() {
+		var pending []string
+		max := 0
+		for {
+			device.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+			b, _, err := device.ReadPacket()
+			if err == nil {
+				m, _ := osc.ParseMessage(b)
+				pending = append(pending, m.Address)
+				continue
+			}
+			if !isTimeout(err) {
+				maxPending <- max
+				return
+			}
+			if len(pending) > max {
+				max = len(pending)
+			}
+			for _, addr := range pending {
+				reply, _ := osc.Message(addr, "i", 1)
+				device.WritePacket(reply)
+			}
+			pending = nil
+		}
+	}()
+
+	flags := make([]bool, 3*maxQueries)
+	var params []param
+	for i := range flags {
+		params = append(params, onOff(fmt.Sprintf("%d", i), &flags[i]))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := mixer.getParams(ctx, "/p/", params); err != nil {
+		t.Fatalf("error getting params: %s", err)
+	}
+	for i, on := range flags {
+		if !on {
+			t.Errorf("param %d not decoded", i)
+		}
+	}
+	device.Close()
+	if max := <-maxPending; max > maxQueries {
+		t.Errorf("%d queries pending, want at most %d", max, maxQueries)
+	}
+}
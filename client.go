@@ -0,0 +1,176 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// maxDatagram is the size of the largest UDP payload.
+const maxDatagram = 65535
+
+// Client sends OSC packets to a single server and receives its replies over
+// UDP or TCP. Client implements Conn, so it can be used by device packages.
+type Client struct {
+	// Observer, if not nil, observes the packets sent and received.
+	Observer Observer
+
+	conn net.Conn
+	r    *bufio.Reader // nil for datagram connections
+}
+
+// Dial connects to the OSC server at the address on the named network, which
+// must be one of "udp", "udp4", "udp6", "tcp", "tcp4" or "tcp6". Packets sent
+// over TCP are framed with their size as specified by OSC 1.0.
+func Dial(network, addr string) (*Client, error) {
+	if !strings.HasPrefix(network, "udp") && !strings.HasPrefix(network, "tcp") {
+		return nil, fmt.Errorf("unsupported network %s", network)
+	}
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient creates a Client using the given connection. Connections that
+// are not net.PacketConns, such as TCP connections, are treated as streams
+// of size-prefixed packets.
+func NewClient(conn net.Conn) *Client {
+	c := &Client{conn: conn}
+	if _, ok := conn.(net.PacketConn); !ok {
+		c.r = bufio.NewReader(conn)
+	}
+	return c
+}
+
+// Write implements the Writer interface for Client. The given byte slice must
+// hold a single encoded OSC packet.
+func (c *Client) Write(p []byte) (int, error) {
+	if c.r == nil {
+		n, err := c.conn.Write(p)
+		if err == nil {
+			c.observe(PacketSent, n)
+		}
+		return n, err
+	}
+	if err := writeFrame(c.conn, p); err != nil {
+		return 0, err
+	}
+	c.observe(PacketSent, len(p))
+	return len(p), nil
+}
+
+func (c *Client) observe(kind EventKind, size int) {
+	observe(c.Observer, Event{Kind: kind, Peer: c.conn.RemoteAddr(), Size: size})
+}
+
+// Read implements the Reader interface for Client by reading a single
+// encoded OSC packet into p. It returns io.ErrShortBuffer if the packet from
+// a stream does not fit into p.
+func (c *Client) Read(p []byte) (int, error) {
+	if c.r == nil {
+		n, err := c.conn.Read(p)
+		if err == nil {
+			c.observe(PacketReceived, n)
+		}
+		return n, err
+	}
+	b, err := readFrame(c.r)
+	if err != nil {
+		return 0, err
+	}
+	c.observe(PacketReceived, len(b))
+	if len(b) > len(p) {
+		return 0, io.ErrShortBuffer
+	}
+	return copy(p, b), nil
+}
+
+// Send sends the OSC packet.
+func (c *Client) Send(p Packet) error {
+	b, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = c.Write(b)
+	return err
+}
+
+// WriteMessage writes the OSC message.
+func (c *Client) WriteMessage(addr, typeTag string, args ...interface{}) error {
+	msg, err := Message(addr, typeTag, args...)
+	if err != nil {
+		return err
+	}
+	_, err = c.Write(msg)
+	return err
+}
+
+// Receive waits for and decodes the next OSC packet from the server.
+func (c *Client) Receive() (Packet, error) {
+	b, _, err := c.ReadPacket()
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParsePacket(b)
+	if err != nil {
+		observe(c.Observer, Event{Kind: DecodeError, Peer: c.conn.RemoteAddr(), Size: len(b), Err: err})
+	}
+	return p, err
+}
+
+// WritePacket implements the Conn interface for Client.
+func (c *Client) WritePacket(p []byte) error {
+	_, err := c.Write(p)
+	return err
+}
+
+// ReadPacket implements the Conn interface for Client. The packets are from
+// the server's address.
+func (c *Client) ReadPacket() ([]byte, net.Addr, error) {
+	if c.r != nil {
+		b, err := readFrame(c.r)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.observe(PacketReceived, len(b))
+		return b, c.conn.RemoteAddr(), nil
+	}
+	b := make([]byte, maxDatagram)
+	n, err := c.conn.Read(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.observe(PacketReceived, n)
+	return append([]byte(nil), b[:n]...), c.conn.RemoteAddr(), nil
+}
+
+// SetReadDeadline sets the deadline for Read and Receive. A zero value for t
+// means they will not time out.
+func (c *Client) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// LocalAddr returns the local network address.
+func (c *Client) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr returns the address of the server.
+func (c *Client) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
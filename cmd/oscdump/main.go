@@ -0,0 +1,141 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+/*
+Command oscdump listens for OSC packets and prints them as they arrive.
+
+Usage:
+
+	oscdump [flags] [host]:port
+
+Each packet is printed on its own line with the time it was received and the
+address of its sender, followed by the packet in the text syntax of
+osc.ParseText or, with -json, as a JSON object:
+
+	oscdump :10024
+	oscdump -filter '/ch/{01,02}/mix/fader' -filter '/main/st/mix/*' :10024
+
+The flags are:
+
+	-tcp
+		listen on TCP instead of UDP
+	-json
+		print each packet as a JSON object
+	-filter pattern
+		only print messages whose address matches the OSC address pattern;
+		may be repeated
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// patterns is a repeatable flag of OSC address patterns.
+type patterns []string
+
+func (p *patterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patterns) Set(s string) error {
+	if !strings.HasPrefix(s, "/") {
+		return fmt.Errorf("invalid address pattern %s", s)
+	}
+	*p = append(*p, s)
+	return nil
+}
+
+// printer prints the received packets.
+type printer struct {
+	mu      sync.Mutex
+	filters patterns
+	json    bool
+}
+
+func main() {
+	var pr printer
+	tcp := flag.Bool("tcp", false, "listen on TCP instead of UDP")
+	flag.BoolVar(&pr.json, "json", false, "print each packet as a JSON object")
+	flag.Var(&pr.filters, "filter", "only print messages matching the OSC address `pattern`; may be repeated")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: oscdump [flags] [host]:port")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	network := "udp"
+	if *tcp {
+		network = "tcp"
+	}
+	s := &osc.Server{Handler: &pr}
+	if err := s.ListenAndServe(network, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, "oscdump:", err)
+		os.Exit(1)
+	}
+}
+
+// ServeOSC implements the osc.Handler interface for printer.
+func (pr *printer) ServeOSC(p osc.Packet, from net.Addr) {
+	now := time.Now()
+	if p = pr.filter(p); p == nil {
+		return
+	}
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	if !pr.json {
+		fmt.Printf("%s %s %s\n", now.Format("15:04:05.000000"), from, p)
+		return
+	}
+	b, err := json.Marshal(struct {
+		Time   time.Time  `json:"time"`
+		From   string     `json:"from"`
+		Packet osc.Packet `json:"packet"`
+	}{now, from.String(), p})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "oscdump:", err)
+		return
+	}
+	fmt.Println(string(b))
+}
+
+// filter returns the packet with only the messages that match the filters,
+// or nil if there are none.
+func (pr *printer) filter(p osc.Packet) osc.Packet {
+	if len(pr.filters) == 0 {
+		return p
+	}
+	switch p := p.(type) {
+	case *osc.Msg:
+		for _, f := range pr.filters {
+			if osc.Match(f, p.Address) {
+				return p
+			}
+		}
+	case *osc.Bundle:
+		filtered := &osc.Bundle{Time: p.Time}
+		for _, elem := range p.Packets {
+			if elem = pr.filter(elem); elem != nil {
+				filtered.Packets = append(filtered.Packets, elem)
+			}
+		}
+		if len(filtered.Packets) > 0 {
+			return filtered
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+/*
+Command oscproxy forwards OSC messages between devices, rewriting, scaling,
+filtering and fanning them out according to the rules of a JSON configuration
+file.
+
+Usage:
+
+	oscproxy config.json
+
+See package oscproxy for the format of the configuration file.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/goaudiovideo/osc/oscproxy"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: oscproxy config.json")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	c, err := oscproxy.LoadConfig(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "oscproxy:", err)
+		os.Exit(1)
+	}
+	if err := oscproxy.ListenAndServe(c); err != nil {
+		fmt.Fprintln(os.Stderr, "oscproxy:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+/*
+Command oscsend sends an OSC message or bundle and optionally waits for the
+reply.
+
+Usage:
+
+	oscsend [flags] host:port packet...
+
+The packet is given in the text syntax of osc.ParseText, either as a single
+argument or spread across several. Quote strings that contain spaces so that
+they reach oscsend intact:
+
+	oscsend 192.168.1.10:10023 /ch/01/mix/fader ,f 0.75
+	oscsend 192.168.1.10:10023 '/ch/01/config/name ,s "Kick In"'
+	oscsend -wait 1s 192.168.1.10:10023 /info
+
+The flags are:
+
+	-tcp
+		send over TCP instead of UDP
+	-wait duration
+		wait up to duration for a reply and print it
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+func main() {
+	tcp := flag.Bool("tcp", false, "send over TCP instead of UDP")
+	wait := flag.Duration("wait", 0, "wait up to `duration` for a reply and print it")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: oscsend [flags] host:port packet...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), strings.Join(flag.Args()[1:], " "), *tcp, *wait); err != nil {
+		fmt.Fprintln(os.Stderr, "oscsend:", err)
+		os.Exit(1)
+	}
+}
+
+func run(addr, text string, tcp bool, wait time.Duration) error {
+	p, err := osc.ParseText(text)
+	if err != nil {
+		return err
+	}
+	network := "udp"
+	if tcp {
+		network = "tcp"
+	}
+	c, err := osc.Dial(network, addr)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if err := c.Send(p); err != nil {
+		return err
+	}
+	if wait <= 0 {
+		return nil
+	}
+	if err := c.SetReadDeadline(time.Now().Add(wait)); err != nil {
+		return err
+	}
+	reply, err := c.Receive()
+	if err != nil {
+		return fmt.Errorf("waiting for reply: %w", err)
+	}
+	fmt.Println(reply)
+	return nil
+}
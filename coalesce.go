@@ -0,0 +1,202 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Coalescer wraps the writer for a single destination and limits the rate at
+// which OSC messages are sent to it. Messages written to the same address
+// between flushes are coalesced so that only the last value is sent, which
+// keeps a dragged fader from flooding the control link.
+//
+// Messages are queued by Write and WriteMessage and sent by Run on every tick
+// of the flush interval or immediately by Flush.
+type Coalescer struct {
+	// Limit caps the number of messages sent on each tick of the flush
+	// interval. Messages beyond the limit stay queued, and keep being
+	// coalesced, until the next tick. Zero means no limit.
+	Limit int
+
+	// NoCoalesce reports whether messages to the given address are not
+	// idempotent and therefore must each be sent in order instead of being
+	// replaced by later messages. Bundles are never coalesced. Such messages
+	// and bundles are barriers: a message written after them is never
+	// coalesced with one written before them.
+	NoCoalesce func(addr string) bool
+
+	// Observer, if not nil, observes the messages dropped because a later
+	// message to the same address replaced them.
+	Observer Observer
+
+	w        io.Writer
+	interval time.Duration
+
+	// wmu serializes flushes so that batches reach the writer in order.
+	wmu   sync.Mutex
+	mu    sync.Mutex
+	queue []queued
+	index map[string]int
+}
+
+type queued struct {
+	addr string
+	msg  []byte
+}
+
+// DefaultCoalesceInterval is the flush interval used by a Coalescer created
+// with an interval that is not positive.
+const DefaultCoalesceInterval = 20 * time.Millisecond
+
+// NewCoalescer creates a Coalescer that writes to w and flushes every
+// interval once Run is called, or every DefaultCoalesceInterval if interval
+// is not positive. Limit and NoCoalesce must be set before the first message
+// is written.
+func NewCoalescer(w io.Writer, interval time.Duration) *Coalescer {
+	if interval <= 0 {
+		interval = DefaultCoalesceInterval
+	}
+	return &Coalescer{
+		w:        w,
+		interval: interval,
+		index:    make(map[string]int),
+	}
+}
+
+// Write implements the Writer interface for Coalescer. The given byte slice
+// must hold a single encoded OSC message or bundle, which is copied and queued.
+func (c *Coalescer) Write(p []byte) (int, error) {
+	addr, err := packetAddress(p)
+	if err != nil {
+		return 0, err
+	}
+	msg := make([]byte, len(p))
+	copy(msg, p)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if addr == bundleTag || (c.NoCoalesce != nil && c.NoCoalesce(addr)) {
+		c.queue = append(c.queue, queued{msg: msg})
+		c.index = make(map[string]int)
+		return len(p), nil
+	}
+	if i, ok := c.index[addr]; ok {
+		observe(c.Observer, Event{Kind: MessageDropped, Address: addr, Size: len(c.queue[i].msg)})
+		c.queue[i].msg = msg
+		return len(p), nil
+	}
+	c.index[addr] = len(c.queue)
+	c.queue = append(c.queue, queued{addr: addr, msg: msg})
+	return len(p), nil
+}
+
+// WriteMessage queues the OSC message.
+func (c *Coalescer) WriteMessage(addr, typeTag string, args ...interface{}) error {
+	msg, err := Message(addr, typeTag, args...)
+	if err != nil {
+		return err
+	}
+	_, err = c.Write(msg)
+	return err
+}
+
+// Pending returns the number of queued messages.
+func (c *Coalescer) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue)
+}
+
+// Flush sends all queued messages regardless of Limit. Call it to mark a
+// bundle boundary, that is, when everything written so far must reach the
+// destination before anything written afterwards. If writing fails, the
+// messages that were not sent stay queued.
+func (c *Coalescer) Flush() error {
+	return c.flush(0)
+}
+
+// Run flushes the queue on every tick of the flush interval until the context
+// is done, at which point the remaining messages are flushed. Run returns the
+// first error encountered writing to the underlying writer. The messages that
+// were not sent stay queued, starting with the one that failed.
+func (c *Coalescer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return c.Flush()
+		case <-ticker.C:
+			if err := c.flush(c.Limit); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// flush sends up to limit queued messages, or all of them if limit is zero.
+func (c *Coalescer) flush(limit int) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.mu.Lock()
+	n := len(c.queue)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	batch := c.queue[:n:n]
+	c.queue = c.queue[n:]
+	c.reindex()
+	c.mu.Unlock()
+
+	for i, q := range batch {
+		if _, err := c.w.Write(q.msg); err != nil {
+			// Put the unsent messages back in front of the queue.
+			c.mu.Lock()
+			c.queue = append(batch[i:], c.queue...)
+			c.reindex()
+			c.mu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+// reindex rebuilds the index of the queued messages that can still be
+// coalesced, those after the last barrier. c.mu must be held.
+func (c *Coalescer) reindex() {
+	c.index = make(map[string]int, len(c.queue))
+	for i, q := range c.queue {
+		if q.addr == "" {
+			c.index = make(map[string]int, len(c.queue)-i)
+		} else {
+			c.index[q.addr] = i
+		}
+	}
+}
+
+// bundleTag is the OSC-string that starts every OSC bundle.
+const bundleTag = "#bundle"
+
+// packetAddress returns the address pattern of an encoded OSC message or the
+// bundle tag for an encoded OSC bundle.
+func packetAddress(p []byte) (string, error) {
+	i := bytes.IndexByte(p, 0)
+	if i < 1 {
+		return "", errors.New("packet does not start with an address")
+	}
+	addr := string(p[:i])
+	if addr[0] != '/' && addr != bundleTag {
+		return "", fmt.Errorf("invalid address %s", addr)
+	}
+	return addr, nil
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// packetRecorder records every packet written to it.
+type packetRecorder struct {
+	mu      sync.Mutex
+	packets []string
+}
+
+func (r *packetRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.packets = append(r.packets, string(p))
+	return len(p), nil
+}
+
+func (r *packetRecorder) sent() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.packets...)
+}
+
+func mustMessage(t *testing.T, addr, typeTag string, args ...interface{}) string {
+	t.Helper()
+	msg, err := Message(addr, typeTag, args...)
+	if err != nil {
+		t.Fatalf("error creating message %s: %s", addr, err)
+	}
+	return string(msg)
+}
+
+func TestCoalescerLastValueWins(t *testing.T) {
+	var r packetRecorder
+	c := NewCoalescer(&r, time.Hour)
+	for _, f := range []float32{0.1, 0.2, 0.3} {
+		if err := c.WriteMessage("/ch/01/mix/fader", "f", f); err != nil {
+			t.Fatalf("error writing message: %s", err)
+		}
+	}
+	if err := c.WriteMessage("/ch/02/mix/fader", "f", float32(0.5)); err != nil {
+		t.Fatalf("error writing message: %s", err)
+	}
+	if err := c.WriteMessage("/ch/01/mix/fader", "f", float32(0.4)); err != nil {
+		t.Fatalf("error writing message: %s", err)
+	}
+	if got := c.Pending(); got != 2 {
+		t.Errorf("\t got = %d pending\n\t\t\twant = 2 pending", got)
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatalf("error flushing: %s", err)
+	}
+	want := []string{
+		mustMessage(t, "/ch/01/mix/fader", "f", float32(0.4)),
+		mustMessage(t, "/ch/02/mix/fader", "f", float32(0.5)),
+	}
+	assertPackets(t, r.sent(), want)
+}
+
+func TestCoalescerNoCoalesce(t *testing.T) {
+	var r packetRecorder
+	c := NewCoalescer(&r, time.Hour)
+	c.NoCoalesce = func(addr string) bool {
+		return strings.HasPrefix(addr, "/-action/")
+	}
+	want := []string{
+		mustMessage(t, "/-action/goscene", "i", 1),
+		mustMessage(t, "/-action/goscene", "i", 2),
+		mustMessage(t, "/ch/01/mix/on", "i", 0),
+	}
+	for _, msg := range []string{want[0], want[1], mustMessage(t, "/ch/01/mix/on", "i", 1), want[2]} {
+		if _, err := c.Write([]byte(msg)); err != nil {
+			t.Fatalf("error writing message: %s", err)
+		}
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatalf("error flushing: %s", err)
+	}
+	assertPackets(t, r.sent(), want)
+}
+
+func TestCoalescerBarrier(t *testing.T) {
+	var r packetRecorder
+	c := NewCoalescer(&r, time.Hour)
+	c.NoCoalesce = func(addr string) bool {
+		return strings.HasPrefix(addr, "/-action/")
+	}
+	// A bundle to be executed immediately holding a single message.
+	elem := mustMessage(t, "/ch/01/mix/on", "i", 1)
+	bundle := bundleTag + "\x00" + "\x00\x00\x00\x00\x00\x00\x00\x01" + string([]byte{0, 0, 0, byte(len(elem))}) + elem
+	want := []string{
+		mustMessage(t, "/ch/01/mix/fader", "f", float32(0.5)),
+		mustMessage(t, "/-action/goscene", "i", 1),
+		mustMessage(t, "/ch/01/mix/fader", "f", float32(0.7)),
+		bundle,
+		mustMessage(t, "/ch/01/mix/fader", "f", float32(0.9)),
+	}
+	// The faders written after a barrier are only coalesced with each other.
+	for _, msg := range []string{
+		want[0],
+		want[1],
+		mustMessage(t, "/ch/01/mix/fader", "f", float32(0.6)),
+		want[2],
+		want[3],
+		mustMessage(t, "/ch/01/mix/fader", "f", float32(0.8)),
+		want[4],
+	} {
+		if _, err := c.Write([]byte(msg)); err != nil {
+			t.Fatalf("error writing message: %s", err)
+		}
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatalf("error flushing: %s", err)
+	}
+	assertPackets(t, r.sent(), want)
+}
+
+// failingWriter fails the writes after the first n.
+type failingWriter struct {
+	packetRecorder
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(w.sent()) >= w.n {
+		return 0, errors.New("write failed")
+	}
+	return w.packetRecorder.Write(p)
+}
+
+func TestCoalescerWriteError(t *testing.T) {
+	w := &failingWriter{n: 1}
+	c := NewCoalescer(w, time.Hour)
+	msgs := []string{
+		mustMessage(t, "/ch/01/mix/fader", "f", float32(0.1)),
+		mustMessage(t, "/ch/02/mix/fader", "f", float32(0.2)),
+		mustMessage(t, "/ch/03/mix/fader", "f", float32(0.3)),
+	}
+	for _, msg := range msgs {
+		if _, err := c.Write([]byte(msg)); err != nil {
+			t.Fatalf("error writing message: %s", err)
+		}
+	}
+	if err := c.Flush(); err == nil {
+		t.Fatal("expected error flushing")
+	}
+	if got := c.Pending(); got != 2 {
+		t.Errorf("\t got = %d pending\n\t\t\twant = 2 pending", got)
+	}
+
+	// The unsent messages are still coalesced and sent by the next flush.
+	last := mustMessage(t, "/ch/03/mix/fader", "f", float32(0.4))
+	if _, err := c.Write([]byte(last)); err != nil {
+		t.Fatalf("error writing message: %s", err)
+	}
+	w.n = 3
+	if err := c.Flush(); err != nil {
+		t.Fatalf("error flushing: %s", err)
+	}
+	assertPackets(t, w.sent(), []string{msgs[0], msgs[1], last})
+}
+
+func TestCoalescerLimit(t *testing.T) {
+	var r packetRecorder
+	c := NewCoalescer(&r, time.Millisecond)
+	c.Limit = 1
+	first := mustMessage(t, "/ch/01/mix/fader", "f", float32(0.1))
+	second := mustMessage(t, "/ch/02/mix/fader", "f", float32(0.2))
+	for _, msg := range []string{first, second} {
+		if _, err := c.Write([]byte(msg)); err != nil {
+			t.Fatalf("error writing message: %s", err)
+		}
+	}
+	if err := c.flush(c.Limit); err != nil {
+		t.Fatalf("error flushing: %s", err)
+	}
+	assertPackets(t, r.sent(), []string{first})
+
+	// The queued message is still coalesced until the next tick.
+	third := mustMessage(t, "/ch/02/mix/fader", "f", float32(0.3))
+	if _, err := c.Write([]byte(third)); err != nil {
+		t.Fatalf("error writing message: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("error running coalescer: %s", err)
+	}
+	assertPackets(t, r.sent(), []string{first, third})
+}
+
+func TestCoalescerZeroInterval(t *testing.T) {
+	var r packetRecorder
+	c := NewCoalescer(&r, 0)
+	if c.interval != DefaultCoalesceInterval {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", c.interval, DefaultCoalesceInterval)
+	}
+	msg := mustMessage(t, "/ch/01/mix/fader", "f", float32(0.5))
+	if _, err := c.Write([]byte(msg)); err != nil {
+		t.Fatalf("error writing message: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*DefaultCoalesceInterval)
+	defer cancel()
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("error running coalescer: %s", err)
+	}
+	assertPackets(t, r.sent(), []string{msg})
+}
+
+func TestCoalescerBadPacket(t *testing.T) {
+	c := NewCoalescer(&packetRecorder{}, time.Hour)
+	for _, p := range []string{"", "\x00\x00\x00\x00", "ch/01\x00\x00\x00"} {
+		if _, err := c.Write([]byte(p)); err == nil {
+			t.Errorf("expected error writing packet %q", p)
+		}
+	}
+}
+
+func assertPackets(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("\t got = %d packets %q\n\t\t\twant = %d packets %q", len(got), got, len(want), want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("packet %d\n\t got = %q\n\t\t\twant = %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Conn is a connection that carries whole OSC packets, whatever the
+// transport: UDP and TCP with Client, SLIP with NewSLIPConn and in-memory
+// pipes with Pipe. Device packages accept a Conn so that the same code runs
+// over any transport.
+type Conn interface {
+	// WritePacket sends a single encoded OSC packet.
+	WritePacket(p []byte) error
+
+	// ReadPacket waits for the next encoded OSC packet and returns it with
+	// the address of its sender, which is nil if unknown.
+	ReadPacket() ([]byte, net.Addr, error)
+
+	// SetReadDeadline sets the deadline for ReadPacket. A zero value for t
+	// means ReadPacket will not time out. After the deadline ReadPacket
+	// returns an error wrapping os.ErrDeadlineExceeded.
+	SetReadDeadline(t time.Time) error
+
+	// Close closes the connection.
+	Close() error
+}
+
+// Pipe creates an in-memory pair of connected Conns, typically used to test
+// device packages without a network. Packets written to one end are queued
+// until read from the other, so writes never block. The address of both ends
+// is PipeAddr.
+func Pipe() (Conn, Conn) {
+	a := &pipe{notify: make(chan struct{}, 1)}
+	b := &pipe{notify: make(chan struct{}, 1)}
+	a.peer, b.peer = b, a
+	return a, b
+}
+
+// PipeAddr is the address of the ends of a Pipe.
+type PipeAddr struct{}
+
+// Network implements the net.Addr interface for PipeAddr.
+func (PipeAddr) Network() string {
+	return "pipe"
+}
+
+// String implements the net.Addr interface for PipeAddr.
+func (PipeAddr) String() string {
+	return "pipe"
+}
+
+// pipe is one end of a Pipe.
+type pipe struct {
+	peer   *pipe
+	notify chan struct{} // signaled when the fields below change
+
+	mu         sync.Mutex
+	queue      [][]byte
+	deadline   time.Time
+	closed     bool
+	peerClosed bool
+}
+
+// signal wakes up a pending ReadPacket.
+func (p *pipe) signal() {
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (p *pipe) WritePacket(b []byte) error {
+	p.mu.Lock()
+	closed := p.closed || p.peerClosed
+	p.mu.Unlock()
+	if closed {
+		return io.ErrClosedPipe
+	}
+	q := p.peer
+	q.mu.Lock()
+	q.queue = append(q.queue, append([]byte(nil), b...))
+	q.mu.Unlock()
+	q.signal()
+	return nil
+}
+
+func (p *pipe) ReadPacket() ([]byte, net.Addr, error) {
+	for {
+		p.mu.Lock()
+		switch {
+		case p.closed:
+			p.mu.Unlock()
+			return nil, nil, io.ErrClosedPipe
+		case len(p.queue) > 0:
+			b := p.queue[0]
+			p.queue[0] = nil
+			p.queue = p.queue[1:]
+			p.mu.Unlock()
+			return b, PipeAddr{}, nil
+		case p.peerClosed:
+			p.mu.Unlock()
+			return nil, nil, io.EOF
+		}
+		deadline := p.deadline
+		p.mu.Unlock()
+
+		if deadline.IsZero() {
+			<-p.notify
+			continue
+		}
+		d := time.Until(deadline)
+		if d <= 0 {
+			return nil, nil, &net.OpError{Op: "read", Net: "pipe", Err: os.ErrDeadlineExceeded}
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-p.notify:
+		case <-t.C:
+		}
+		t.Stop()
+	}
+}
+
+func (p *pipe) SetReadDeadline(t time.Time) error {
+	p.mu.Lock()
+	p.deadline = t
+	p.mu.Unlock()
+	p.signal()
+	return nil
+}
+
+func (p *pipe) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.signal()
+	q := p.peer
+	q.mu.Lock()
+	q.peerClosed = true
+	q.mu.Unlock()
+	q.signal()
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPipe(t *testing.T) {
+	a, b := Pipe()
+	for _, p := range []string{"/one\x00\x00\x00\x00,\x00\x00\x00", "/two\x00\x00\x00\x00,\x00\x00\x00"} {
+		if err := a.WritePacket([]byte(p)); err != nil {
+			t.Fatalf("error writing: %s", err)
+		}
+	}
+	for _, want := range []string{"/one\x00\x00\x00\x00,\x00\x00\x00", "/two\x00\x00\x00\x00,\x00\x00\x00"} {
+		got, from, err := b.ReadPacket()
+		if err != nil {
+			t.Fatalf("error reading: %s", err)
+		}
+		if string(got) != want || from != (PipeAddr{}) {
+			t.Errorf("\t got = %q %v\n\t\t\twant = %q pipe", got, from, want)
+		}
+	}
+
+	// A pending read times out when a deadline is set.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	}()
+	_, _, err := b.ReadPacket()
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("got error %v, want deadline exceeded", err)
+	}
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("got error %v, want timeout", err)
+	}
+	b.SetReadDeadline(time.Time{})
+
+	// A pending read returns when the packet arrives.
+	go a.WritePacket([]byte("/three\x00\x00,\x00\x00\x00"))
+	if got, _, err := b.ReadPacket(); err != nil || string(got) != "/three\x00\x00,\x00\x00\x00" {
+		t.Errorf("got %q, %v", got, err)
+	}
+
+	// Packets queued before closing are read before io.EOF.
+	a.WritePacket([]byte("/four\x00\x00\x00,\x00\x00\x00"))
+	a.Close()
+	if err := b.WritePacket([]byte("/five\x00\x00\x00,\x00\x00\x00")); err != io.ErrClosedPipe {
+		t.Errorf("got error %v, want %v", err, io.ErrClosedPipe)
+	}
+	if got, _, err := b.ReadPacket(); err != nil || string(got) != "/four\x00\x00\x00,\x00\x00\x00" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if _, _, err := b.ReadPacket(); err != io.EOF {
+		t.Errorf("got error %v, want %v", err, io.EOF)
+	}
+	if _, _, err := a.ReadPacket(); err != io.ErrClosedPipe {
+		t.Errorf("got error %v, want %v", err, io.ErrClosedPipe)
+	}
+}
+
+func TestClientConn(t *testing.T) {
+	// The servers echo the packets they receive.
+	var tests = []struct {
+		network string
+		listen  func() (string, io.Closer, error)
+	}{
+		{"udp", func() (string, io.Closer, error) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				return "", nil, err
+			}
+			go func() {
+				b := make([]byte, maxDatagram)
+				for {
+					n, from, err := conn.ReadFrom(b)
+					if err != nil {
+						return
+					}
+					conn.WriteTo(b[:n], from)
+				}
+			}()
+			return conn.LocalAddr().String(), conn, nil
+		}},
+		{"tcp", func() (string, io.Closer, error) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				return "", nil, err
+			}
+			go func() {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				for {
+					p, err := readFrame(conn)
+					if err != nil {
+						return
+					}
+					writeFrame(conn, p)
+				}
+			}()
+			return l.Addr().String(), l, nil
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.network, func(t *testing.T) {
+			addr, closer, err := test.listen()
+			if err != nil {
+				t.Fatalf("error listening: %s", err)
+			}
+			defer closer.Close()
+			var c Conn
+			client, err := Dial(test.network, addr)
+			if err != nil {
+				t.Fatalf("error dialing: %s", err)
+			}
+			c = client
+			defer c.Close()
+			want := "/ping\x00\x00\x00,\x00\x00\x00"
+			if err := c.WritePacket([]byte(want)); err != nil {
+				t.Fatalf("error writing: %s", err)
+			}
+			c.SetReadDeadline(time.Now().Add(time.Second))
+			got, from, err := c.ReadPacket()
+			if err != nil {
+				t.Fatalf("error reading: %s", err)
+			}
+			if string(got) != want || from.String() != addr {
+				t.Errorf("\t got = %q %s\n\t\t\twant = %q %s", got, from, want, addr)
+			}
+			c.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+			if _, _, err := c.ReadPacket(); !errors.Is(err, os.ErrDeadlineExceeded) {
+				t.Errorf("got error %v, want deadline exceeded", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MethodFunc handles an OSC message dispatched to an OSC method.
+type MethodFunc func(m *Msg, from net.Addr)
+
+// Dispatcher is a Handler that dispatches each message it receives to every
+// OSC method whose address matches the message's address pattern. The
+// messages of a bundle whose time tag is in the future are dispatched at that
+// time. The zero value is ready to use.
+type Dispatcher struct {
+	// Observer, if not nil, observes the messages dispatched and the late
+	// bundles.
+	Observer Observer
+
+	mu      sync.RWMutex
+	methods map[string]MethodFunc
+	infos   map[string]MethodInfo
+}
+
+// MethodInfo describes an OSC method for namespace discovery, such as by
+// OSCQuery.
+type MethodInfo struct {
+	Address     string
+	TypeTag     string
+	Description string
+
+	// Range holds the range of each argument.
+	Range []Range
+
+	// Value, if set, returns the current arguments of the method, which
+	// makes the method readable.
+	Value func() []interface{}
+
+	// Writable reports whether a MethodFunc is registered at the address.
+	// It is set by Dispatcher.Methods and ignored by Dispatcher.Describe.
+	Writable bool
+}
+
+// Range is the range of an argument's values: from Min to Max inclusive, or
+// one of Vals. Any of the fields may be unset.
+type Range struct {
+	Min  interface{}
+	Max  interface{}
+	Vals []interface{}
+}
+
+// Handle registers the method at the address, replacing any method already
+// registered there. The address must not contain any OSC wildcards.
+func (d *Dispatcher) Handle(addr string, f MethodFunc) error {
+	if err := checkAddress(addr); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.methods == nil {
+		d.methods = make(map[string]MethodFunc)
+	}
+	d.methods[addr] = f
+	return nil
+}
+
+// Describe describes the method at the address given by the info. Methods
+// that are only described, and not registered with Handle, are read-only.
+func (d *Dispatcher) Describe(info MethodInfo) error {
+	if err := checkAddress(info.Address); err != nil {
+		return err
+	}
+	if n := numArgs(info.TypeTag); len(info.Range) > n {
+		return fmt.Errorf("%d ranges for type tag %q", len(info.Range), info.TypeTag)
+	}
+	info.Writable = false
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.infos == nil {
+		d.infos = make(map[string]MethodInfo)
+	}
+	d.infos[info.Address] = info
+	return nil
+}
+
+// Remove removes the method registered or described at the address.
+func (d *Dispatcher) Remove(addr string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.methods, addr)
+	delete(d.infos, addr)
+}
+
+// Methods returns the descriptions of the registered and described methods,
+// sorted by address.
+func (d *Dispatcher) Methods() []MethodInfo {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	addrs := d.sortedLocked()
+	for addr := range d.infos {
+		if _, ok := d.methods[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	infos := make([]MethodInfo, len(addrs))
+	for i, addr := range addrs {
+		info, ok := d.infos[addr]
+		if !ok {
+			info.Address = addr
+		}
+		_, info.Writable = d.methods[addr]
+		infos[i] = info
+	}
+	return infos
+}
+
+// Addresses returns the sorted addresses of the registered methods.
+func (d *Dispatcher) Addresses() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.sortedLocked()
+}
+
+// ServeOSC implements the Handler interface for Dispatcher.
+func (d *Dispatcher) ServeOSC(p Packet, from net.Addr) {
+	switch p := p.(type) {
+	case *Msg:
+		d.Dispatch(p, from)
+	case *Bundle:
+		d.dispatchBundle(p, from)
+	}
+}
+
+// Dispatch immediately dispatches the message to the methods whose addresses
+// match its address pattern, in address order, and returns the number of
+// methods called.
+func (d *Dispatcher) Dispatch(m *Msg, from net.Addr) int {
+	var matched []string
+	var funcs []MethodFunc
+	d.mu.RLock()
+	if f, ok := d.methods[m.Address]; ok {
+		matched, funcs = append(matched, m.Address), append(funcs, f)
+	} else {
+		for _, addr := range d.sortedLocked() {
+			if Match(m.Address, addr) {
+				matched, funcs = append(matched, addr), append(funcs, d.methods[addr])
+			}
+		}
+	}
+	d.mu.RUnlock()
+	if d.Observer == nil {
+		for _, f := range funcs {
+			f(m, from)
+		}
+		return len(funcs)
+	}
+	if len(funcs) == 0 {
+		d.Observer.Observe(Event{Kind: MessageUnhandled, Peer: from, Address: m.Address})
+	}
+	for i, f := range funcs {
+		start := time.Now()
+		f(m, from)
+		d.Observer.Observe(Event{Kind: MessageDispatched, Peer: from, Address: matched[i], Duration: time.Since(start)})
+	}
+	return len(funcs)
+}
+
+// sortedLocked returns the sorted method addresses. The caller must hold mu.
+func (d *Dispatcher) sortedLocked() []string {
+	addrs := make([]string, 0, len(d.methods))
+	for addr := range d.methods {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// dispatchBundle dispatches the bundle now or schedules it for its time tag.
+func (d *Dispatcher) dispatchBundle(b *Bundle, from net.Addr) {
+	if b.Time != Immediately {
+		delay := time.Until(b.Time.Time())
+		if delay > 0 {
+			time.AfterFunc(delay, func() {
+				d.dispatchElements(b, from)
+			})
+			return
+		}
+		observe(d.Observer, Event{Kind: BundleLate, Peer: from, Duration: -delay})
+	}
+	d.dispatchElements(b, from)
+}
+
+func (d *Dispatcher) dispatchElements(b *Bundle, from net.Addr) {
+	for _, p := range b.Packets {
+		switch p := p.(type) {
+		case *Msg:
+			d.Dispatch(p, from)
+		case *Bundle:
+			d.dispatchBundle(p, from)
+		}
+	}
+}
+
+// checkAddress checks that the address is a valid OSC method address.
+func checkAddress(addr string) error {
+	if !strings.HasPrefix(addr, "/") {
+		return fmt.Errorf("invalid address %q", addr)
+	}
+	for _, part := range strings.Split(addr[1:], "/") {
+		if part == "" || strings.ContainsAny(part, " #*,?[]{}") {
+			return fmt.Errorf("invalid address %q", addr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// dispatchRecorder registers methods that record the addresses called.
+type dispatchRecorder struct {
+	mu     sync.Mutex
+	called []string
+}
+
+func (r *dispatchRecorder) method(addr string) MethodFunc {
+	return func(m *Msg, from net.Addr) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.called = append(r.called, addr+" "+m.String())
+	}
+}
+
+func (r *dispatchRecorder) calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.called...)
+}
+
+func TestDispatch(t *testing.T) {
+	var d Dispatcher
+	var r dispatchRecorder
+	for _, addr := range []string{"/ch/01/mix/on", "/ch/02/mix/on", "/ch/01/mix/fader", "/main/st/mix/on"} {
+		if err := d.Handle(addr, r.method(addr)); err != nil {
+			t.Fatalf("error handling %s: %s", addr, err)
+		}
+	}
+	var tests = []struct {
+		pattern string
+		want    []string
+	}{
+		{"/ch/01/mix/on", []string{"/ch/01/mix/on"}},
+		{"/ch/*/mix/on", []string{"/ch/01/mix/on", "/ch/02/mix/on"}},
+		{"/ch/01/mix/*", []string{"/ch/01/mix/fader", "/ch/01/mix/on"}},
+		{"/{ch/02,main/st}/mix/on", []string{"/ch/02/mix/on", "/main/st/mix/on"}},
+		{"/bus/01/mix/on", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			r.called = nil
+			m := &Msg{test.pattern, "i", []interface{}{int32(0)}}
+			if n := d.Dispatch(m, nil); n != len(test.want) {
+				t.Errorf("\t got = %d methods\n\t\t\twant = %d methods", n, len(test.want))
+			}
+			var want []string
+			for _, addr := range test.want {
+				want = append(want, addr+" "+m.String())
+			}
+			if got := r.calls(); !reflect.DeepEqual(got, want) {
+				t.Errorf("\t got = %q\n\t\t\twant = %q", got, want)
+			}
+		})
+	}
+
+	d.Remove("/ch/02/mix/on")
+	want := []string{"/ch/01/mix/fader", "/ch/01/mix/on", "/main/st/mix/on"}
+	if got := d.Addresses(); !reflect.DeepEqual(got, want) {
+		t.Errorf("\t got = %q\n\t\t\twant = %q", got, want)
+	}
+}
+
+func TestDispatchBundle(t *testing.T) {
+	var d Dispatcher
+	var r dispatchRecorder
+	d.Handle("/a", r.method("/a"))
+	d.Handle("/b", r.method("/b"))
+	later := NewTimeTag(time.Now().Add(30 * time.Millisecond))
+	d.ServeOSC(&Bundle{Immediately, []Packet{
+		&Bundle{later, []Packet{&Msg{Address: "/b"}}},
+		&Msg{Address: "/a"},
+	}}, nil)
+	if got, want := r.calls(), []string{"/a /a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("\t got = %q\n\t\t\twant = %q", got, want)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got, want := r.calls(), []string{"/a /a", "/b /b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("\t got = %q\n\t\t\twant = %q", got, want)
+	}
+}
+
+func TestHandleInvalidAddress(t *testing.T) {
+	var d Dispatcher
+	for _, addr := range []string{"", "ch", "/", "/ch//on", "/ch/*/on", "/ch/0?", "/a b"} {
+		if err := d.Handle(addr, func(*Msg, net.Addr) {}); err == nil {
+			t.Errorf("expected error handling %q", addr)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	var d Dispatcher
+	d.Handle("/ch/01/mix/fader", func(*Msg, net.Addr) {})
+	d.Handle("/ch/01/mix/on", func(*Msg, net.Addr) {})
+	fader := MethodInfo{
+		Address:     "/ch/01/mix/fader",
+		TypeTag:     "f",
+		Description: "channel 1 fader",
+		Range:       []Range{{Min: float32(0), Max: float32(1)}},
+		Writable:    false,
+	}
+	meter := MethodInfo{Address: "/meters/1", TypeTag: "b"}
+	for _, info := range []MethodInfo{fader, meter} {
+		if err := d.Describe(info); err != nil {
+			t.Fatalf("error describing %s: %s", info.Address, err)
+		}
+	}
+	if err := d.Describe(MethodInfo{Address: "/a", TypeTag: "i", Range: make([]Range, 2)}); err == nil {
+		t.Error("expected error describing more ranges than arguments")
+	}
+	fader.Writable = true
+	want := []MethodInfo{fader, {Address: "/ch/01/mix/on", Writable: true}, meter}
+	if got := d.Methods(); !reflect.DeepEqual(got, want) {
+		t.Errorf("\t got = %+v\n\t\t\twant = %+v", got, want)
+	}
+	// Described methods without a MethodFunc are not dispatched to.
+	if n := d.Dispatch(&Msg{Address: "/meters/*"}, nil); n != 0 {
+		t.Errorf("\t got = %d methods\n\t\t\twant = 0 methods", n)
+	}
+}
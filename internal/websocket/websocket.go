@@ -0,0 +1,362 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+/*
+Package websocket implements the subset of the WebSocket protocol (RFC 6455)
+needed to carry OSC packets: the opening handshake on both sides, text and
+binary messages, and the ping and close control frames. Extensions are not
+supported.
+*/
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message types, which are the opcodes of their frames.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	closeMessage  = 8
+	pingMessage   = 9
+	pongMessage   = 10
+)
+
+// MaxMessageSize limits the size of the messages read.
+const MaxMessageSize = 1 << 24
+
+// acceptGUID is appended to the client's key to compute the accept key.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrClosed is returned when reading from or writing to a closed connection.
+var ErrClosed = errors.New("websocket closed")
+
+// Conn is a WebSocket connection. Reads must be made from a single goroutine,
+// but writes may be made from any number of goroutines.
+type Conn struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	client bool
+
+	wmu    sync.Mutex
+	closed bool
+}
+
+// Upgrade completes the opening handshake of a WebSocket request and returns
+// the connection. On failure it replies to the request with an HTTP error.
+//
+// checkOrigin reports whether the request is accepted from its Origin header,
+// which browsers set to the origin of the page opening the connection, so
+// that other sites cannot connect through the browser of a user. If it is
+// nil, SameOrigin is used. A rejected request gets a 403 Forbidden reply.
+func Upgrade(w http.ResponseWriter, r *http.Request, checkOrigin func(*http.Request) bool) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "websocket upgrade required", http.StatusUpgradeRequired)
+		return nil, errors.New("not a websocket upgrade request")
+	}
+	if checkOrigin == nil {
+		checkOrigin = SameOrigin
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return nil, fmt.Errorf("origin %s not allowed", r.Header.Get("Origin"))
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusBadRequest)
+		return nil, errors.New("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("response does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(resp)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Conn{conn: conn, r: rw.Reader}, nil
+}
+
+// SameOrigin reports whether the request has no Origin header, as from
+// clients other than browsers, or an Origin whose host matches the Host of the
+// request.
+func SameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// Dial opens a WebSocket connection to the ws:// URL.
+func Dial(ctx context.Context, rawurl string) (*Conn, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" {
+		return nil, fmt.Errorf("unsupported websocket scheme %s", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "80")
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req := "GET " + u.RequestURI() + " HTTP/1.1\r\n" +
+		"Host: " + u.Host + "\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, &http.Request{Method: http.MethodGet})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake failed: %s", resp.Status)
+	}
+	return &Conn{conn: conn, r: r, client: true}, nil
+}
+
+// acceptKey computes the Sec-WebSocket-Accept value for the client's key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// headerContains reports whether the comma-separated header values contain
+// the token, ignoring case.
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadMessage reads the next text or binary message, answering pings and
+// reassembling fragmented messages along the way. It returns io.EOF once the
+// peer closes the connection.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var typ int
+	var msg []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch opcode {
+		case pingMessage:
+			if err := c.writeFrame(pongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case pongMessage:
+			continue
+		case closeMessage:
+			c.writeFrame(closeMessage, payload)
+			c.conn.Close()
+			return 0, nil, io.EOF
+		case TextMessage, BinaryMessage:
+			if typ != 0 {
+				return 0, nil, errors.New("websocket message interrupted")
+			}
+			typ = opcode
+		case 0:
+			if typ == 0 {
+				return 0, nil, errors.New("unexpected websocket continuation frame")
+			}
+		default:
+			return 0, nil, fmt.Errorf("unknown websocket opcode %d", opcode)
+		}
+		if len(msg)+len(payload) > MaxMessageSize {
+			return 0, nil, errors.New("websocket message too large")
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return typ, msg, nil
+		}
+	}
+}
+
+// readFrame reads a single frame, unmasking its payload.
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.r, hdr[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := hdr[0]&0x80 != 0
+	opcode := int(hdr[0] & 0x0f)
+	if hdr[0]&0x70 != 0 {
+		return false, 0, nil, errors.New("websocket extensions not supported")
+	}
+	masked := hdr[1]&0x80 != 0
+	if !masked && !c.client {
+		// Clients must mask their frames.
+		c.writeFrame(closeMessage, []byte{0x03, 0xea}) // 1002: protocol error
+		c.conn.Close()
+		return false, 0, nil, errors.New("unmasked websocket frame from client")
+	}
+	size := uint64(hdr[1] & 0x7f)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if size > MaxMessageSize {
+		return false, 0, nil, errors.New("websocket frame too large")
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage writes a text or binary message as a single frame.
+func (c *Conn) WriteMessage(typ int, data []byte) error {
+	if typ != TextMessage && typ != BinaryMessage {
+		return fmt.Errorf("invalid websocket message type %d", typ)
+	}
+	return c.writeFrame(typ, data)
+}
+
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	b := make([]byte, 0, 14+len(payload))
+	b = append(b, 0x80|byte(opcode))
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		b = append(b, maskBit|byte(n))
+	case n <= 0xffff:
+		b = append(b, maskBit|126, byte(n>>8), byte(n))
+	default:
+		b = append(b, maskBit|127)
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		b = append(b, ext[:]...)
+	}
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		b = append(b, mask[:]...)
+		for i, p := range payload {
+			b = append(b, p^mask[i%4])
+		}
+	} else {
+		b = append(b, payload...)
+	}
+	if opcode == closeMessage {
+		c.closed = true
+	}
+	_, err := c.conn.Write(b)
+	return err
+}
+
+// Close sends a close frame and closes the connection.
+func (c *Conn) Close() error {
+	c.writeFrame(closeMessage, []byte{0x03, 0xe8}) // 1000: normal closure
+	return c.conn.Close()
+}
+
+// RemoteAddr returns the address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadDeadline sets the deadline for ReadMessage.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func echoServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			typ, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := c.WriteMessage(typ, msg); err != nil {
+				t.Errorf("error echoing: %s", err)
+				return
+			}
+		}
+	}))
+}
+
+func TestEcho(t *testing.T) {
+	srv := echoServer(t)
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	c, err := Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer c.Close()
+
+	var tests = []struct {
+		typ  int
+		data []byte
+	}{
+		{TextMessage, []byte("hello")},
+		{BinaryMessage, []byte{0, 1, 2, 3}},
+		{BinaryMessage, bytes.Repeat([]byte{0xaa}, 300)},
+		{BinaryMessage, bytes.Repeat([]byte{0x55}, 70000)},
+		{TextMessage, nil},
+	}
+	for _, test := range tests {
+		if err := c.WriteMessage(test.typ, test.data); err != nil {
+			t.Fatalf("error writing: %s", err)
+		}
+		typ, data, err := c.ReadMessage()
+		if err != nil {
+			t.Fatalf("error reading: %s", err)
+		}
+		if typ != test.typ || !bytes.Equal(data, test.data) {
+			t.Errorf("\t got = %d %d bytes\n\t\t\twant = %d %d bytes", typ, len(data), test.typ, len(test.data))
+		}
+	}
+
+	// A ping in between the fragments of a message is answered with a pong
+	// and the fragments are reassembled.
+	if err := c.writeRaw(0x01, []byte("frag")); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	if err := c.writeRaw(0x89, []byte("ping")); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	if err := c.writeRaw(0x80, []byte("ment")); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	typ, data, err := c.ReadMessage()
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+	if typ != TextMessage || string(data) != "fragment" {
+		t.Errorf("\t got = %d %q\n\t\t\twant = %d %q", typ, data, TextMessage, "fragment")
+	}
+}
+
+// writeRaw writes a masked frame with the given first header byte.
+func (c *Conn) writeRaw(b0 byte, payload []byte) error {
+	frame := []byte{b0, 0x80 | byte(len(payload)), 1, 2, 3, 4}
+	for i, p := range payload {
+		frame = append(frame, p^byte(i%4+1))
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func TestCloseFromPeer(t *testing.T) {
+	done := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r, nil)
+		if err != nil {
+			done <- err
+			return
+		}
+		_, _, err = c.ReadMessage()
+		done <- err
+	}))
+	defer srv.Close()
+	c, err := Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	c.Close()
+	if err := <-done; err != io.EOF {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", err, io.EOF)
+	}
+	if err := c.WriteMessage(TextMessage, []byte("late")); err != ErrClosed {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", err, ErrClosed)
+	}
+}
+
+func TestUnmaskedFrame(t *testing.T) {
+	done := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r, nil)
+		if err != nil {
+			done <- err
+			return
+		}
+		_, _, err = c.ReadMessage()
+		done <- err
+	}))
+	defer srv.Close()
+	c, err := Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer c.Close()
+	if _, err := c.conn.Write([]byte{0x81, 2, 'h', 'i'}); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	if err := <-done; err == nil {
+		t.Error("expected error reading an unmasked frame")
+	}
+	// The server closes the connection with status 1002, protocol error.
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	_, opcode, payload, err := c.readFrame()
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+	if opcode != closeMessage || string(payload) != "\x03\xea" {
+		t.Errorf("\t got = %d %x\n\t\t\twant = %d 03ea", opcode, payload, closeMessage)
+	}
+}
+
+func TestUpgradeRejectsPlainRequests(t *testing.T) {
+	srv := echoServer(t)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("error getting: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("\t got = %d\n\t\t\twant = %d", resp.StatusCode, http.StatusUpgradeRequired)
+	}
+}
+
+func TestUpgradeChecksOrigin(t *testing.T) {
+	srv := echoServer(t)
+	defer srv.Close()
+	var tests = []struct {
+		origin string
+		want   int
+	}{
+		{"", http.StatusSwitchingProtocols},
+		{srv.URL, http.StatusSwitchingProtocols},
+		{"http://evil.example.com", http.StatusForbidden},
+		{"null", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.origin, func(t *testing.T) {
+			if got := upgradeStatus(t, srv.URL, test.origin); got != test.want {
+				t.Errorf("\t got = %d\n\t\t\twant = %d", got, test.want)
+			}
+		})
+	}
+}
+
+// upgradeStatus sends a WebSocket upgrade request with the Origin header and
+// returns the status code of the reply.
+func upgradeStatus(t *testing.T, rawurl, origin string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, rawurl, nil)
+	if err != nil {
+		t.Fatalf("error creating request: %s", err)
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error requesting upgrade: %s", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
@@ -0,0 +1,354 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// jsonMsg is the JSON representation of a Msg.
+type jsonMsg struct {
+	Address json.RawMessage   `json:"address"`
+	TypeTag string            `json:"typeTag"`
+	Args    []json.RawMessage `json:"args"`
+}
+
+// jsonBundle is the JSON representation of a Bundle.
+type jsonBundle struct {
+	TimeTag string            `json:"timeTag"`
+	Packets []json.RawMessage `json:"packets"`
+}
+
+// MarshalJSON implements the json.Marshaler interface for Msg. Packets are
+// represented in JSON losslessly, so that a packet converted from OSC to JSON
+// and back encodes to the same bytes. A message is an object with its address,
+// type tag and one argument for each type tag other than the array brackets:
+//
+//	{"address": "/ch/01/mix/fader", "typeTag": "f", "args": [0.75]}
+//
+// Arguments are represented according to their type tag:
+//
+//	i r        number
+//	h t        string holding the decimal integer, since JSON numbers cannot
+//	           hold every 64-bit integer exactly
+//	f d        number, or for infinities and NaNs, which JSON numbers cannot
+//	           hold, a string holding the IEEE 754 bits in hexadecimal
+//	s S        string, or for strings that are not valid UTF-8, which JSON
+//	           strings cannot hold, an object holding the standard base64
+//	           encoding of the bytes: {"base64": "Q2Fm6Q=="}
+//	b          string holding the standard base64 encoding
+//	c          number holding the code point, which may not be a valid rune
+//	m          array of the four bytes
+//	T F        true and false
+//	N I        null
+//
+// The address is represented like an s argument.
+//
+// A bundle is an object with its time tag, as a string holding the decimal
+// integer, and its packets:
+//
+//	{"timeTag": "1", "packets": [{"address": "/ch/01/mix/on", ...}]}
+func (m *Msg) MarshalJSON() ([]byte, error) {
+	if n := numArgs(m.TypeTag); n != len(m.Args) {
+		return nil, fmt.Errorf("type tag %q needs %d arguments, got %d", m.TypeTag, n, len(m.Args))
+	}
+	addr, err := json.Marshal(jsonString(m.Address))
+	if err != nil {
+		return nil, err
+	}
+	jm := jsonMsg{Address: addr, TypeTag: m.TypeTag, Args: []json.RawMessage{}}
+	args := m.Args
+	for i := 0; i < len(m.TypeTag); i++ {
+		tag := m.TypeTag[i]
+		if tag == '[' || tag == ']' {
+			continue
+		}
+		v, err := jsonArg(tag, args[0])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d of %s: %w", len(m.Args)-len(args), m.Address, err)
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		jm.Args = append(jm.Args, raw)
+		args = args[1:]
+	}
+	return json.Marshal(jm)
+}
+
+// jsonArg converts the argument for the type tag to its JSON representation.
+func jsonArg(tag byte, arg interface{}) (interface{}, error) {
+	// Encoding the argument checks that it suits the type tag.
+	b, err := appendArg(nil, tag, arg)
+	if err != nil {
+		return nil, err
+	}
+	decoded, _, err := readArg(tag, b)
+	if err != nil {
+		return nil, err
+	}
+	switch v := decoded.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case TimeTag:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case float32:
+		if math.IsInf(float64(v), 0) || math.IsNaN(float64(v)) {
+			return fmt.Sprintf("0x%08x", math.Float32bits(v)), nil
+		}
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return fmt.Sprintf("0x%016x", math.Float64bits(v)), nil
+		}
+	case string:
+		return jsonString(v), nil
+	case MIDI:
+		return []int{int(v[0]), int(v[1]), int(v[2]), int(v[3])}, nil
+	}
+	return decoded, nil
+}
+
+// jsonBase64 is the JSON representation of a string that is not valid UTF-8.
+type jsonBase64 struct {
+	Base64 []byte `json:"base64"`
+}
+
+// jsonString returns the JSON representation of an OSC string.
+func jsonString(s string) interface{} {
+	if utf8.ValidString(s) {
+		return s
+	}
+	return jsonBase64{[]byte(s)}
+}
+
+// unmarshalString converts the JSON representation of an OSC string to the
+// string.
+func unmarshalString(raw json.RawMessage) (string, error) {
+	if !bytes.HasPrefix(raw, []byte("{")) {
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	}
+	var v jsonBase64
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", err
+	}
+	if v.Base64 == nil {
+		return "", fmt.Errorf("%s is not a string", raw)
+	}
+	return string(v.Base64), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Msg.
+func (m *Msg) UnmarshalJSON(data []byte) error {
+	var jm jsonMsg
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return err
+	}
+	if err := checkArrays(jm.TypeTag); err != nil {
+		return err
+	}
+	if n := numArgs(jm.TypeTag); n != len(jm.Args) {
+		return fmt.Errorf("type tag %q needs %d arguments, got %d", jm.TypeTag, n, len(jm.Args))
+	}
+	addr, err := unmarshalString(jm.Address)
+	if err != nil {
+		return fmt.Errorf("address: %w", err)
+	}
+	msg := Msg{Address: addr, TypeTag: jm.TypeTag}
+	raws := jm.Args
+	for i := 0; i < len(jm.TypeTag); i++ {
+		tag := jm.TypeTag[i]
+		if tag == '[' || tag == ']' {
+			continue
+		}
+		arg, err := unmarshalArg(tag, raws[0])
+		if err != nil {
+			return fmt.Errorf("argument %d of %s: %w", len(jm.Args)-len(raws), addr, err)
+		}
+		msg.Args = append(msg.Args, arg)
+		raws = raws[1:]
+	}
+	*m = msg
+	return nil
+}
+
+// unmarshalArg converts the JSON representation of the argument for the type
+// tag to its Go value.
+func unmarshalArg(tag byte, raw json.RawMessage) (interface{}, error) {
+	var err error
+	switch tag {
+	case 'i':
+		var v int32
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case 'r':
+		var v uint32
+		err = json.Unmarshal(raw, &v)
+		return RGBA(v), err
+	case 'h':
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case 't':
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		u, err := strconv.ParseUint(s, 10, 64)
+		return TimeTag(u), err
+	case 'f':
+		var v float32
+		if bytes.HasPrefix(raw, []byte(`"`)) {
+			var bits uint64
+			bits, err = unmarshalBits(raw, 32)
+			v = math.Float32frombits(uint32(bits))
+		} else {
+			err = json.Unmarshal(raw, &v)
+		}
+		return v, err
+	case 'd':
+		var v float64
+		if bytes.HasPrefix(raw, []byte(`"`)) {
+			var bits uint64
+			bits, err = unmarshalBits(raw, 64)
+			v = math.Float64frombits(bits)
+		} else {
+			err = json.Unmarshal(raw, &v)
+		}
+		return v, err
+	case 's', 'S':
+		return unmarshalString(raw)
+	case 'b':
+		var v []byte
+		if err = json.Unmarshal(raw, &v); err == nil && v == nil {
+			v = []byte{}
+		}
+		return v, err
+	case 'c':
+		if !bytes.HasPrefix(raw, []byte(`"`)) {
+			var v rune
+			err = json.Unmarshal(raw, &v)
+			return v, err
+		}
+		// A string holding a single character is also accepted.
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 || size != len(s) {
+			return nil, fmt.Errorf("%q is not a single character", s)
+		}
+		return r, nil
+	case 'm':
+		var v MIDI
+		var b []uint8
+		if err = json.Unmarshal(raw, &b); err == nil && len(b) != len(v) {
+			err = errors.New("MIDI message is not 4 bytes")
+		}
+		copy(v[:], b)
+		return v, err
+	case 'T', 'F':
+		var v bool
+		if err = json.Unmarshal(raw, &v); err == nil && v != (tag == 'T') {
+			err = fmt.Errorf("%s does not match type tag %c", raw, tag)
+		}
+		return v, err
+	case 'N', 'I':
+		if string(raw) != "null" {
+			return nil, fmt.Errorf("%s does not match type tag %c", raw, tag)
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown type tag %c", tag)
+}
+
+// unmarshalBits unmarshals a JSON string holding hexadecimal IEEE 754 bits.
+func unmarshalBits(raw json.RawMessage, bitSize int) (uint64, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return 0, err
+	}
+	if len(s) < 2 || s[:2] != "0x" {
+		return 0, fmt.Errorf("invalid float bits %q", s)
+	}
+	return strconv.ParseUint(s[2:], 16, bitSize)
+}
+
+// MarshalJSON implements the json.Marshaler interface for Bundle.
+func (bundle *Bundle) MarshalJSON() ([]byte, error) {
+	jb := jsonBundle{
+		TimeTag: strconv.FormatUint(uint64(bundle.Time), 10),
+		Packets: []json.RawMessage{},
+	}
+	for _, p := range bundle.Packets {
+		raw, err := json.Marshal(p)
+		if err != nil {
+			return nil, err
+		}
+		jb.Packets = append(jb.Packets, raw)
+	}
+	return json.Marshal(jb)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Bundle.
+func (bundle *Bundle) UnmarshalJSON(data []byte) error {
+	var jb jsonBundle
+	if err := json.Unmarshal(data, &jb); err != nil {
+		return err
+	}
+	tt, err := strconv.ParseUint(jb.TimeTag, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid time tag %q", jb.TimeTag)
+	}
+	b := Bundle{Time: TimeTag(tt)}
+	for _, raw := range jb.Packets {
+		p, err := UnmarshalPacketJSON(raw)
+		if err != nil {
+			return err
+		}
+		b.Packets = append(b.Packets, p)
+	}
+	*bundle = b
+	return nil
+}
+
+// UnmarshalPacketJSON decodes the JSON representation of an OSC message or
+// bundle.
+func UnmarshalPacketJSON(data []byte) (Packet, error) {
+	var probe struct {
+		Address json.RawMessage `json:"address"`
+		TimeTag *string         `json:"timeTag"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	switch {
+	case probe.Address != nil:
+		m := new(Msg)
+		if err := m.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case probe.TimeTag != nil:
+		b := new(Bundle)
+		if err := b.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	return nil, errors.New("JSON object is neither an OSC message nor a bundle")
+}
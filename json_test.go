@@ -0,0 +1,137 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	var tests = []struct {
+		name   string
+		packet Packet
+		want   string
+	}{
+		{
+			"fader",
+			&Msg{"/ch/01/mix/fader", "f", []interface{}{float32(0.4648)}},
+			`{"address":"/ch/01/mix/fader","typeTag":"f","args":[0.4648]}`,
+		},
+		{
+			"no args",
+			&Msg{Address: "/info"},
+			`{"address":"/info","typeTag":"","args":[]}`,
+		},
+		{
+			"all types",
+			&Msg{"/all", "ihfdsSbtcrmTFNI[i]", []interface{}{
+				int32(-2), int64(1<<62 + 1), float32(0.1), 0.1, "str", "sym", []byte("hello"),
+				TimeTag(0xdeadbeef00000001), 'é', RGBA(0xff0080ff), MIDI{0, 0x90, 0x3c, 0x7f},
+				true, false, nil, nil, int32(7),
+			}},
+			`{"address":"/all","typeTag":"ihfdsSbtcrmTFNI[i]","args":[-2,"4611686018427387905",0.1,0.1,` +
+				`"str","sym","aGVsbG8=","16045690981097406465",233,4278223103,[0,144,60,127],true,false,null,null,7]}`,
+		},
+		{
+			"invalid runes",
+			&Msg{"/c", "ccc", []interface{}{rune(0xd800), rune(-1), rune(0x110000)}},
+			`{"address":"/c","typeTag":"ccc","args":[55296,-1,1114112]}`,
+		},
+		{
+			"invalid UTF-8",
+			&Msg{"/caf\xe9", "sS", []interface{}{"Caf\xe9", "Café"}},
+			`{"address":{"base64":"L2NhZuk="},"typeTag":"sS","args":[{"base64":"Q2Fm6Q=="},"Café"]}`,
+		},
+		{
+			"non-finite",
+			&Msg{"/nan", "fdf", []interface{}{
+				math.Float32frombits(0x7fc00001), math.Inf(-1), float32(math.Inf(1)),
+			}},
+			`{"address":"/nan","typeTag":"fdf","args":["0x7fc00001","0xfff0000000000000","0x7f800000"]}`,
+		},
+		{
+			"bundle",
+			&Bundle{Immediately, []Packet{
+				&Msg{"/ch/01/mix/on", "i", []interface{}{int32(0)}},
+				&Bundle{Time: TimeTag(1 << 40)},
+			}},
+			`{"timeTag":"1","packets":[{"address":"/ch/01/mix/on","typeTag":"i","args":[0]},` +
+				`{"timeTag":"1099511627776","packets":[]}]}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			osc, err := test.packet.MarshalBinary()
+			if err != nil {
+				t.Fatalf("unexpected error encoding OSC: %s", err)
+			}
+			decoded, err := ParsePacket(osc)
+			if err != nil {
+				t.Fatalf("unexpected error decoding OSC: %s", err)
+			}
+			b, err := json.Marshal(decoded)
+			if err != nil {
+				t.Fatalf("unexpected error encoding JSON: %s", err)
+			}
+			if string(b) != test.want {
+				t.Errorf("\t got = %s\n\t\t\twant = %s", b, test.want)
+			}
+			p, err := UnmarshalPacketJSON(b)
+			if err != nil {
+				t.Fatalf("unexpected error decoding JSON: %s", err)
+			}
+			again, err := p.MarshalBinary()
+			if err != nil {
+				t.Fatalf("unexpected error encoding OSC: %s", err)
+			}
+			if string(again) != string(osc) {
+				t.Errorf("\t got = %q\n\t\t\twant = %q", again, osc)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	var tests = []string{
+		`[]`,
+		`{}`,
+		`{"address":"/a","typeTag":"i","args":[]}`,
+		`{"address":"/a","typeTag":"i","args":[1.5]}`,
+		`{"address":"/a","typeTag":"h","args":[1]}`,
+		`{"address":"/a","typeTag":"f","args":["NaN"]}`,
+		`{"address":"/a","typeTag":"c","args":["ab"]}`,
+		`{"address":"/a","typeTag":"c","args":[1.5]}`,
+		`{"address":"/a","typeTag":"m","args":[[1,2,3]]}`,
+		`{"address":"/a","typeTag":"s","args":[{}]}`,
+		`{"address":"/a","typeTag":"s","args":[{"base64":"!"}]}`,
+		`{"address":{"base64":1},"typeTag":"","args":[]}`,
+		`{"address":"/a","typeTag":"T","args":[false]}`,
+		`{"address":"/a","typeTag":"N","args":[0]}`,
+		`{"address":"/a","typeTag":"[i","args":[1]}`,
+		`{"address":"/a","typeTag":"x","args":[1]}`,
+		`{"timeTag":"now","packets":[]}`,
+		`{"timeTag":"1","packets":[{}]}`,
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			if p, err := UnmarshalPacketJSON([]byte(test)); err == nil {
+				t.Errorf("expected error decoding %s, got %v", test, p)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONCharString(t *testing.T) {
+	p, err := UnmarshalPacketJSON([]byte(`{"address":"/a","typeTag":"c","args":["é"]}`))
+	if err != nil {
+		t.Fatalf("unexpected error decoding JSON: %s", err)
+	}
+	if got := p.(*Msg).Args[0]; got != 'é' {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", got, 'é')
+	}
+}
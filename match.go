@@ -0,0 +1,84 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import "strings"
+
+// Match reports whether the OSC address pattern matches the address. The
+// pattern may contain the OSC 1.0 wildcards:
+//
+//	?          any single character
+//	*          any sequence of zero or more characters
+//	[abc]      any character in the list, which may contain ranges (a-z)
+//	[!abc]     any character not in the list
+//	{foo,bar}  any of the comma-separated strings
+//
+// None of the wildcards match the '/' that separates address parts.
+func Match(pattern, addr string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			pattern = strings.TrimLeft(pattern, "*")
+			for i := 0; i <= len(addr); i++ {
+				if Match(pattern, addr[i:]) {
+					return true
+				}
+				if i < len(addr) && addr[i] == '/' {
+					return false
+				}
+			}
+			return false
+		case '?':
+			if len(addr) == 0 || addr[0] == '/' {
+				return false
+			}
+		case '[':
+			end := strings.IndexByte(pattern, ']')
+			if end < 0 || len(addr) == 0 || addr[0] == '/' || !matchList(pattern[1:end], addr[0]) {
+				return false
+			}
+			pattern = pattern[end:]
+		case '{':
+			end := strings.IndexByte(pattern, '}')
+			if end < 0 {
+				return false
+			}
+			for _, alt := range strings.Split(pattern[1:end], ",") {
+				if strings.HasPrefix(addr, alt) && Match(pattern[end+1:], addr[len(alt):]) {
+					return true
+				}
+			}
+			return false
+		default:
+			if len(addr) == 0 || pattern[0] != addr[0] {
+				return false
+			}
+		}
+		pattern, addr = pattern[1:], addr[1:]
+	}
+	return len(addr) == 0
+}
+
+// matchList reports whether the character is in the bracketed list.
+func matchList(list string, c byte) bool {
+	negate := strings.HasPrefix(list, "!")
+	if negate {
+		list = list[1:]
+	}
+	for i := 0; i < len(list); i++ {
+		if i+2 < len(list) && list[i+1] == '-' {
+			if list[i] <= c && c <= list[i+2] {
+				return !negate
+			}
+			i += 2
+			continue
+		}
+		if list[i] == c {
+			return !negate
+		}
+	}
+	return negate
+}
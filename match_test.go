@@ -0,0 +1,46 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		addr    string
+		want    bool
+	}{
+		{"/ch/01/mix/on", "/ch/01/mix/on", true},
+		{"/ch/01/mix/on", "/ch/02/mix/on", false},
+		{"/ch/01/mix", "/ch/01/mix/on", false},
+		{"/ch/0?/mix/on", "/ch/05/mix/on", true},
+		{"/ch/0?/mix/on", "/ch/15/mix/on", false},
+		{"/ch/*/mix/on", "/ch/15/mix/on", true},
+		{"/ch/*/on", "/ch/15/mix/on", false},
+		{"/ch/*", "/ch/15/mix/on", false},
+		{"/ch/1*5/mix/on", "/ch/15/mix/on", true},
+		{"/ch/**/mix/on", "/ch/15/mix/on", true},
+		{"/ch/[0-1][1-3]/mix/on", "/ch/12/mix/on", true},
+		{"/ch/[0-1][1-3]/mix/on", "/ch/14/mix/on", false},
+		{"/ch/[!0]1/mix/on", "/ch/11/mix/on", true},
+		{"/ch/[!0]1/mix/on", "/ch/01/mix/on", false},
+		{"/ch/[12-]1/mix/on", "/ch/-1/mix/on", true},
+		{"/ch/{01,02}/mix/on", "/ch/02/mix/on", true},
+		{"/ch/{01,02}/mix/on", "/ch/03/mix/on", false},
+		{"/{ch,bus}/*/mix/on", "/bus/03/mix/on", true},
+		{"/ch/{01/mix/on", "/ch/01/mix/on", false},
+		{"/ch/[01/mix/on", "/ch/01/mix/on", false},
+		{"/ch/?", "/ch/", false},
+		{"/*", "/", true},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern+"_"+test.addr, func(t *testing.T) {
+			if got := Match(test.pattern, test.addr); got != test.want {
+				t.Errorf("\t got = %t\n\t\t\twant = %t", got, test.want)
+			}
+		})
+	}
+}
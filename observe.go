@@ -0,0 +1,102 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"net"
+	"time"
+)
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+// Enum for the kinds of events passed to an Observer.
+const (
+	// PacketReceived is a packet received by a Client or a Server.
+	PacketReceived EventKind = iota + 1
+
+	// PacketSent is a packet sent by a Client.
+	PacketSent
+
+	// DecodeError is a packet received by a Client or a Server that cannot
+	// be decoded.
+	DecodeError
+
+	// MessageDispatched is a message dispatched to a method by a
+	// Dispatcher. The Address is the method's address and the Duration is
+	// how long the method took.
+	MessageDispatched
+
+	// MessageUnhandled is a message that matched no method of a Dispatcher.
+	MessageUnhandled
+
+	// BundleLate is a bundle received by a Dispatcher after its time tag.
+	// The Duration is how late it is. Late bundles are dispatched
+	// immediately.
+	BundleLate
+
+	// MessageDropped is a message queued by a Coalescer that was replaced by
+	// a later message to the same address before it was sent.
+	MessageDropped
+)
+
+var eventKinds = map[EventKind]string{
+	PacketReceived:    "packet received",
+	PacketSent:        "packet sent",
+	DecodeError:       "decode error",
+	MessageDispatched: "message dispatched",
+	MessageUnhandled:  "message unhandled",
+	BundleLate:        "bundle late",
+	MessageDropped:    "message dropped",
+}
+
+func (k EventKind) String() string {
+	if s, ok := eventKinds[k]; ok {
+		return s
+	}
+	return "unknown event"
+}
+
+// Event describes something that happened to a packet. Fields that do not
+// apply to the Kind are unset.
+type Event struct {
+	Kind EventKind
+
+	// Peer is the address the packet was received from or sent to.
+	Peer net.Addr
+
+	// Address is the OSC address of the message.
+	Address string
+
+	// Size is the size of the encoded packet in bytes.
+	Size int
+
+	Duration time.Duration
+	Err      error
+}
+
+// Observer observes the packets handled by Clients, Servers, Dispatchers and
+// Coalescers, typically to collect metrics. Observe is called synchronously
+// and possibly from several goroutines at once, so it must be fast and safe
+// for concurrent use.
+type Observer interface {
+	Observe(e Event)
+}
+
+// ObserverFunc adapts an ordinary function to the Observer interface.
+type ObserverFunc func(e Event)
+
+// Observe implements the Observer interface by calling f.
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+// observe passes the event to the observer, if any.
+func observe(o Observer, e Event) {
+	if o != nil {
+		o.Observe(e)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventRecorder records the events it observes as strings.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *eventRecorder) Observe(e Event) {
+	s := e.Kind.String()
+	if e.Address != "" {
+		s += " " + e.Address
+	}
+	if e.Size != 0 {
+		s += fmt.Sprintf(" %d", e.Size)
+	}
+	if e.Err != nil {
+		s += " error"
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, s)
+}
+
+func (r *eventRecorder) wait(t *testing.T, n int) string {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		r.mu.Lock()
+		events := append([]string(nil), r.events...)
+		r.mu.Unlock()
+		if len(events) >= n || time.Now().After(deadline) {
+			return strings.Join(events, "\n")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestObserver(t *testing.T) {
+	var serverEvents, clientEvents eventRecorder
+	d := &Dispatcher{Observer: &serverEvents}
+	d.Handle("/ch/01/mix/fader", func(m *Msg, from net.Addr) {})
+	s := &Server{Handler: d, Observer: &serverEvents, ErrorLog: log.New(ioutil.Discard, "", 0)}
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	go s.ServePacket(conn)
+	defer s.Close()
+
+	c, err := Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer c.Close()
+	c.Observer = &clientEvents
+	c.Write([]byte("garbage"))
+	c.WriteMessage("/ch/01/mix/fader", "f", float32(0.5))
+	c.WriteMessage("/ch/02/mix/fader", "f", float32(0.5))
+	c.Send(&Bundle{Time: NewTimeTag(time.Now().Add(-time.Second))})
+
+	want := "packet received 7\n" +
+		"decode error 7 error\n" +
+		"packet received 28\n" +
+		"message dispatched /ch/01/mix/fader\n" +
+		"packet received 28\n" +
+		"message unhandled /ch/02/mix/fader\n" +
+		"packet received 16\n" +
+		"bundle late"
+	if got := serverEvents.wait(t, 8); got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+	want = "packet sent 7\npacket sent 28\npacket sent 28\npacket sent 16"
+	if got := clientEvents.wait(t, 4); got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+}
+
+func TestCoalescerObserver(t *testing.T) {
+	var events eventRecorder
+	c := NewCoalescer(ioutil.Discard, time.Hour)
+	c.Observer = &events
+	c.WriteMessage("/ch/01/mix/fader", "f", float32(0.25))
+	c.WriteMessage("/ch/01/mix/fader", "f", float32(0.5))
+	if got, want := events.wait(t, 1), "message dropped /ch/01/mix/fader 28"; got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+}
@@ -21,11 +21,13 @@ func Message(addr, typeTag string, args ...interface{}) ([]byte, error) {
 	msg = addZeroBytes(msg)
 
 	// Add OSC Type Tag to message or add a comma and nulls if there aren't any
-	// type tags provided and the appropriate number of zero bytes.
+	// type tags provided and the appropriate number of zero bytes. Like the
+	// address, the type tag string is terminated by at least one zero byte.
 	msg = append(msg, []byte(",")...)
 	if typeTag != "" {
 		msg = append(msg, typeTag...)
 	}
+	msg = append(msg, 0)
 	msg = addZeroBytes(msg)
 
 	// Add args to message if there are any given.
@@ -52,6 +52,10 @@ func TestGoodMessages(t *testing.T) {
 			"ch1 gate str", "/ch/01/gate/mode", "s", args4,
 			[]byte("/ch/01/gate/mode\x00\x00\x00\x00,s\x00\x00GATE\x00\x00\x00\x00"),
 		},
+		{
+			"meters type tag of 4 bytes", "/meters", "sii", []interface{}{"/meters/6", 1, 2},
+			[]byte("/meters\x00,sii\x00\x00\x00\x00/meters/6\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x02"),
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
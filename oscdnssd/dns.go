@@ -0,0 +1,386 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscdnssd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// DNS resource record types and class.
+const (
+	typeA    = 1
+	typePTR  = 12
+	typeTXT  = 16
+	typeAAAA = 28
+	typeSRV  = 33
+	typeANY  = 255
+
+	classIN = 1
+
+	// classMask clears the mDNS unicast-response bit of questions and the
+	// cache-flush bit of records.
+	classMask = 0x7fff
+	// unicastResponse asks for a unicast response to a question.
+	unicastResponse = 0x8000
+	// cacheFlush marks a record as unique.
+	cacheFlush = 0x8000
+)
+
+const (
+	flagResponse = 0x8000
+	flagAuth     = 0x0400
+)
+
+// maxLabel is the maximum length of a DNS label.
+const maxLabel = 63
+
+var errTruncated = errors.New("truncated DNS message")
+
+// message is a DNS message, as exchanged by mDNS queriers and responders.
+type message struct {
+	ID        uint16
+	Flags     uint16
+	Questions []question
+	Answers   []record
+	Extra     []record
+}
+
+type question struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// record is a DNS resource record of one of the types used by DNS-SD.
+type record struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+
+	// Target is the domain name of PTR and SRV records.
+	Target string
+	// Port, Priority and Weight are set for SRV records.
+	Priority uint16
+	Weight   uint16
+	Port     uint16
+	// Text holds the strings of TXT records.
+	Text []string
+	// IP is the address of A and AAAA records.
+	IP net.IP
+}
+
+func (m *message) isResponse() bool {
+	return m.Flags&flagResponse != 0
+}
+
+// MarshalBinary encodes the message without name compression.
+func (m *message) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(b[0:], m.ID)
+	binary.BigEndian.PutUint16(b[2:], m.Flags)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(m.Questions)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(m.Answers)))
+	binary.BigEndian.PutUint16(b[10:], uint16(len(m.Extra)))
+	var err error
+	for _, q := range m.Questions {
+		if b, err = appendName(b, q.Name); err != nil {
+			return nil, err
+		}
+		b = appendUint16(b, q.Type)
+		b = appendUint16(b, q.Class)
+	}
+	for _, rr := range m.Answers {
+		if b, err = appendRecord(b, rr); err != nil {
+			return nil, err
+		}
+	}
+	for _, rr := range m.Extra {
+		if b, err = appendRecord(b, rr); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func appendRecord(b []byte, rr record) ([]byte, error) {
+	b, err := appendName(b, rr.Name)
+	if err != nil {
+		return nil, err
+	}
+	b = appendUint16(b, rr.Type)
+	b = appendUint16(b, rr.Class)
+	b = append(b, byte(rr.TTL>>24), byte(rr.TTL>>16), byte(rr.TTL>>8), byte(rr.TTL))
+	b = append(b, 0, 0)
+	start := len(b)
+	switch rr.Type {
+	case typePTR:
+		b, err = appendName(b, rr.Target)
+	case typeSRV:
+		b = appendUint16(b, rr.Priority)
+		b = appendUint16(b, rr.Weight)
+		b = appendUint16(b, rr.Port)
+		b, err = appendName(b, rr.Target)
+	case typeTXT:
+		text := rr.Text
+		if len(text) == 0 {
+			// A TXT record holds at least one string.
+			text = []string{""}
+		}
+		for _, s := range text {
+			if len(s) > 255 {
+				return nil, fmt.Errorf("TXT string %.20q... longer than 255 bytes", s)
+			}
+			b = append(b, byte(len(s)))
+			b = append(b, s...)
+		}
+	case typeA:
+		ip := rr.IP.To4()
+		if ip == nil {
+			return nil, fmt.Errorf("A record of %s with address %s", rr.Name, rr.IP)
+		}
+		b = append(b, ip...)
+	case typeAAAA:
+		b = append(b, rr.IP.To16()...)
+	default:
+		return nil, fmt.Errorf("unsupported DNS record type %d", rr.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(b[start-2:], uint16(len(b)-start))
+	return b, nil
+}
+
+func appendUint16(b []byte, u uint16) []byte {
+	return append(b, byte(u>>8), byte(u))
+}
+
+// appendName appends the labels of the domain name, in which dots and
+// backslashes within a label are escaped with a backslash.
+func appendName(b []byte, name string) ([]byte, error) {
+	for _, label := range splitName(name) {
+		if len(label) == 0 || len(label) > maxLabel {
+			return nil, fmt.Errorf("invalid label %q in domain name %s", label, name)
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0), nil
+}
+
+// splitName returns the unescaped labels of the domain name.
+func splitName(name string) []string {
+	var labels []string
+	var label strings.Builder
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c == '\\' && i+1 < len(name):
+			i++
+			label.WriteByte(name[i])
+		case c == '.':
+			labels = append(labels, label.String())
+			label.Reset()
+		default:
+			label.WriteByte(c)
+		}
+	}
+	if label.Len() > 0 {
+		labels = append(labels, label.String())
+	}
+	return labels
+}
+
+// escapeLabel escapes the dots and backslashes of a label.
+func escapeLabel(label string) string {
+	return strings.NewReplacer(`\`, `\\`, `.`, `\.`).Replace(label)
+}
+
+// parseMessage decodes a DNS message, skipping the records of other types.
+func parseMessage(b []byte) (*message, error) {
+	if len(b) < 12 {
+		return nil, errTruncated
+	}
+	m := &message{
+		ID:    binary.BigEndian.Uint16(b[0:]),
+		Flags: binary.BigEndian.Uint16(b[2:]),
+	}
+	qd := int(binary.BigEndian.Uint16(b[4:]))
+	an := int(binary.BigEndian.Uint16(b[6:]))
+	ns := int(binary.BigEndian.Uint16(b[8:]))
+	ar := int(binary.BigEndian.Uint16(b[10:]))
+	off := 12
+	for i := 0; i < qd; i++ {
+		name, n, err := readName(b, off)
+		if err != nil {
+			return nil, err
+		}
+		off = n
+		if off+4 > len(b) {
+			return nil, errTruncated
+		}
+		m.Questions = append(m.Questions, question{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(b[off:]),
+			Class: binary.BigEndian.Uint16(b[off+2:]),
+		})
+		off += 4
+	}
+	for i := 0; i < an+ns+ar; i++ {
+		rr, n, err := readRecord(b, off)
+		if err != nil {
+			return nil, err
+		}
+		off = n
+		if rr == nil {
+			continue
+		}
+		switch {
+		case i < an:
+			m.Answers = append(m.Answers, *rr)
+		case i >= an+ns:
+			m.Extra = append(m.Extra, *rr)
+		}
+	}
+	return m, nil
+}
+
+// readRecord reads the resource record at the offset and returns it, or nil
+// if it has an unsupported type, and the offset following it.
+func readRecord(b []byte, off int) (*record, int, error) {
+	name, off, err := readName(b, off)
+	if err != nil {
+		return nil, 0, err
+	}
+	if off+10 > len(b) {
+		return nil, 0, errTruncated
+	}
+	rr := &record{
+		Name:  name,
+		Type:  binary.BigEndian.Uint16(b[off:]),
+		Class: binary.BigEndian.Uint16(b[off+2:]),
+		TTL:   binary.BigEndian.Uint32(b[off+4:]),
+	}
+	length := int(binary.BigEndian.Uint16(b[off+8:]))
+	off += 10
+	end := off + length
+	if end > len(b) {
+		return nil, 0, errTruncated
+	}
+	data := b[off:end]
+	switch rr.Type {
+	case typePTR:
+		if rr.Target, _, err = readName(b, off); err != nil {
+			return nil, 0, err
+		}
+	case typeSRV:
+		if length < 6 {
+			return nil, 0, errTruncated
+		}
+		rr.Priority = binary.BigEndian.Uint16(data[0:])
+		rr.Weight = binary.BigEndian.Uint16(data[2:])
+		rr.Port = binary.BigEndian.Uint16(data[4:])
+		if rr.Target, _, err = readName(b, off+6); err != nil {
+			return nil, 0, err
+		}
+	case typeTXT:
+		for len(data) > 0 {
+			n := int(data[0])
+			if 1+n > len(data) {
+				return nil, 0, errTruncated
+			}
+			rr.Text = append(rr.Text, string(data[1:1+n]))
+			data = data[1+n:]
+		}
+	case typeA, typeAAAA:
+		if length != net.IPv4len && length != net.IPv6len {
+			return nil, 0, fmt.Errorf("address of %d bytes", length)
+		}
+		rr.IP = append(net.IP(nil), data...)
+	default:
+		return nil, end, nil
+	}
+	return rr, end, nil
+}
+
+// readName reads the possibly compressed domain name at the offset and
+// returns it with its labels escaped, and the offset following it.
+func readName(b []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(b) {
+			return "", 0, errTruncated
+		}
+		n := int(b[off])
+		switch {
+		case n == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case n&0xc0 == 0xc0:
+			if off+1 >= len(b) {
+				return "", 0, errTruncated
+			}
+			if jumps++; jumps > 32 {
+				return "", 0, errors.New("DNS name compression loop")
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(b[off:]) & 0x3fff)
+		case n > maxLabel:
+			return "", 0, fmt.Errorf("invalid DNS label length %d", n)
+		default:
+			if off+1+n > len(b) {
+				return "", 0, errTruncated
+			}
+			labels = append(labels, escapeLabel(string(b[off+1:off+1+n])))
+			off += 1 + n
+		}
+	}
+}
+
+// textRecord returns the strings of a TXT record holding the key/value pairs.
+func textRecord(text map[string]string) []string {
+	s := make([]string, 0, len(text))
+	for k, v := range text {
+		s = append(s, k+"="+v)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// parseText returns the key/value pairs of the strings of a TXT record. Keys
+// without a value are mapped to the empty string.
+func parseText(text []string) map[string]string {
+	m := make(map[string]string)
+	for _, s := range text {
+		if s == "" {
+			continue
+		}
+		k, v := s, ""
+		if i := strings.IndexByte(s, '='); i >= 0 {
+			k, v = s[:i], s[i+1:]
+		}
+		// The first occurrence of a key wins.
+		if _, ok := m[k]; !ok {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// equalName reports whether the domain names are equal, ignoring case.
+func equalName(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscdnssd
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestMessage(t *testing.T) {
+	m := &message{
+		ID:        7,
+		Flags:     flagResponse | flagAuth,
+		Questions: []question{{Name: "_osc._udp.local.", Type: typePTR, Class: classIN | unicastResponse}},
+		Answers: []record{
+			{Name: "_osc._udp.local.", Type: typePTR, Class: classIN, TTL: 4500, Target: `Rack\.1._osc._udp.local.`},
+		},
+		Extra: []record{
+			{Name: `Rack\.1._osc._udp.local.`, Type: typeSRV, Class: classIN | cacheFlush, TTL: 4500, Port: 10023, Target: "x32.local."},
+			{Name: `Rack\.1._osc._udp.local.`, Type: typeTXT, Class: classIN | cacheFlush, TTL: 4500, Text: []string{"model=X32", "txtvers=1"}},
+			{Name: "x32.local.", Type: typeA, Class: classIN | cacheFlush, TTL: 120, IP: net.IPv4(192, 168, 1, 10).To4()},
+			{Name: "x32.local.", Type: typeAAAA, Class: classIN | cacheFlush, TTL: 120, IP: net.ParseIP("fe80::1")},
+		},
+	}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error encoding: %s", err)
+	}
+	got, err := parseMessage(b)
+	if err != nil {
+		t.Fatalf("error decoding: %s", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("\t got = %+v\n\t\t\twant = %+v", got, m)
+	}
+	for i := 12; i < len(b); i += 7 {
+		if _, err := parseMessage(b[:i]); err == nil {
+			t.Errorf("%d bytes: expected error decoding truncated message", i)
+		}
+	}
+}
+
+func TestParseCompressed(t *testing.T) {
+	// A response with a PTR record whose target points back to its name.
+	b := []byte{
+		0, 0, 0x84, 0, 0, 0, 0, 1, 0, 0, 0, 0,
+		4, '_', 'o', 's', 'c', 4, '_', 'u', 'd', 'p', 5, 'l', 'o', 'c', 'a', 'l', 0,
+		0, typePTR, 0, classIN, 0, 0, 0, 10, 0, 7,
+		4, 'S', 'y', 'n', 't', 0xc0, 12,
+	}
+	m, err := parseMessage(b)
+	if err != nil {
+		t.Fatalf("error decoding: %s", err)
+	}
+	want := record{Name: "_osc._udp.local.", Type: typePTR, Class: classIN, TTL: 10, Target: "Synt._osc._udp.local."}
+	if len(m.Answers) != 1 || !reflect.DeepEqual(m.Answers[0], want) {
+		t.Errorf("\t got = %+v\n\t\t\twant = %+v", m.Answers, want)
+	}
+
+	// A name pointing to itself.
+	b[len(b)-1] = byte(len(b) - 7)
+	b[len(b)-7] = 0xc0
+	if _, err := parseMessage(b); err == nil {
+		t.Error("expected error decoding compression loop")
+	}
+}
+
+func TestNames(t *testing.T) {
+	var tests = []struct {
+		name   string
+		labels []string
+	}{
+		{"_osc._udp.local.", []string{"_osc", "_udp", "local"}},
+		{`My\.Synth\\2._osc._tcp.local.`, []string{"My.Synth\\2", "_osc", "_tcp", "local"}},
+		{"x32.local", []string{"x32", "local"}},
+	}
+	for _, test := range tests {
+		got := splitName(test.name)
+		if !reflect.DeepEqual(got, test.labels) {
+			t.Errorf("\t got = %q\n\t\t\twant = %q", got, test.labels)
+		}
+		b, err := appendName(nil, test.name)
+		if err != nil {
+			t.Fatalf("error encoding %s: %s", test.name, err)
+		}
+		name, _, err := readName(b, 0)
+		if err != nil {
+			t.Fatalf("error decoding %s: %s", test.name, err)
+		}
+		if want := test.name; want[len(want)-1] != '.' {
+			test.name += "."
+		}
+		if name != test.name {
+			t.Errorf("\t got = %s\n\t\t\twant = %s", name, test.name)
+		}
+	}
+	if _, err := appendName(nil, "a..local."); err == nil {
+		t.Error("expected error encoding empty label")
+	}
+}
+
+func TestText(t *testing.T) {
+	text := map[string]string{"txtvers": "1", "model": "X32", "flag": ""}
+	s := textRecord(text)
+	if want := []string{"flag=", "model=X32", "txtvers=1"}; !reflect.DeepEqual(s, want) {
+		t.Errorf("\t got = %q\n\t\t\twant = %q", s, want)
+	}
+	got := parseText(append(s, "model=other", "bare", ""))
+	text["bare"] = ""
+	if !reflect.DeepEqual(got, text) {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", got, text)
+	}
+}
@@ -0,0 +1,661 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+/*
+Package oscdnssd advertises and discovers Open Sound Control (OSC) services
+with DNS Service Discovery (DNS-SD) over multicast DNS (mDNS), as zeroconf
+implementations such as Bonjour and Avahi do, with the _osc._udp and _osc._tcp
+service types.
+
+It is implemented in pure Go over IPv4 multicast, without an external daemon.
+A Responder answers the queries for its services, and a Browser discovers the
+services of a type with their host, port, addresses and TXT metadata:
+
+	r := &oscdnssd.Responder{Services: []*oscdnssd.Service{{
+		Instance: "Synth",
+		Type:     oscdnssd.UDP,
+		Port:     8000,
+		Text:     map[string]string{"txtvers": "1"},
+	}}}
+	go r.ListenAndServe()
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	services, err := oscdnssd.Lookup(ctx, oscdnssd.UDP)
+
+The Responder does not probe for conflicting instance names.
+*/
+package oscdnssd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// Service types of OSC services.
+const (
+	UDP = "_osc._udp"
+	TCP = "_osc._tcp"
+)
+
+// DefaultDomain is the domain of mDNS names.
+const DefaultDomain = "local."
+
+// DefaultAddr is the mDNS IPv4 multicast group address.
+var DefaultAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// TTL of the records of a Responder: host records are refreshed more often,
+// as recommended by RFC 6762.
+const (
+	hostTTL    = 120
+	serviceTTL = 4500
+	// legacyTTL is the maximum TTL of records sent to legacy queriers.
+	legacyTTL = 10
+)
+
+// servicesName is the name listing the service types of a domain.
+const servicesName = "_services._dns-sd._udp."
+
+// Service is an instance of an OSC service.
+type Service struct {
+	// Instance is the user-friendly name of the service, e.g. "X32 Rack".
+	Instance string
+	// Type is the service type, UDP or TCP.
+	Type string
+	// Domain is the domain of the service. If empty, DefaultDomain is used.
+	Domain string
+
+	// Host is the domain name of the host providing the service. If empty, a
+	// Responder uses the host name in its domain.
+	Host string
+	Port int
+
+	// IPs are the addresses of the host. If empty, a Responder uses the
+	// addresses of its interface.
+	IPs []net.IP
+
+	// Text holds the metadata of the service's TXT record.
+	Text map[string]string
+}
+
+// NewService returns the service for the local UDP or TCP address of an OSC
+// server.
+func NewService(instance string, addr net.Addr) (*Service, error) {
+	s := &Service{Instance: instance}
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		s.Type, s.Port, ip = UDP, a.Port, a.IP
+	case *net.TCPAddr:
+		s.Type, s.Port, ip = TCP, a.Port, a.IP
+	default:
+		return nil, fmt.Errorf("unsupported %s address %s", addr.Network(), addr)
+	}
+	if ip != nil && !ip.IsUnspecified() {
+		s.IPs = []net.IP{ip}
+	}
+	return s, nil
+}
+
+// Name returns the domain name of the service instance, in which the dots of
+// the instance are escaped.
+func (s *Service) Name() string {
+	return escapeLabel(s.Instance) + "." + s.typeName()
+}
+
+func (s *Service) typeName() string {
+	return s.Type + "." + s.domain()
+}
+
+func (s *Service) domain() string {
+	if s.Domain == "" {
+		return DefaultDomain
+	}
+	return s.Domain
+}
+
+func (s *Service) String() string {
+	return fmt.Sprintf("%s at %s:%d", s.Name(), s.Host, s.Port)
+}
+
+// Responder answers the mDNS queries for its services.
+type Responder struct {
+	Services []*Service
+
+	// Interface is the network interface to serve on. If nil, the system
+	// chooses one.
+	Interface *net.Interface
+	// Addr is the multicast group address. If nil, DefaultAddr is used.
+	Addr *net.UDPAddr
+
+	// ErrorLog logs queries that cannot be decoded and replies that cannot be
+	// sent. If nil, they are logged using the log package's standard logger.
+	ErrorLog *log.Logger
+
+	mu     sync.Mutex
+	conn   net.PacketConn
+	closed bool
+}
+
+// ListenAndServe joins the multicast group on the Interface and serves the
+// queries received.
+func (r *Responder) ListenAndServe() error {
+	conn, err := net.ListenMulticastUDP("udp4", r.Interface, r.addr())
+	if err != nil {
+		return err
+	}
+	return r.Serve(conn)
+}
+
+// Serve announces the services on the connection, which has joined the
+// multicast group, then serves the queries received until it fails or the
+// Responder is closed. The connection is closed on return.
+func (r *Responder) Serve(conn net.PacketConn) error {
+	r.mu.Lock()
+	if r.closed || r.conn != nil {
+		r.mu.Unlock()
+		conn.Close()
+		return osc.ErrServerClosed
+	}
+	r.conn = conn
+	r.mu.Unlock()
+	defer conn.Close()
+
+	if err := r.multicast(conn, r.announcement(serviceTTL)); err != nil {
+		r.logf("oscdnssd: announcing: %s", err)
+	}
+	b := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFrom(b)
+		if err != nil {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.closed {
+				return osc.ErrServerClosed
+			}
+			return err
+		}
+		m, err := parseMessage(b[:n])
+		if err != nil {
+			r.logf("oscdnssd: query from %s: %s", from, err)
+			continue
+		}
+		if m.isResponse() {
+			continue
+		}
+		r.answer(conn, m, from)
+	}
+}
+
+// Close sends goodbye records for the services and stops serving.
+func (r *Responder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.conn == nil {
+		return nil
+	}
+	if err := r.multicast(r.conn, r.announcement(0)); err != nil {
+		r.logf("oscdnssd: saying goodbye: %s", err)
+	}
+	return r.conn.Close()
+}
+
+func (r *Responder) addr() *net.UDPAddr {
+	if r.Addr == nil {
+		return DefaultAddr
+	}
+	return r.Addr
+}
+
+func (r *Responder) multicast(conn net.PacketConn, m *message) error {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = conn.WriteTo(b, r.addr())
+	return err
+}
+
+// answer replies to a query. Queries from ports other than the mDNS port come
+// from legacy queriers and are answered by unicast, as are questions asking
+// for a unicast response. Other queries are answered by multicast.
+func (r *Responder) answer(conn net.PacketConn, q *message, from net.Addr) {
+	udp, _ := from.(*net.UDPAddr)
+	legacy := udp != nil && udp.Port != r.addr().Port
+	unicast := legacy
+	reply := &message{Flags: flagResponse | flagAuth}
+	if legacy {
+		reply.ID = q.ID
+		reply.Questions = q.Questions
+	}
+	for _, question := range q.Questions {
+		if question.Class&unicastResponse != 0 {
+			unicast = true
+		}
+		answers, extra := r.records(question)
+		reply.Answers = append(reply.Answers, answers...)
+		reply.Extra = append(reply.Extra, extra...)
+	}
+	if len(reply.Answers) == 0 {
+		return
+	}
+	reply.Extra = uniqueRecords(reply.Extra, reply.Answers)
+	if legacy {
+		for _, rrs := range [][]record{reply.Answers, reply.Extra} {
+			for i := range rrs {
+				rrs[i].Class &= classMask
+				if rrs[i].TTL > legacyTTL {
+					rrs[i].TTL = legacyTTL
+				}
+			}
+		}
+	}
+	b, err := reply.MarshalBinary()
+	if err == nil {
+		if unicast {
+			_, err = conn.WriteTo(b, from)
+		} else {
+			_, err = conn.WriteTo(b, r.addr())
+		}
+	}
+	if err != nil {
+		r.logf("oscdnssd: replying to %s: %s", from, err)
+	}
+}
+
+// records returns the answers to the question and the additional records
+// describing them.
+func (r *Responder) records(q question) (answers, extra []record) {
+	qtype := q.Type
+	for _, s := range r.Services {
+		name, typeName := s.Name(), s.typeName()
+		switch {
+		case equalName(q.Name, servicesName+s.domain()) && (qtype == typePTR || qtype == typeANY):
+			answers = append(answers, record{Name: servicesName + s.domain(), Type: typePTR, Class: classIN, TTL: serviceTTL, Target: typeName})
+		case equalName(q.Name, typeName) && (qtype == typePTR || qtype == typeANY):
+			answers = append(answers, r.ptr(s, serviceTTL))
+			extra = append(extra, r.srv(s, serviceTTL), r.txt(s, serviceTTL))
+			extra = append(extra, r.addresses(s, hostTTL)...)
+		case equalName(q.Name, name):
+			if qtype == typeSRV || qtype == typeANY {
+				answers = append(answers, r.srv(s, serviceTTL))
+				extra = append(extra, r.addresses(s, hostTTL)...)
+			}
+			if qtype == typeTXT || qtype == typeANY {
+				answers = append(answers, r.txt(s, serviceTTL))
+			}
+		case equalName(q.Name, r.host(s)):
+			for _, rr := range r.addresses(s, hostTTL) {
+				if qtype == rr.Type || qtype == typeANY {
+					answers = append(answers, rr)
+				}
+			}
+		}
+	}
+	return answers, extra
+}
+
+// announcement returns the unsolicited response announcing the services, or
+// saying goodbye if the TTL is 0.
+func (r *Responder) announcement(ttl uint32) *message {
+	m := &message{Flags: flagResponse | flagAuth}
+	for _, s := range r.Services {
+		m.Answers = append(m.Answers, r.ptr(s, ttl), r.srv(s, ttl), r.txt(s, ttl))
+		if ttl > 0 {
+			m.Answers = append(m.Answers, r.addresses(s, hostTTL)...)
+		}
+	}
+	m.Answers = uniqueRecords(m.Answers, nil)
+	return m
+}
+
+func (r *Responder) ptr(s *Service, ttl uint32) record {
+	return record{Name: s.typeName(), Type: typePTR, Class: classIN, TTL: ttl, Target: s.Name()}
+}
+
+func (r *Responder) srv(s *Service, ttl uint32) record {
+	return record{Name: s.Name(), Type: typeSRV, Class: classIN | cacheFlush, TTL: ttl, Port: uint16(s.Port), Target: r.host(s)}
+}
+
+func (r *Responder) txt(s *Service, ttl uint32) record {
+	return record{Name: s.Name(), Type: typeTXT, Class: classIN | cacheFlush, TTL: ttl, Text: textRecord(s.Text)}
+}
+
+func (r *Responder) addresses(s *Service, ttl uint32) []record {
+	ips := s.IPs
+	if len(ips) == 0 {
+		ips = interfaceIPs(r.Interface)
+	}
+	rrs := make([]record, 0, len(ips))
+	for _, ip := range ips {
+		rr := record{Name: r.host(s), Type: typeAAAA, Class: classIN | cacheFlush, TTL: ttl, IP: ip}
+		if ip.To4() != nil {
+			rr.Type = typeA
+		}
+		rrs = append(rrs, rr)
+	}
+	return rrs
+}
+
+func (r *Responder) host(s *Service) string {
+	if s.Host != "" {
+		return s.Host
+	}
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		name = "localhost"
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		name = name[:i]
+	}
+	return name + "." + s.domain()
+}
+
+func (r *Responder) logf(format string, args ...interface{}) {
+	if r.ErrorLog != nil {
+		r.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// interfaceIPs returns the addresses of the interface, or of all the
+// interfaces that are up if nil, preferring those that are not loopback
+// addresses.
+func interfaceIPs(ifi *net.Interface) []net.IP {
+	var addrs []net.Addr
+	if ifi != nil {
+		addrs, _ = ifi.Addrs()
+	} else {
+		ifis, _ := net.Interfaces()
+		for _, ifi := range ifis {
+			if ifi.Flags&net.FlagUp == 0 {
+				continue
+			}
+			a, _ := ifi.Addrs()
+			addrs = append(addrs, a...)
+		}
+	}
+	var ips, loopback []net.IP
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipnet.IP.IsLoopback() {
+			loopback = append(loopback, ipnet.IP)
+		} else {
+			ips = append(ips, ipnet.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return loopback
+	}
+	return ips
+}
+
+// uniqueRecords returns the records that are not duplicated or in the
+// excluded records.
+func uniqueRecords(rrs, exclude []record) []record {
+	seen := make(map[string]bool)
+	key := func(rr record) string {
+		return fmt.Sprintf("%s %d %s %d %s %v", strings.ToLower(rr.Name), rr.Type, rr.Target, rr.Port, rr.IP, rr.Text)
+	}
+	for _, rr := range exclude {
+		seen[key(rr)] = true
+	}
+	unique := rrs[:0:0]
+	for _, rr := range rrs {
+		if k := key(rr); !seen[k] {
+			seen[k] = true
+			unique = append(unique, rr)
+		}
+	}
+	return unique
+}
+
+// Browser discovers services by sending mDNS queries and collecting the
+// responses. It queries as a legacy querier from an ephemeral port, so it
+// does not need the mDNS port, and responders answer it by unicast.
+type Browser struct {
+	// Interface is the network interface to query on. If nil, the system
+	// chooses one.
+	Interface *net.Interface
+	// Addr is the multicast group address. If nil, DefaultAddr is used.
+	Addr *net.UDPAddr
+}
+
+// Time between the queries of a Browser, doubling up to the maximum.
+const (
+	minQueryInterval = time.Second
+	maxQueryInterval = time.Minute
+)
+
+// Browse discovers the services of the type, e.g. UDP or TCP, in the default
+// domain until the context is done, and returns the context's error. It
+// calls found with each service discovered, and again when its host, port,
+// addresses or metadata change. The calls are made from the goroutine that
+// called Browse.
+func (b *Browser) Browse(ctx context.Context, serviceType string, found func(*Service)) error {
+	var conn *net.UDPConn
+	var err error
+	if b.Interface != nil {
+		// Listening on the group with an ephemeral port selects the
+		// interface for the queries.
+		conn, err = net.ListenMulticastUDP("udp4", b.Interface, &net.UDPAddr{IP: b.addr().IP})
+	} else {
+		conn, err = net.ListenUDP("udp4", nil)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	typeName := serviceType + "." + DefaultDomain
+	c := newCache(typeName)
+	reported := make(map[string]string)
+	buf := make([]byte, 9000)
+	interval := minQueryInterval
+	next := time.Now()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		now := time.Now()
+		if !now.Before(next) {
+			questions := []question{{Name: typeName, Type: typePTR, Class: classIN}}
+			for _, name := range c.incomplete() {
+				questions = append(questions,
+					question{Name: name, Type: typeSRV, Class: classIN},
+					question{Name: name, Type: typeTXT, Class: classIN})
+			}
+			if err := b.query(conn, questions); err != nil {
+				return err
+			}
+			next = now.Add(interval)
+			if interval *= 2; interval > maxQueryInterval {
+				interval = maxQueryInterval
+			}
+		}
+		deadline := next
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		// Wake up regularly to notice the cancellation of the context.
+		if d := time.Now().Add(100 * time.Millisecond); d.Before(deadline) {
+			deadline = d
+		}
+		conn.SetReadDeadline(deadline)
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		m, err := parseMessage(buf[:n])
+		if err != nil || !m.isResponse() {
+			continue
+		}
+		c.add(m)
+		for _, s := range c.services() {
+			key := s.Name()
+			if v := fmt.Sprint(*s); reported[key] != v {
+				reported[key] = v
+				found(s)
+			}
+		}
+	}
+}
+
+func (b *Browser) addr() *net.UDPAddr {
+	if b.Addr == nil {
+		return DefaultAddr
+	}
+	return b.Addr
+}
+
+func (b *Browser) query(conn *net.UDPConn, questions []question) error {
+	m := &message{ID: uint16(time.Now().UnixNano()), Questions: questions}
+	p, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = conn.WriteToUDP(p, b.addr())
+	return err
+}
+
+// Lookup browses the services of the type on the interface chosen by the
+// system until the context is done, and returns the services discovered
+// sorted by name.
+func Lookup(ctx context.Context, serviceType string) ([]*Service, error) {
+	var b Browser
+	found := make(map[string]*Service)
+	err := b.Browse(ctx, serviceType, func(s *Service) {
+		found[s.Name()] = s
+	})
+	if err != nil && ctx.Err() == nil {
+		return nil, err
+	}
+	services := make([]*Service, 0, len(found))
+	for _, s := range found {
+		services = append(services, s)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name() < services[j].Name()
+	})
+	return services, nil
+}
+
+// cache holds the records received by a Browser.
+type cache struct {
+	typeName  string
+	instances map[string]bool
+	srv       map[string]record
+	txt       map[string]record
+	ips       map[string][]net.IP
+}
+
+func newCache(typeName string) *cache {
+	return &cache{
+		typeName:  typeName,
+		instances: make(map[string]bool),
+		srv:       make(map[string]record),
+		txt:       make(map[string]record),
+		ips:       make(map[string][]net.IP),
+	}
+}
+
+// add adds the records of the response to the cache. Records with a TTL of 0
+// say goodbye and remove the records.
+func (c *cache) add(m *message) {
+	for _, rrs := range [][]record{m.Answers, m.Extra} {
+		for _, rr := range rrs {
+			name := strings.ToLower(rr.Name)
+			switch rr.Type {
+			case typePTR:
+				if equalName(rr.Name, c.typeName) {
+					c.instances[strings.ToLower(rr.Target)] = rr.TTL > 0
+				}
+			case typeSRV:
+				c.srv[name] = rr
+			case typeTXT:
+				c.txt[name] = rr
+			case typeA, typeAAAA:
+				if rr.TTL == 0 {
+					delete(c.ips, name)
+					continue
+				}
+				c.ips[name] = appendIP(c.ips[name], rr.IP)
+			}
+		}
+	}
+}
+
+func appendIP(ips []net.IP, ip net.IP) []net.IP {
+	for _, x := range ips {
+		if x.Equal(ip) {
+			return ips
+		}
+	}
+	return append(ips, ip)
+}
+
+// incomplete returns the names of the instances without an SRV record.
+func (c *cache) incomplete() []string {
+	var names []string
+	for name, ok := range c.instances {
+		if _, found := c.srv[name]; ok && !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// services returns the instances with an SRV record.
+func (c *cache) services() []*Service {
+	var services []*Service
+	for name, ok := range c.instances {
+		srv, found := c.srv[name]
+		if !ok || !found || srv.TTL == 0 {
+			continue
+		}
+		labels := splitName(srv.Name)
+		if len(labels) < 4 {
+			continue
+		}
+		s := &Service{
+			Instance: labels[0],
+			Type:     labels[1] + "." + labels[2],
+			Domain:   strings.Join(labels[3:], ".") + ".",
+			Host:     srv.Target,
+			Port:     int(srv.Port),
+			IPs:      c.ips[strings.ToLower(srv.Target)],
+			Text:     parseText(c.txt[name].Text),
+		}
+		services = append(services, s)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name() < services[j].Name()
+	})
+	return services
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscdnssd
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testAddr is a multicast group address that does not interfere with the
+// system's mDNS responder.
+var testAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 53531}
+
+func loopback(t *testing.T) *net.Interface {
+	ifis, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("no interfaces: %s", err)
+	}
+	for _, ifi := range ifis {
+		if ifi.Flags&net.FlagLoopback != 0 && ifi.Flags&net.FlagUp != 0 {
+			return &ifi
+		}
+	}
+	t.Skip("no loopback interface")
+	return nil
+}
+
+func serve(t *testing.T, ifi *net.Interface, services ...*Service) *Responder {
+	conn, err := net.ListenMulticastUDP("udp4", ifi, testAddr)
+	if err != nil {
+		t.Skipf("cannot join multicast group on %s: %s", ifi.Name, err)
+	}
+	r := &Responder{
+		Services:  services,
+		Interface: ifi,
+		Addr:      testAddr,
+		ErrorLog:  log.New(ioutil.Discard, "", 0),
+	}
+	go r.Serve(conn)
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestBrowse(t *testing.T) {
+	ifi := loopback(t)
+	synth := &Service{
+		Instance: "Synth 1.0",
+		Type:     UDP,
+		Host:     "synth.local.",
+		Port:     8000,
+		IPs:      []net.IP{net.IPv4(127, 0, 0, 1).To4()},
+		Text:     map[string]string{"txtvers": "1", "model": "Modular"},
+	}
+	mixer := &Service{
+		Instance: "X32",
+		Type:     TCP,
+		Host:     "x32.local.",
+		Port:     10023,
+		IPs:      []net.IP{net.IPv4(127, 0, 0, 1).To4()},
+	}
+	serve(t, ifi, synth, mixer)
+
+	b := &Browser{Interface: ifi, Addr: testAddr}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var found []*Service
+	err := b.Browse(ctx, UDP, func(s *Service) {
+		found = append(found, s)
+		cancel()
+	})
+	if err != context.Canceled {
+		t.Fatalf("browsing: %v", err)
+	}
+	want := *synth
+	want.Domain = DefaultDomain
+	if len(found) != 1 || !reflect.DeepEqual(*found[0], want) {
+		t.Fatalf("\t got = %+v\n\t\t\twant = %+v", found, []*Service{&want})
+	}
+	if got, want := found[0].Name(), `Synth 1\.0._osc._udp.local.`; got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+}
+
+func TestResponder(t *testing.T) {
+	ifi := loopback(t)
+	s := &Service{Instance: "X32", Type: UDP, Host: "x32.local.", Port: 10023, IPs: []net.IP{net.IPv4(127, 0, 0, 1).To4()}}
+	serve(t, ifi, s)
+
+	conn, err := net.ListenMulticastUDP("udp4", ifi, &net.UDPAddr{IP: testAddr.IP})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var tests = []struct {
+		q     question
+		types []uint16
+	}{
+		{question{"_services._dns-sd._udp.local.", typePTR, classIN}, []uint16{typePTR}},
+		{question{"_osc._udp.local.", typePTR, classIN}, []uint16{typePTR}},
+		{question{"X32._osc._udp.local.", typeSRV, classIN}, []uint16{typeSRV}},
+		{question{"x32._osc._udp.local.", typeANY, classIN}, []uint16{typeSRV, typeTXT}},
+		{question{"X32.LOCAL.", typeA, classIN}, []uint16{typeA}},
+	}
+	for _, test := range tests {
+		b, _ := (&message{ID: 42, Questions: []question{test.q}}).MarshalBinary()
+		if _, err := conn.WriteToUDP(b, testAddr); err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		buf := make([]byte, 9000)
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("%s: %s", test.q.Name, err)
+		}
+		m, err := parseMessage(buf[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.ID != 42 || !m.isResponse() || len(m.Questions) != 1 {
+			t.Errorf("%s: unexpected legacy response %+v", test.q.Name, m)
+		}
+		var types []uint16
+		for _, rr := range m.Answers {
+			types = append(types, rr.Type)
+			if rr.TTL > legacyTTL || rr.Class != classIN {
+				t.Errorf("%s: unexpected legacy record %+v", test.q.Name, rr)
+			}
+		}
+		if !reflect.DeepEqual(types, test.types) {
+			t.Errorf("%s:\t got = %v\n\t\t\twant = %v", test.q.Name, types, test.types)
+		}
+	}
+}
+
+func TestNewService(t *testing.T) {
+	s, err := NewService("Synth", &net.UDPAddr{Port: 8000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Type != UDP || s.Port != 8000 || s.IPs != nil {
+		t.Errorf("unexpected service %+v", s)
+	}
+	s, err = NewService("Synth", &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 9000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Type != TCP || s.Port != 9000 || len(s.IPs) != 1 {
+		t.Errorf("unexpected service %+v", s)
+	}
+	if _, err := NewService("Synth", &net.UnixAddr{Name: "/tmp/osc", Net: "unix"}); err == nil {
+		t.Error("expected error for unix address")
+	}
+}
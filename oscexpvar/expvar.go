@@ -0,0 +1,148 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+/*
+Package oscexpvar exports the events observed on OSC clients, servers,
+dispatchers and coalescers as counters, both through the expvar package and in
+the Prometheus text format.
+
+	o := oscexpvar.New("osc")
+	expvar.Publish("osc", o.Vars())
+	server := &osc.Server{Handler: &dispatcher, Observer: o}
+	dispatcher.Observer = o
+	http.Handle("/metrics", o)
+
+The counters are named in the Prometheus style, e.g. packets_received_total,
+and are prefixed with the name given to New. Publishing their expvar.Map makes
+them appear in /debug/vars. The per-address counts are labeled with the address
+of the method; their number grows with the number of methods dispatched to.
+*/
+package oscexpvar
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// Observer is an osc.Observer that counts the events it observes.
+type Observer struct {
+	name string
+	vars expvar.Map
+
+	packetsReceived expvar.Int
+	bytesReceived   expvar.Int
+	packetsSent     expvar.Int
+	bytesSent       expvar.Int
+	decodeErrors    expvar.Int
+	dispatched      expvar.Int
+	dispatchSeconds expvar.Float
+	unhandled       expvar.Int
+	lateBundles     expvar.Int
+	dropped         expvar.Int
+	byAddress       expvar.Map
+}
+
+// metric describes a counter.
+type metric struct {
+	name string
+	help string
+}
+
+var metrics = []metric{
+	{"packets_received_total", "OSC packets received."},
+	{"bytes_received_total", "Bytes of OSC packets received."},
+	{"packets_sent_total", "OSC packets sent."},
+	{"bytes_sent_total", "Bytes of OSC packets sent."},
+	{"decode_errors_total", "OSC packets received that could not be decoded."},
+	{"messages_dispatched_total", "OSC messages dispatched to methods."},
+	{"dispatch_seconds_total", "Time spent in OSC methods."},
+	{"messages_unhandled_total", "OSC messages that matched no method."},
+	{"bundles_late_total", "OSC bundles received after their time tag."},
+	{"messages_dropped_total", "Queued OSC messages replaced by later messages."},
+	{"messages_dispatched_by_address_total", "OSC messages dispatched by method address."},
+}
+
+// New returns an Observer whose Prometheus metric names are prefixed with the
+// name, if not empty. The counters are not published with expvar; as
+// expvar.Publish panics if a name is reused, that is left to the caller, e.g.
+// with expvar.Publish(name, o.Vars()).
+func New(name string) *Observer {
+	o := &Observer{name: name}
+	o.vars.Init()
+	o.byAddress.Init()
+	vars := []expvar.Var{
+		&o.packetsReceived, &o.bytesReceived, &o.packetsSent, &o.bytesSent,
+		&o.decodeErrors, &o.dispatched, &o.dispatchSeconds, &o.unhandled,
+		&o.lateBundles, &o.dropped, &o.byAddress,
+	}
+	for i, m := range metrics {
+		o.vars.Set(m.name, vars[i])
+	}
+	return o
+}
+
+// Observe implements the osc.Observer interface for Observer.
+func (o *Observer) Observe(e osc.Event) {
+	switch e.Kind {
+	case osc.PacketReceived:
+		o.packetsReceived.Add(1)
+		o.bytesReceived.Add(int64(e.Size))
+	case osc.PacketSent:
+		o.packetsSent.Add(1)
+		o.bytesSent.Add(int64(e.Size))
+	case osc.DecodeError:
+		o.decodeErrors.Add(1)
+	case osc.MessageDispatched:
+		o.dispatched.Add(1)
+		o.dispatchSeconds.Add(e.Duration.Seconds())
+		o.byAddress.Add(e.Address, 1)
+	case osc.MessageUnhandled:
+		o.unhandled.Add(1)
+	case osc.BundleLate:
+		o.lateBundles.Add(1)
+	case osc.MessageDropped:
+		o.dropped.Add(1)
+	}
+}
+
+// Vars returns the map of the counters, to be published with expvar.
+func (o *Observer) Vars() *expvar.Map {
+	return &o.vars
+}
+
+// ServeHTTP writes the counters in the Prometheus text format.
+func (o *Observer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	prefix := ""
+	if o.name != "" {
+		prefix = o.name + "_"
+	}
+	for _, m := range metrics {
+		name := prefix + m.name
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, m.help, name)
+		v := o.vars.Get(m.name)
+		byAddress, ok := v.(*expvar.Map)
+		if !ok {
+			fmt.Fprintf(w, "%s %s\n", name, v)
+			continue
+		}
+		var addrs []string
+		byAddress.Do(func(kv expvar.KeyValue) {
+			addrs = append(addrs, kv.Key)
+		})
+		sort.Strings(addrs)
+		for _, addr := range addrs {
+			fmt.Fprintf(w, "%s{address=\"%s\"} %s\n", name, labelEscaper.Replace(addr), byAddress.Get(addr))
+		}
+	}
+}
+
+// labelEscaper escapes a label value in the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,81 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscexpvar
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+func TestObserver(t *testing.T) {
+	o := New("osc_test")
+	events := []osc.Event{
+		{Kind: osc.PacketReceived, Size: 28},
+		{Kind: osc.PacketReceived, Size: 7},
+		{Kind: osc.DecodeError, Size: 7, Err: errors.New("bad")},
+		{Kind: osc.PacketSent, Size: 16},
+		{Kind: osc.MessageDispatched, Address: "/ch/01/mix/fader", Duration: 250 * time.Millisecond},
+		{Kind: osc.MessageDispatched, Address: "/ch/01/mix/fader", Duration: 250 * time.Millisecond},
+		{Kind: osc.MessageDispatched, Address: "/ch/01/mix/on", Duration: 500 * time.Millisecond},
+		{Kind: osc.MessageUnhandled, Address: "/nowhere"},
+		{Kind: osc.BundleLate, Duration: time.Second},
+		{Kind: osc.MessageDropped, Address: "/ch/01/mix/fader", Size: 28},
+		{Kind: osc.MessageDispatched, Address: "/a\"b\\c\n\u00e9"},
+	}
+	for _, e := range events {
+		o.Observe(e)
+	}
+
+	if got, want := o.Vars().Get("bytes_received_total").String(), "35"; got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+
+	w := httptest.NewRecorder()
+	o.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	want := `# HELP osc_test_packets_received_total OSC packets received.
+# TYPE osc_test_packets_received_total counter
+osc_test_packets_received_total 2
+# HELP osc_test_bytes_received_total Bytes of OSC packets received.
+# TYPE osc_test_bytes_received_total counter
+osc_test_bytes_received_total 35
+# HELP osc_test_packets_sent_total OSC packets sent.
+# TYPE osc_test_packets_sent_total counter
+osc_test_packets_sent_total 1
+# HELP osc_test_bytes_sent_total Bytes of OSC packets sent.
+# TYPE osc_test_bytes_sent_total counter
+osc_test_bytes_sent_total 16
+# HELP osc_test_decode_errors_total OSC packets received that could not be decoded.
+# TYPE osc_test_decode_errors_total counter
+osc_test_decode_errors_total 1
+# HELP osc_test_messages_dispatched_total OSC messages dispatched to methods.
+# TYPE osc_test_messages_dispatched_total counter
+osc_test_messages_dispatched_total 4
+# HELP osc_test_dispatch_seconds_total Time spent in OSC methods.
+# TYPE osc_test_dispatch_seconds_total counter
+osc_test_dispatch_seconds_total 1
+# HELP osc_test_messages_unhandled_total OSC messages that matched no method.
+# TYPE osc_test_messages_unhandled_total counter
+osc_test_messages_unhandled_total 1
+# HELP osc_test_bundles_late_total OSC bundles received after their time tag.
+# TYPE osc_test_bundles_late_total counter
+osc_test_bundles_late_total 1
+# HELP osc_test_messages_dropped_total Queued OSC messages replaced by later messages.
+# TYPE osc_test_messages_dropped_total counter
+osc_test_messages_dropped_total 1
+# HELP osc_test_messages_dispatched_by_address_total OSC messages dispatched by method address.
+# TYPE osc_test_messages_dispatched_by_address_total counter
+osc_test_messages_dispatched_by_address_total{address="/a\"b\\c\né"} 1
+osc_test_messages_dispatched_by_address_total{address="/ch/01/mix/fader"} 2
+osc_test_messages_dispatched_by_address_total{address="/ch/01/mix/on"} 1
+`
+	if got := w.Body.String(); got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+/*
+Package oscmidi converts between the 4-byte MIDI messages carried by Open Sound
+Control (OSC) m arguments and MIDI events, and translates MIDI control changes
+to OSC messages and back.
+
+An OSC MIDI argument holds a port ID, a status byte and two data bytes, so it
+carries any MIDI channel or system message except system exclusive, which
+SysEx and ParseSysEx frame for OSC blob arguments instead.
+*/
+package oscmidi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// Type is the type of a MIDI event: the high nibble of the status byte of a
+// channel message, or the whole status byte of a system message.
+type Type byte
+
+// Enum for the MIDI channel message types.
+const (
+	NoteOff         Type = 0x80
+	NoteOn          Type = 0x90
+	PolyPressure    Type = 0xa0
+	ControlChange   Type = 0xb0
+	ProgramChange   Type = 0xc0
+	ChannelPressure Type = 0xd0
+	PitchBend       Type = 0xe0
+)
+
+var types = map[Type]string{
+	NoteOff:         "note off",
+	NoteOn:          "note on",
+	PolyPressure:    "poly pressure",
+	ControlChange:   "control change",
+	ProgramChange:   "program change",
+	ChannelPressure: "channel pressure",
+	PitchBend:       "pitch bend",
+}
+
+func (t Type) String() string {
+	if s, ok := types[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("system 0x%02x", byte(t))
+}
+
+// Event is a MIDI channel or system message.
+type Event struct {
+	// Port is the port ID of the OSC MIDI argument.
+	Port byte
+	Type Type
+
+	// Channel is the MIDI channel from 1 to 16, or 0 for system messages.
+	Channel int
+
+	Data1 byte
+	Data2 byte
+}
+
+// Note returns a note on event, or a note off event if the velocity is 0.
+func Note(ch int, note, velocity byte) Event {
+	if velocity == 0 {
+		return Event{Type: NoteOff, Channel: ch, Data1: note}
+	}
+	return Event{Type: NoteOn, Channel: ch, Data1: note, Data2: velocity}
+}
+
+// CC returns a control change event.
+func CC(ch int, controller, value byte) Event {
+	return Event{Type: ControlChange, Channel: ch, Data1: controller, Data2: value}
+}
+
+// Program returns a program change event.
+func Program(ch int, program byte) Event {
+	return Event{Type: ProgramChange, Channel: ch, Data1: program}
+}
+
+// Bend returns a pitch bend event for the bend from -8192 to 8191, 0 being
+// the center.
+func Bend(ch int, bend int) Event {
+	v := bend + 8192
+	if v < 0 {
+		v = 0
+	} else if v > 0x3fff {
+		v = 0x3fff
+	}
+	return Event{Type: PitchBend, Channel: ch, Data1: byte(v & 0x7f), Data2: byte(v >> 7)}
+}
+
+// Bend returns the pitch bend of a pitch bend event, from -8192 to 8191.
+func (e Event) Bend() int {
+	return int(e.Data2&0x7f)<<7 | int(e.Data1&0x7f) - 8192
+}
+
+// IsNoteOff reports whether the event is a note off, including a note on with
+// velocity 0.
+func (e Event) IsNoteOff() bool {
+	return e.Type == NoteOff || e.Type == NoteOn && e.Data2 == 0
+}
+
+// MIDI returns the event as an OSC MIDI argument.
+func (e Event) MIDI() (osc.MIDI, error) {
+	if e.Data1 > 0x7f || e.Data2 > 0x7f {
+		return osc.MIDI{}, fmt.Errorf("MIDI data bytes %d %d out of range 0-127", e.Data1, e.Data2)
+	}
+	if e.Type < 0xf0 {
+		if _, ok := types[e.Type]; !ok {
+			return osc.MIDI{}, fmt.Errorf("invalid MIDI event type 0x%02x", byte(e.Type))
+		}
+		if e.Channel < 1 || e.Channel > 16 {
+			return osc.MIDI{}, fmt.Errorf("MIDI channel %d out of range 1-16", e.Channel)
+		}
+		return osc.MIDI{e.Port, byte(e.Type) | byte(e.Channel-1), e.Data1, e.Data2}, nil
+	}
+	if e.Type == sysExStart || e.Type == sysExEnd {
+		return osc.MIDI{}, errors.New("MIDI system exclusive does not fit in an OSC MIDI argument")
+	}
+	return osc.MIDI{e.Port, byte(e.Type), e.Data1, e.Data2}, nil
+}
+
+// Decode returns the event carried by an OSC MIDI argument.
+func Decode(m osc.MIDI) (Event, error) {
+	port, status := m[0], m[1]
+	if status < 0x80 {
+		return Event{}, fmt.Errorf("invalid MIDI status byte 0x%02x", status)
+	}
+	if status == sysExStart || status == sysExEnd {
+		return Event{}, errors.New("MIDI system exclusive does not fit in an OSC MIDI argument")
+	}
+	e := Event{Port: port, Type: Type(status), Data1: m[2] & 0x7f, Data2: m[3] & 0x7f}
+	if status < 0xf0 {
+		e.Type, e.Channel = Type(status&0xf0), int(status&0x0f)+1
+	}
+	return e, nil
+}
+
+// String returns the event in a readable form, e.g. "ch 1 control change 7 100".
+func (e Event) String() string {
+	if e.Channel == 0 {
+		return fmt.Sprintf("%s %d %d", e.Type, e.Data1, e.Data2)
+	}
+	switch e.Type {
+	case ProgramChange, ChannelPressure:
+		return fmt.Sprintf("ch %d %s %d", e.Channel, e.Type, e.Data1)
+	case PitchBend:
+		return fmt.Sprintf("ch %d %s %d", e.Channel, e.Type, e.Bend())
+	}
+	return fmt.Sprintf("ch %d %s %d %d", e.Channel, e.Type, e.Data1, e.Data2)
+}
+
+// MIDI system exclusive status bytes.
+const (
+	sysExStart = 0xf0
+	sysExEnd   = 0xf7
+)
+
+// SysEx frames the data of a system exclusive message, which starts with the
+// manufacturer ID, with its start and end bytes, for an OSC blob argument.
+func SysEx(data []byte) ([]byte, error) {
+	b := make([]byte, 0, len(data)+2)
+	b = append(b, sysExStart)
+	for i, x := range data {
+		if x > 0x7f {
+			return nil, fmt.Errorf("system exclusive data byte %d is 0x%02x", i, x)
+		}
+		b = append(b, x)
+	}
+	return append(b, sysExEnd), nil
+}
+
+// ParseSysEx returns the data of a framed system exclusive message.
+func ParseSysEx(b []byte) ([]byte, error) {
+	if len(b) < 2 || b[0] != sysExStart || b[len(b)-1] != sysExEnd {
+		return nil, errors.New("missing system exclusive start or end byte")
+	}
+	data := b[1 : len(b)-1]
+	for i, x := range data {
+		if x > 0x7f {
+			return nil, fmt.Errorf("system exclusive data byte %d is 0x%02x", i, x)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscmidi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/goaudiovideo/osc"
+)
+
+func TestEvents(t *testing.T) {
+	var tests = []struct {
+		event Event
+		midi  osc.MIDI
+		str   string
+	}{
+		{Note(1, 60, 100), osc.MIDI{0, 0x90, 60, 100}, "ch 1 note on 60 100"},
+		{Note(16, 60, 0), osc.MIDI{0, 0x8f, 60, 0}, "ch 16 note off 60 0"},
+		{CC(2, 7, 127), osc.MIDI{0, 0xb1, 7, 127}, "ch 2 control change 7 127"},
+		{Program(10, 5), osc.MIDI{0, 0xc9, 5, 0}, "ch 10 program change 5"},
+		{Bend(1, 0), osc.MIDI{0, 0xe0, 0, 0x40}, "ch 1 pitch bend 0"},
+		{Bend(1, -8192), osc.MIDI{0, 0xe0, 0, 0}, "ch 1 pitch bend -8192"},
+		{Bend(1, 8191), osc.MIDI{0, 0xe0, 0x7f, 0x7f}, "ch 1 pitch bend 8191"},
+		{Event{Port: 3, Type: 0xf8}, osc.MIDI{3, 0xf8, 0, 0}, "system 0xf8 0 0"},
+	}
+	for _, test := range tests {
+		t.Run(test.str, func(t *testing.T) {
+			m, err := test.event.MIDI()
+			if err != nil {
+				t.Fatalf("error encoding: %s", err)
+			}
+			if m != test.midi {
+				t.Errorf("\t got = %x\n\t\t\twant = %x", m, test.midi)
+			}
+			e, err := Decode(m)
+			if err != nil {
+				t.Fatalf("error decoding: %s", err)
+			}
+			if e != test.event {
+				t.Errorf("\t got = %+v\n\t\t\twant = %+v", e, test.event)
+			}
+			if got := e.String(); got != test.str {
+				t.Errorf("\t got = %s\n\t\t\twant = %s", got, test.str)
+			}
+		})
+	}
+	if !Note(1, 60, 0).IsNoteOff() || !(Event{Type: NoteOn, Channel: 1, Data1: 60}).IsNoteOff() {
+		t.Error("expected note off")
+	}
+}
+
+func TestEventErrors(t *testing.T) {
+	var tests = []struct {
+		event Event
+		want  string
+	}{
+		{CC(0, 7, 1), "MIDI channel 0 out of range 1-16"},
+		{CC(17, 7, 1), "MIDI channel 17 out of range 1-16"},
+		{CC(1, 128, 1), "MIDI data bytes 128 1 out of range 0-127"},
+		{Event{Type: 0x10, Channel: 1}, "invalid MIDI event type 0x10"},
+		{Event{Type: 0xf0}, "MIDI system exclusive does not fit in an OSC MIDI argument"},
+	}
+	for _, test := range tests {
+		if _, err := test.event.MIDI(); err == nil || err.Error() != test.want {
+			t.Errorf("\t got = %v\n\t\t\twant = %s", err, test.want)
+		}
+	}
+	if _, err := Decode(osc.MIDI{0, 0x40, 0, 0}); err == nil {
+		t.Error("expected error decoding data byte as status")
+	}
+}
+
+func TestSysEx(t *testing.T) {
+	data := []byte{0x00, 0x20, 0x32, 0x32, 0x01}
+	b, err := SysEx(data)
+	if err != nil {
+		t.Fatalf("error framing: %s", err)
+	}
+	if want := []byte{0xf0, 0x00, 0x20, 0x32, 0x32, 0x01, 0xf7}; !bytes.Equal(b, want) {
+		t.Errorf("\t got = %x\n\t\t\twant = %x", b, want)
+	}
+	got, err := ParseSysEx(b)
+	if err != nil {
+		t.Fatalf("error parsing: %s", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("\t got = %x\n\t\t\twant = %x", got, data)
+	}
+	if _, err := SysEx([]byte{0x80}); err == nil {
+		t.Error("expected error framing 8-bit data")
+	}
+	if _, err := ParseSysEx([]byte{0xf0, 0x01}); err == nil {
+		t.Error("expected error parsing unterminated message")
+	}
+}
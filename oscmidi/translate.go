@@ -0,0 +1,151 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscmidi
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// Mapping maps a MIDI control change to the single argument of the messages
+// to an OSC address. The controller value from 0 to 127 is scaled linearly to
+// the range from Min to Max.
+type Mapping struct {
+	// Channel is the MIDI channel from 1 to 16. Zero matches control changes
+	// on any channel and sends them on channel 1.
+	Channel int
+
+	Controller byte
+	Address    string
+
+	// TypeTag is the type of the OSC argument: "f" or "d" for a scaled
+	// value, "i" or "h" for a rounded one. If empty, "f" is used. Other
+	// type tags are reported by Translator.Check.
+	TypeTag string
+
+	Min float64
+	Max float64
+}
+
+// check reports an error in the mapping.
+func (mp *Mapping) check() error {
+	if mp.Channel < 0 || mp.Channel > 16 {
+		return fmt.Errorf("channel %d out of range 0-16", mp.Channel)
+	}
+	if mp.Controller > 127 {
+		return fmt.Errorf("controller %d out of range 0-127", mp.Controller)
+	}
+	if _, ok := mp.typeTag(); !ok {
+		return fmt.Errorf("invalid type tag %q", mp.TypeTag)
+	}
+	for _, x := range []float64{mp.Min, mp.Max} {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return fmt.Errorf("invalid range %g-%g", mp.Min, mp.Max)
+		}
+	}
+	return nil
+}
+
+// Translator translates MIDI control changes to OSC messages and back using
+// its mappings. The first mapping that matches an event or a message is used.
+// Mappings that Check reports an error in are ignored.
+type Translator struct {
+	Mappings []Mapping
+}
+
+// Check reports the first error in the translator's mappings.
+func (t *Translator) Check() error {
+	for i := range t.Mappings {
+		if err := t.Mappings[i].check(); err != nil {
+			return fmt.Errorf("mapping %d: %s", i+1, err)
+		}
+	}
+	return nil
+}
+
+// ToOSC returns the OSC message for the control change event and true, or
+// false if the event is not a control change matched by a mapping.
+func (t *Translator) ToOSC(e Event) (*osc.Msg, bool) {
+	if e.Type != ControlChange {
+		return nil, false
+	}
+	for _, mp := range t.Mappings {
+		if mp.Controller != e.Data1 || mp.Channel != 0 && mp.Channel != e.Channel || mp.check() != nil {
+			continue
+		}
+		v := mp.Min + float64(e.Data2&0x7f)/127*(mp.Max-mp.Min)
+		tag, _ := mp.typeTag()
+		m := &osc.Msg{Address: mp.Address, TypeTag: tag}
+		switch tag {
+		case "i":
+			m.Args = []interface{}{int32(math.Round(v))}
+		case "h":
+			m.Args = []interface{}{int64(math.Round(v))}
+		case "d":
+			m.Args = []interface{}{v}
+		default:
+			m.Args = []interface{}{float32(v)}
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// ToMIDI returns the control change event for the OSC message and true, or
+// false if no mapping matches the message's address or its single argument is
+// not a number. Values outside of the mapping's range, including infinities,
+// are clamped; NaN is not translated.
+func (t *Translator) ToMIDI(m *osc.Msg) (Event, bool) {
+	if len(m.Args) != 1 {
+		return Event{}, false
+	}
+	var v float64
+	switch arg := m.Args[0].(type) {
+	case int32:
+		v = float64(arg)
+	case int64:
+		v = float64(arg)
+	case float32:
+		v = float64(arg)
+	case float64:
+		v = arg
+	default:
+		return Event{}, false
+	}
+	if math.IsNaN(v) {
+		return Event{}, false
+	}
+	for _, mp := range t.Mappings {
+		if mp.Address != m.Address || mp.check() != nil {
+			continue
+		}
+		value := 0.0
+		if mp.Max != mp.Min {
+			value = math.Round((v - mp.Min) / (mp.Max - mp.Min) * 127)
+		}
+		value = math.Max(0, math.Min(127, value))
+		ch := mp.Channel
+		if ch == 0 {
+			ch = 1
+		}
+		return CC(ch, mp.Controller, byte(value)), true
+	}
+	return Event{}, false
+}
+
+// typeTag returns the type tag of the mapping's argument and whether it is
+// valid.
+func (mp *Mapping) typeTag() (string, bool) {
+	switch mp.TypeTag {
+	case "":
+		return "f", true
+	case "i", "h", "f", "d":
+		return mp.TypeTag, true
+	}
+	return "", false
+}
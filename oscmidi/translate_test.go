@@ -0,0 +1,108 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscmidi
+
+import (
+	"math"
+	"testing"
+
+	"github.com/goaudiovideo/osc"
+)
+
+func TestTranslator(t *testing.T) {
+	tr := &Translator{Mappings: []Mapping{
+		{Channel: 1, Controller: 7, Address: "/ch/01/mix/fader", Max: 1},
+		{Channel: 1, Controller: 8, Address: "/ch/01/mix/on", TypeTag: "i", Max: 1},
+		{Controller: 10, Address: "/ch/01/mix/pan", TypeTag: "d", Min: -1, Max: 1},
+	}}
+	var tests = []struct {
+		event Event
+		msg   string
+	}{
+		{CC(1, 7, 127), "/ch/01/mix/fader ,f 1"},
+		{CC(1, 7, 0), "/ch/01/mix/fader ,f 0"},
+		{CC(1, 8, 100), "/ch/01/mix/on ,i 1"},
+		{CC(1, 10, 127), "/ch/01/mix/pan ,d 1"},
+		{CC(1, 10, 0), "/ch/01/mix/pan ,d -1"},
+	}
+	for _, test := range tests {
+		m, ok := tr.ToOSC(test.event)
+		if !ok {
+			t.Errorf("%s: not translated", test.event)
+			continue
+		}
+		if got := m.String(); got != test.msg {
+			t.Errorf("\t got = %s\n\t\t\twant = %s", got, test.msg)
+		}
+		back, ok := tr.ToMIDI(m)
+		if !ok {
+			t.Errorf("%s: not translated back", m)
+			continue
+		}
+		want := test.event
+		if want.Data1 == 8 {
+			want.Data2 = 127 // the mute is rounded to 1
+		}
+		if back != want {
+			t.Errorf("\t got = %s\n\t\t\twant = %s", back, want)
+		}
+	}
+
+	for _, e := range []Event{CC(2, 7, 1), CC(1, 9, 1), Note(1, 7, 1)} {
+		if m, ok := tr.ToOSC(e); ok {
+			t.Errorf("%s: unexpectedly translated to %s", e, m)
+		}
+	}
+	for _, m := range []*osc.Msg{
+		{Address: "/ch/02/mix/fader", TypeTag: "f", Args: []interface{}{float32(1)}},
+		{Address: "/ch/01/mix/fader", TypeTag: "s", Args: []interface{}{"loud"}},
+	} {
+		if e, ok := tr.ToMIDI(m); ok {
+			t.Errorf("%s: unexpectedly translated to %s", m, e)
+		}
+	}
+	// NaN is not translated, and infinities are clamped.
+	nan := &osc.Msg{Address: "/ch/01/mix/fader", TypeTag: "f", Args: []interface{}{float32(math.NaN())}}
+	if e, ok := tr.ToMIDI(nan); ok {
+		t.Errorf("%s: unexpectedly translated to %s", nan, e)
+	}
+	e, _ := tr.ToMIDI(&osc.Msg{Address: "/ch/01/mix/pan", TypeTag: "d", Args: []interface{}{math.Inf(-1)}})
+	if e != CC(1, 10, 0) {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", e, CC(1, 10, 0))
+	}
+	// Values out of range are clamped.
+	e, _ = tr.ToMIDI(&osc.Msg{Address: "/ch/01/mix/fader", TypeTag: "f", Args: []interface{}{float32(2)}})
+	if e != CC(1, 7, 127) {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", e, CC(1, 7, 127))
+	}
+}
+
+func TestTranslatorCheck(t *testing.T) {
+	var tests = []struct {
+		mapping Mapping
+		want    string
+	}{
+		{Mapping{Channel: 17, Controller: 7, Address: "/a"}, "mapping 1: channel 17 out of range 0-16"},
+		{Mapping{Controller: 128, Address: "/a"}, "mapping 1: controller 128 out of range 0-127"},
+		{Mapping{Controller: 7, Address: "/a", TypeTag: "s"}, `mapping 1: invalid type tag "s"`},
+		{Mapping{Controller: 7, Address: "/a", Max: math.NaN()}, "mapping 1: invalid range 0-NaN"},
+		{Mapping{Controller: 7, Address: "/a", Min: math.Inf(-1), Max: 1}, "mapping 1: invalid range -Inf-1"},
+	}
+	for _, test := range tests {
+		tr := &Translator{Mappings: []Mapping{test.mapping}}
+		if err := tr.Check(); err == nil || err.Error() != test.want {
+			t.Errorf("\t got = %v\n\t\t\twant = %s", err, test.want)
+		}
+		// An invalid mapping is not used instead of falling back to "f".
+		if m, ok := tr.ToOSC(CC(1, 7, 1)); ok {
+			t.Errorf("%+v: unexpectedly translated to %s", test.mapping, m)
+		}
+	}
+	tr := &Translator{Mappings: []Mapping{{Controller: 7, Address: "/a", TypeTag: "f"}}}
+	if err := tr.Check(); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+/*
+Package oscproxy forwards Open Sound Control (OSC) messages between devices,
+rewriting their addresses, scaling their values and filtering or fanning them
+out to several destinations, e.g. to control a mixing console from a control
+surface that only sends /fader/1 to /fader/8.
+
+A Proxy is an osc.Handler, so an osc.Server passes it the packets it receives.
+Each message is routed by the first of the proxy's rules whose pattern matches
+its address; messages that match no rule are sent to the default destinations.
+The messages of a bundle are routed one by one and sent to each destination in
+a bundle with the same time tag.
+
+The rules can be loaded from a JSON file:
+
+	{
+		"listen": {"network": "udp", "address": ":9000"},
+		"destinations": {
+			"x32": {"address": "192.168.1.10:10023"},
+			"monitor": {"network": "tcp", "address": "127.0.0.1:9001"}
+		},
+		"default": ["x32"],
+		"rules": [
+			{
+				"match": "/fader/*",
+				"rewrite": "/ch/{2:02}/mix/fader",
+				"scale": {"in": [0, 127], "out": [0, 1], "clamp": true, "type": "f"},
+				"to": ["x32", "monitor"]
+			},
+			{"match": "/ping", "drop": true}
+		]
+	}
+
+Only JSON configuration files are read, not YAML: parsing YAML would need a
+package outside of the standard library, which the module does not depend on.
+A YAML file can be converted to JSON with a tool such as yq.
+
+Only messages flow through a proxy from its listener to its destinations;
+replies from the destinations are not sent back.
+*/
+package oscproxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sort"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// Proxy is an osc.Handler that routes the messages it is passed to its
+// destinations according to its rules.
+type Proxy struct {
+	// Rules are tried in order; the first rule whose Match pattern matches a
+	// message's address routes the message.
+	Rules []Rule
+
+	// Default names the destinations of the messages that match no rule. If
+	// empty, they are dropped.
+	Default []string
+
+	// Destinations are the writers the encoded packets are sent to, one packet
+	// per Write, by name, typically osc.Clients.
+	Destinations map[string]io.Writer
+
+	// ErrorLog logs messages that cannot be rewritten and packets that cannot
+	// be sent. If nil, they are logged using the log package's standard
+	// logger.
+	ErrorLog *log.Logger
+}
+
+// ServeOSC implements the osc.Handler interface for Proxy by routing the
+// packet and sending it to its destinations.
+func (p *Proxy) ServeOSC(pkt osc.Packet, from net.Addr) {
+	routes := p.Route(pkt)
+	names := make([]string, 0, len(routes))
+	for name := range routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := p.send(name, routes[name]); err != nil {
+			p.logf("oscproxy: sending packet from %s to %s: %s", from, name, err)
+		}
+	}
+}
+
+func (p *Proxy) send(name string, pkt osc.Packet) error {
+	w, ok := p.Destinations[name]
+	if !ok {
+		return errors.New("unknown destination")
+	}
+	b, err := pkt.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// Route returns the packets to send to the destinations, by name, for the
+// packet. Messages that cannot be rewritten are logged and dropped.
+func (p *Proxy) Route(pkt osc.Packet) map[string]osc.Packet {
+	routes := make(map[string]osc.Packet)
+	switch pkt := pkt.(type) {
+	case *osc.Msg:
+		m, to, err := p.routeMessage(pkt)
+		if err != nil {
+			p.logf("oscproxy: %s", err)
+			break
+		}
+		for _, name := range to {
+			routes[name] = m
+		}
+	case *osc.Bundle:
+		for _, elem := range pkt.Packets {
+			for name, routed := range p.Route(elem) {
+				b, ok := routes[name].(*osc.Bundle)
+				if !ok {
+					b = &osc.Bundle{Time: pkt.Time}
+					routes[name] = b
+				}
+				b.Packets = append(b.Packets, routed)
+			}
+		}
+	}
+	return routes
+}
+
+// routeMessage returns the rewritten message and the names of its
+// destinations, or nil if it is dropped.
+func (p *Proxy) routeMessage(m *osc.Msg) (*osc.Msg, []string, error) {
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if !osc.Match(r.Match, m.Address) {
+			continue
+		}
+		out, err := r.apply(m)
+		if err != nil || out == nil {
+			return nil, nil, err
+		}
+		if len(r.To) > 0 {
+			return out, r.To, nil
+		}
+		return out, p.Default, nil
+	}
+	return m, p.Default, nil
+}
+
+// Close closes the destinations that are io.Closers.
+func (p *Proxy) Close() error {
+	var err error
+	for _, w := range p.Destinations {
+		if c, ok := w.(io.Closer); ok {
+			if cerr := c.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
+
+func (p *Proxy) logf(format string, args ...interface{}) {
+	if p.ErrorLog != nil {
+		p.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// Endpoint is the network address of a listener or a destination.
+type Endpoint struct {
+	// Network is "udp" or "tcp", or one of their IPv4 and IPv6 variants.
+	// If empty, "udp" is used.
+	Network string `json:"network,omitempty"`
+	Address string `json:"address"`
+}
+
+func (e Endpoint) network() string {
+	if e.Network == "" {
+		return "udp"
+	}
+	return e.Network
+}
+
+// Config is the configuration of a proxy as loaded from a JSON file.
+type Config struct {
+	Listen       Endpoint            `json:"listen"`
+	Destinations map[string]Endpoint `json:"destinations"`
+	Default      []string            `json:"default,omitempty"`
+	Rules        []Rule              `json:"rules"`
+}
+
+// ReadConfig reads and checks a JSON configuration. YAML is not supported.
+func ReadConfig(r io.Reader) (*Config, error) {
+	var c Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return nil, err
+	}
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// LoadConfig reads and checks the JSON configuration file.
+func LoadConfig(name string) (*Config, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := ReadConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return c, nil
+}
+
+// check reports an error in the configuration.
+func (c *Config) check() error {
+	for _, name := range c.Default {
+		if _, ok := c.Destinations[name]; !ok {
+			return fmt.Errorf("unknown default destination %s", name)
+		}
+	}
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		if err := r.check(); err != nil {
+			return fmt.Errorf("rule %d: %s", i+1, err)
+		}
+		for _, name := range r.To {
+			if _, ok := c.Destinations[name]; !ok {
+				return fmt.Errorf("rule %d: unknown destination %s", i+1, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Dial connects to the destinations of the configuration and returns a proxy
+// that sends to them.
+func Dial(c *Config) (*Proxy, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	p := &Proxy{
+		Rules:        c.Rules,
+		Default:      c.Default,
+		Destinations: make(map[string]io.Writer),
+	}
+	for name, e := range c.Destinations {
+		client, err := osc.Dial(e.network(), e.Address)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("destination %s: %s", name, err)
+		}
+		p.Destinations[name] = client
+	}
+	return p, nil
+}
+
+// ListenAndServe dials the destinations of the configuration and serves the
+// packets received on its listen endpoint until the server fails.
+func ListenAndServe(c *Config) error {
+	p, err := Dial(c)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+	s := &osc.Server{Handler: p}
+	return s.ListenAndServe(c.Listen.network(), c.Listen.Address)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscproxy
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// recorder records the packets written to it.
+type recorder struct {
+	mu      sync.Mutex
+	packets []string
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	pkt, err := osc.ParsePacket(p)
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.packets = append(r.packets, pkt.String())
+	return len(p), nil
+}
+
+const testConfig = `{
+	"listen": {"address": "127.0.0.1:0"},
+	"destinations": {
+		"x32": {"address": "127.0.0.1:10023"},
+		"monitor": {"network": "tcp", "address": "127.0.0.1:9001"}
+	},
+	"default": ["x32"],
+	"rules": [
+		{"match": "/ping", "drop": true},
+		{
+			"match": "/fader/*",
+			"rewrite": "/ch/{2:02}/mix/fader",
+			"scale": {"in": [0, 127], "out": [0, 1], "clamp": true, "type": "f"},
+			"to": ["x32", "monitor"]
+		},
+		{"match": "/meter/*", "to": ["monitor"]}
+	]
+}`
+
+func TestProxy(t *testing.T) {
+	c, err := ReadConfig(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatalf("error reading config: %s", err)
+	}
+	x32, monitor := &recorder{}, &recorder{}
+	var logged bytes.Buffer
+	p := &Proxy{
+		Rules:        c.Rules,
+		Default:      c.Default,
+		Destinations: map[string]io.Writer{"x32": x32, "monitor": monitor},
+		ErrorLog:     log.New(&logged, "", 0),
+	}
+	p.Rules = append(p.Rules, Rule{Match: "/bad", Rewrite: "/{2}"})
+	var tests = []struct {
+		in      string
+		x32     []string
+		monitor []string
+	}{
+		{"/fader/3 ,i 127", []string{"/ch/03/mix/fader ,f 1"}, []string{"/ch/03/mix/fader ,f 1"}},
+		{"/ping", nil, nil},
+		{"/bad", nil, nil},
+		{"/meter/1 ,f 0.5", nil, []string{"/meter/1 ,f 0.5"}},
+		{"/info", []string{"/info"}, nil},
+		{
+			"#bundle immediately { /fader/1 ,f 63.5 ; /ping ; /info }",
+			[]string{"#bundle immediately { /ch/01/mix/fader ,f 0.5 ; /info }"},
+			[]string{"#bundle immediately { /ch/01/mix/fader ,f 0.5 }"},
+		},
+	}
+	for _, test := range tests {
+		x32.packets, monitor.packets = nil, nil
+		in, err := osc.ParseText(test.in)
+		if err != nil {
+			t.Fatalf("error parsing %s: %s", test.in, err)
+		}
+		p.ServeOSC(in, nil)
+		if strings.Join(x32.packets, "\n") != strings.Join(test.x32, "\n") {
+			t.Errorf("%s: x32\n\t got = %q\n\t\t\twant = %q", test.in, x32.packets, test.x32)
+		}
+		if strings.Join(monitor.packets, "\n") != strings.Join(test.monitor, "\n") {
+			t.Errorf("%s: monitor\n\t got = %q\n\t\t\twant = %q", test.in, monitor.packets, test.monitor)
+		}
+	}
+	if want := "oscproxy: rewriting /bad with /{2}: address has no part 2\n"; logged.String() != want {
+		t.Errorf("\t got = %q\n\t\t\twant = %q", logged.String(), want)
+	}
+}
+
+func TestReadConfigErrors(t *testing.T) {
+	var tests = []struct {
+		config string
+		want   string
+	}{
+		{`{"rules": [{"match": "fader"}]}`, `rule 1: invalid match pattern "fader"`},
+		{`{"rules": [{"match": "/fader/*", "rewrite": "/ch/{x}"}]}`, "rule 1: invalid rewrite template /ch/{x}: invalid part {x}"},
+		{`{"rules": [{"match": "/a", "to": ["x32"]}]}`, "rule 1: unknown destination x32"},
+		{`{"rules": [{"match": "/a", "scale": {"in": [0, 1], "out": [0, 1], "type": "d"}}]}`, `rule 1: invalid scale type "d"`},
+		{`{"default": ["x32"]}`, "unknown default destination x32"},
+		{`{"rule": []}`, `json: unknown field "rule"`},
+	}
+	for _, test := range tests {
+		_, err := ReadConfig(strings.NewReader(test.config))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("%s:\n\t got = %v\n\t\t\twant = %s", test.config, err, test.want)
+		}
+	}
+}
+
+func TestListenAndServe(t *testing.T) {
+	device, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	defer device.Close()
+	p, err := Dial(&Config{
+		Destinations: map[string]Endpoint{"device": {Address: device.LocalAddr().String()}},
+		Default:      []string{"device"},
+		Rules:        []Rule{{Match: "/fader/*", Rewrite: "/ch/{2:02}/mix/fader"}},
+	})
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer p.Close()
+	p.ErrorLog = log.New(ioutil.Discard, "", 0)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	s := &osc.Server{Handler: p}
+	go s.ServePacket(conn)
+	defer s.Close()
+
+	surface, err := osc.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer surface.Close()
+	if err := surface.WriteMessage("/fader/2", "f", float32(0.75)); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	buf := make([]byte, 64)
+	device.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := device.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+	want := "/ch/02/mix/fader\x00\x00\x00\x00,f\x00\x00\x3f\x40\x00\x00"
+	if got := string(buf[:n]); got != want {
+		t.Errorf("\t got = %q\n\t\t\twant = %q", got, want)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscproxy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// Rule routes the messages whose address matches an OSC address pattern.
+type Rule struct {
+	// Match is the OSC address pattern of the messages the rule applies to,
+	// e.g. "/fader/*".
+	Match string `json:"match"`
+
+	// Rewrite is the template of the address the messages are sent to. If
+	// empty, the address is unchanged. See Rule.Address.
+	Rewrite string `json:"rewrite,omitempty"`
+
+	// Scale, if not nil, scales the numeric arguments of the messages.
+	Scale *Scale `json:"scale,omitempty"`
+
+	// Transform, if not nil, is called with the rewritten and scaled message
+	// and returns the message to send, or nil to drop it.
+	Transform func(m *osc.Msg) *osc.Msg `json:"-"`
+
+	// To names the destinations the messages are sent to. If empty, the
+	// proxy's default destinations are used.
+	To []string `json:"to,omitempty"`
+
+	// Drop drops the messages instead of sending them.
+	Drop bool `json:"drop,omitempty"`
+}
+
+// Scale maps numeric arguments linearly from the range In to the range Out.
+// Integer arguments are rounded to the nearest integer unless Type converts
+// them to floats.
+type Scale struct {
+	In  [2]float64 `json:"in"`
+	Out [2]float64 `json:"out"`
+
+	// Clamp limits the scaled arguments to the range Out.
+	Clamp bool `json:"clamp,omitempty"`
+
+	// Type, if not empty, is the type tag the scaled arguments are sent as:
+	// "i" for int32 or "f" for float32. If empty, the arguments keep their
+	// type.
+	Type string `json:"type,omitempty"`
+}
+
+// Apply returns the scaled value of x.
+func (s *Scale) Apply(x float64) float64 {
+	y := s.Out[0]
+	if s.In[1] != s.In[0] {
+		y += (x - s.In[0]) * (s.Out[1] - s.Out[0]) / (s.In[1] - s.In[0])
+	}
+	if s.Clamp {
+		lo, hi := s.Out[0], s.Out[1]
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		y = math.Max(lo, math.Min(hi, y))
+	}
+	return y
+}
+
+// scaleArgs returns the type tag and the arguments of a message with the
+// numeric arguments scaled.
+func (s *Scale) scaleArgs(typeTag string, args []interface{}) (string, []interface{}) {
+	tags := []byte(typeTag)
+	scaled := make([]interface{}, len(args))
+	i := 0
+	for j := range tags {
+		if tags[j] == '[' || tags[j] == ']' {
+			continue
+		}
+		if i == len(args) {
+			break
+		}
+		var x float64
+		switch v := args[i].(type) {
+		case int32:
+			x = float64(v)
+		case int64:
+			x = float64(v)
+		case float32:
+			x = float64(v)
+		case float64:
+			x = v
+		default:
+			scaled[i] = args[i]
+			i++
+			continue
+		}
+		y := s.Apply(x)
+		switch s.Type {
+		case "i":
+			tags[j], scaled[i] = 'i', int32(math.Round(y))
+		case "f":
+			tags[j], scaled[i] = 'f', float32(y)
+		default:
+			switch args[i].(type) {
+			case int32:
+				scaled[i] = int32(math.Round(y))
+			case int64:
+				scaled[i] = int64(math.Round(y))
+			case float32:
+				scaled[i] = float32(y)
+			default:
+				scaled[i] = y
+			}
+		}
+		i++
+	}
+	return string(tags), scaled
+}
+
+// Address returns the rewritten address of a message sent to addr. The
+// Rewrite template is copied to the address with each {n} replaced by the nth
+// part of addr, counting from 1. The part may be followed by +k or -k to add
+// to or subtract from it as an integer and by :0w to pad it with zeros to the
+// width w. For example the rewrite template "/ch/{2+16:02}/mix/fader" maps
+// "/fader/1" to "/ch/17/mix/fader".
+func (r *Rule) Address(addr string) (string, error) {
+	if r.Rewrite == "" {
+		return addr, nil
+	}
+	s, err := r.rewrite(strings.Split(strings.TrimPrefix(addr, "/"), "/"))
+	if err != nil {
+		return "", fmt.Errorf("rewriting %s with %s: %s", addr, r.Rewrite, err)
+	}
+	return s, nil
+}
+
+// rewrite returns the Rewrite template expanded with the parts of an
+// address.
+func (r *Rule) rewrite(parts []string) (string, error) {
+	var b strings.Builder
+	tmpl := r.Rewrite
+	for {
+		i := strings.IndexByte(tmpl, '{')
+		if i < 0 {
+			b.WriteString(tmpl)
+			return b.String(), nil
+		}
+		j := strings.IndexByte(tmpl[i:], '}')
+		if j < 0 {
+			return "", errors.New("unclosed {")
+		}
+		b.WriteString(tmpl[:i])
+		s, err := expand(tmpl[i+1:i+j], parts)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s)
+		tmpl = tmpl[i+j+1:]
+	}
+}
+
+// expand returns the value of a {n[+k|-k][:0w]} template field.
+func expand(field string, parts []string) (string, error) {
+	width := 0
+	if i := strings.IndexByte(field, ':'); i >= 0 {
+		format := field[i+1:]
+		w, err := strconv.Atoi(strings.TrimPrefix(format, "0"))
+		if err != nil || !strings.HasPrefix(format, "0") || w < 1 {
+			return "", fmt.Errorf("invalid width %s", format)
+		}
+		field, width = field[:i], w
+	}
+	var offset int
+	if i := strings.IndexAny(field, "+-"); i >= 0 {
+		k, err := strconv.Atoi(field[i:])
+		if err != nil {
+			return "", fmt.Errorf("invalid offset %s", field[i:])
+		}
+		field, offset = field[:i], k
+	}
+	n, err := strconv.Atoi(field)
+	if err != nil || n < 1 {
+		return "", fmt.Errorf("invalid part {%s}", field)
+	}
+	if n > len(parts) {
+		return "", fmt.Errorf("address has no part %d", n)
+	}
+	part := parts[n-1]
+	if offset == 0 && width == 0 {
+		return part, nil
+	}
+	v, err := strconv.Atoi(part)
+	if err != nil {
+		return "", fmt.Errorf("part %d %q is not an integer", n, part)
+	}
+	return fmt.Sprintf("%0*d", width, v+offset), nil
+}
+
+// apply returns the message rewritten by the rule, or nil if the rule drops
+// it.
+func (r *Rule) apply(m *osc.Msg) (*osc.Msg, error) {
+	if r.Drop {
+		return nil, nil
+	}
+	addr, err := r.Address(m.Address)
+	if err != nil {
+		return nil, err
+	}
+	out := &osc.Msg{Address: addr, TypeTag: m.TypeTag, Args: m.Args}
+	if r.Scale != nil {
+		out.TypeTag, out.Args = r.Scale.scaleArgs(m.TypeTag, m.Args)
+	}
+	if r.Transform != nil {
+		out = r.Transform(out)
+	}
+	return out, nil
+}
+
+// check reports an error in the rule.
+func (r *Rule) check() error {
+	if !strings.HasPrefix(r.Match, "/") {
+		return fmt.Errorf("invalid match pattern %q", r.Match)
+	}
+	if r.Rewrite != "" && !strings.HasPrefix(r.Rewrite, "/") {
+		return fmt.Errorf("invalid rewrite template %q", r.Rewrite)
+	}
+	if r.Scale != nil && r.Scale.Type != "" && r.Scale.Type != "i" && r.Scale.Type != "f" {
+		return fmt.Errorf("invalid scale type %q", r.Scale.Type)
+	}
+	// Rewriting an address of integer parts finds the template's errors.
+	parts := make([]string, 64)
+	for i := range parts {
+		parts[i] = "1"
+	}
+	if _, err := r.rewrite(parts); err != nil {
+		return fmt.Errorf("invalid rewrite template %s: %s", r.Rewrite, err)
+	}
+	return nil
+}
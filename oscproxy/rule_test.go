@@ -0,0 +1,74 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscproxy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRuleAddress(t *testing.T) {
+	var tests = []struct {
+		rewrite string
+		addr    string
+		want    string
+		err     string
+	}{
+		{"", "/fader/1", "/fader/1", ""},
+		{"/main/st/mix/fader", "/fader/1", "/main/st/mix/fader", ""},
+		{"/ch/{2:02}/mix/fader", "/fader/1", "/ch/01/mix/fader", ""},
+		{"/ch/{2+16:02}/mix/fader", "/fader/1", "/ch/17/mix/fader", ""},
+		{"/bus/{2-8}/{1}", "/fader/9", "/bus/1/fader", ""},
+		{"/ch/{2}/{1}", "/fader/a", "/ch/a/fader", ""},
+		{"/ch/{2:02}", "/fader/a", "", `rewriting /fader/a with /ch/{2:02}: part 2 "a" is not an integer`},
+		{"/ch/{3}", "/fader/1", "", "rewriting /fader/1 with /ch/{3}: address has no part 3"},
+		{"/ch/{0}", "/fader/1", "", "rewriting /fader/1 with /ch/{0}: invalid part {0}"},
+		{"/ch/{2:2}", "/fader/1", "", "rewriting /fader/1 with /ch/{2:2}: invalid width 2"},
+		{"/ch/{2+x}", "/fader/1", "", "rewriting /fader/1 with /ch/{2+x}: invalid offset +x"},
+		{"/ch/{2", "/fader/1", "", "rewriting /fader/1 with /ch/{2: unclosed {"},
+	}
+	for _, test := range tests {
+		r := &Rule{Match: "/*/*", Rewrite: test.rewrite}
+		got, err := r.Address(test.addr)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%s: error\n\t got = %s\n\t\t\twant = %s", test.rewrite, err, test.err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%s: expected error %s", test.rewrite, test.err)
+		}
+		if got != test.want {
+			t.Errorf("%s:\n\t got = %s\n\t\t\twant = %s", test.rewrite, got, test.want)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	s := &Scale{In: [2]float64{0, 127}, Out: [2]float64{0, 1}, Clamp: true}
+	tag, got := s.scaleArgs("ifds", []interface{}{int32(127), float32(63.5), 254.0, "x"})
+	want := []interface{}{int32(1), float32(0.5), 1.0, "x"}
+	if tag != "ifds" || !reflect.DeepEqual(got, want) {
+		t.Errorf("\t got = %s %#v\n\t\t\twant = ifds %#v", tag, got, want)
+	}
+	s.Type = "f"
+	tag, got = s.scaleArgs("[ih]T", []interface{}{int32(127), int64(0), true})
+	want = []interface{}{float32(1), float32(0), true}
+	if tag != "[ff]T" || !reflect.DeepEqual(got, want) {
+		t.Errorf("\t got = %s %#v\n\t\t\twant = [ff]T %#v", tag, got, want)
+	}
+	s.Type = "i"
+	tag, got = s.scaleArgs("f", []interface{}{float32(100)})
+	want = []interface{}{int32(1)}
+	if tag != "i" || !reflect.DeepEqual(got, want) {
+		t.Errorf("\t got = %s %#v\n\t\t\twant = i %#v", tag, got, want)
+	}
+	s = &Scale{In: [2]float64{0, 1}, Out: [2]float64{-90, 10}}
+	if got := s.Apply(1.5); got != 60 {
+		t.Errorf("\t got = %g\n\t\t\twant = %g", got, 60.0)
+	}
+}
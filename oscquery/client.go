@@ -0,0 +1,180 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+	"github.com/goaudiovideo/osc/internal/websocket"
+)
+
+// Client queries the namespace of a remote OSCQuery server.
+type Client struct {
+	// URL is the HTTP URL of the server, e.g. "http://10.0.0.5:8080".
+	URL string
+
+	// HTTPClient makes the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// HostInfo returns the server's host info.
+func (c *Client) HostInfo(ctx context.Context) (*HostInfo, error) {
+	var info HostInfo
+	if err := c.get(ctx, "/", "HOST_INFO", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Namespace returns the root node of the server's namespace.
+func (c *Client) Namespace(ctx context.Context) (*Node, error) {
+	return c.Node(ctx, "/")
+}
+
+// Node returns the node at the path and the nodes below it.
+func (c *Client) Node(ctx context.Context, path string) (*Node, error) {
+	var n Node
+	if err := c.get(ctx, path, "", &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Value returns the current value of the method at the path, or nil if it
+// has no value. The whole node is fetched, rather than only its VALUE
+// attribute, so that the arguments have the Go types of its type tag.
+func (c *Client) Value(ctx context.Context, path string) ([]interface{}, error) {
+	n, err := c.Node(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return n.Value, nil
+}
+
+// get decodes the JSON reply to a GET request for the path and the query
+// into v, leaving v unchanged if the server replies with no content.
+func (c *Client) get(ctx context.Context, path, query string, v interface{}) error {
+	u := strings.TrimSuffix(c.URL, "/") + path
+	if query != "" {
+		u += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(v)
+	case http.StatusNoContent:
+		return nil
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	return fmt.Errorf("GET %s: %s", u, resp.Status)
+}
+
+// Listen connects to the server's WebSocket and asks it to send the value
+// changes of the methods at the paths.
+func (c *Client) Listen(ctx context.Context, paths ...string) (*Subscription, error) {
+	info, err := c.HostInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, err
+	}
+	host, port := u.Hostname(), u.Port()
+	if info.WSIP != "" {
+		host = info.WSIP
+	}
+	if info.WSPort != 0 {
+		port = strconv.Itoa(info.WSPort)
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	ws, err := websocket.Dial(ctx, "ws://"+host+"/")
+	if err != nil {
+		return nil, err
+	}
+	s := &Subscription{ws: ws}
+	for _, path := range paths {
+		if err := s.Listen(path); err != nil {
+			ws.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Subscription is a WebSocket connection to an OSCQuery server that receives
+// the value changes of the methods it listens to.
+type Subscription struct {
+	ws *websocket.Conn
+}
+
+// Listen asks the server to send the value changes of the method at the path.
+func (s *Subscription) Listen(path string) error {
+	return s.command("LISTEN", path)
+}
+
+// Ignore asks the server to stop sending the value changes of the method at
+// the path.
+func (s *Subscription) Ignore(path string) error {
+	return s.command("IGNORE", path)
+}
+
+func (s *Subscription) command(name, path string) error {
+	b, err := json.Marshal(command{Command: name, Data: path})
+	if err != nil {
+		return err
+	}
+	return s.ws.WriteMessage(websocket.TextMessage, b)
+}
+
+// Receive returns the next value change, an OSC message from the server.
+// Other commands the server sends are skipped.
+func (s *Subscription) Receive() (osc.Packet, error) {
+	for {
+		typ, data, err := s.ws.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		if typ == websocket.BinaryMessage {
+			return osc.ParsePacket(data)
+		}
+	}
+}
+
+// SetReadDeadline sets the deadline for Receive.
+func (s *Subscription) SetReadDeadline(t time.Time) error {
+	return s.ws.SetReadDeadline(t)
+}
+
+// Close closes the WebSocket connection.
+func (s *Subscription) Close() error {
+	return s.ws.Close()
+}
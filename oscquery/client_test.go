@@ -0,0 +1,146 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscquery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+func TestClient(t *testing.T) {
+	s, _ := newTestServer(t)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	c := &Client{URL: srv.URL}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	info, err := c.HostInfo(ctx)
+	if err != nil {
+		t.Fatalf("error getting host info: %s", err)
+	}
+	if info.Name != "test mixer" || info.OSCPort != 10023 || !info.Extensions["LISTEN"] {
+		t.Errorf("got host info %+v", info)
+	}
+
+	root, err := c.Namespace(ctx)
+	if err != nil {
+		t.Fatalf("error getting namespace: %s", err)
+	}
+	var methods []string
+	root.Walk(func(n *Node) error {
+		if n.IsMethod() {
+			methods = append(methods, n.FullPath)
+		}
+		return nil
+	})
+	if want := []string{"/ch/01/mix/fader", "/meters/1"}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("\t got = %q\n\t\t\twant = %q", methods, want)
+	}
+	fader := root.Lookup("/ch/01/mix/fader")
+	if fader.Access != ReadWrite || fader.Description != "channel 1 fader" {
+		t.Errorf("got fader %+v", fader)
+	}
+
+	value, err := c.Value(ctx, "/meters/1")
+	if err != nil {
+		t.Fatalf("error getting value: %s", err)
+	}
+	if want := []interface{}{int32(-42)}; !reflect.DeepEqual(value, want) {
+		t.Errorf("\t got = %#v\n\t\t\twant = %#v", value, want)
+	}
+	if _, err := c.Node(ctx, "/ch/02"); err == nil {
+		t.Error("expected error getting missing node")
+	}
+
+	sub, err := c.Listen(ctx, "/ch/01/mix/fader")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	defer sub.Close()
+	// Wait for the server to handle LISTEN before changing the value.
+	if err := sub.Listen("/meters/1"); err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !listening(s, "/meters/1") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	s.ServeOSC(&osc.Msg{Address: "/ch/01/mix/fader", TypeTag: "f", Args: []interface{}{float32(0.25)}}, nil)
+	sub.SetReadDeadline(time.Now().Add(time.Second))
+	p, err := sub.Receive()
+	if err != nil {
+		t.Fatalf("error receiving: %s", err)
+	}
+	if got, want := p.String(), "/ch/01/mix/fader ,f 0.25"; got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+}
+
+// listening reports whether a WebSocket client listens to the path.
+func listening(s *Server, path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, paths := range s.listeners {
+		if paths[path] {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNodeJSON(t *testing.T) {
+	n := &Node{
+		FullPath: "/all",
+		Type:     "ihfdsbtTNcrm",
+		Access:   ReadOnly,
+		Value: []interface{}{
+			int32(1), int64(-2), float32(0.5), 0.25, "x", []byte{1, 2},
+			osc.TimeTag(7), true, nil, 'é', osc.RGBA(0x0a0b0c0d), osc.MIDI{0, 0x90, 60, 127},
+		},
+	}
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatalf("error marshaling: %s", err)
+	}
+	want := `{"FULL_PATH":"/all","TYPE":"ihfdsbtTNcrm","ACCESS":1,` +
+		`"VALUE":[1,-2,0.5,0.25,"x","AQI=",7,true,null,"é","#0A0B0C0D",[0,144,60,127]]}`
+	if string(b) != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", b, want)
+	}
+	var got Node
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("error unmarshaling: %s", err)
+	}
+	if !reflect.DeepEqual(&got, n) {
+		t.Errorf("\t got = %#v\n\t\t\twant = %#v", got.Value, n.Value)
+	}
+
+	// Values that do not match their type are kept as decoded.
+	if err := json.Unmarshal([]byte(`{"FULL_PATH":"/x","TYPE":"i","VALUE":["oops"]}`), &got); err != nil {
+		t.Fatalf("error unmarshaling: %s", err)
+	}
+	if want := []interface{}{"oops"}; !reflect.DeepEqual(got.Value, want) {
+		t.Errorf("\t got = %#v\n\t\t\twant = %#v", got.Value, want)
+	}
+}
+
+func TestClientStatus(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	c := &Client{URL: srv.URL}
+	_, err := c.Namespace(context.Background())
+	if want := "GET " + srv.URL + "/: 404 Not Found"; err == nil || err.Error() != want {
+		t.Errorf("\t got = %v\n\t\t\twant = %s", err, want)
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+/*
+Package oscquery implements OSCQuery, the HTTP and WebSocket protocol used by
+tools such as TouchOSC, Chataigne and VRChat to discover the Open Sound
+Control (OSC) methods of a service, read their current values and listen for
+changes.
+
+The namespace served by a Server is a JSON tree of nodes, one for every part
+of the addresses of an osc.Dispatcher's methods. A node can be queried as a
+whole, e.g. GET /ch/01/mix/fader, or for a single attribute, e.g. GET
+/ch/01/mix/fader?VALUE. GET /?HOST_INFO returns the HostInfo. WebSocket
+clients send {"COMMAND": "LISTEN", "DATA": "/ch/01/mix/fader"} to receive the
+method's value changes as binary OSC messages, and IGNORE to stop.
+
+A Client fetches the namespace of a remote server as Nodes, reads values and
+listens for their changes.
+
+See https://github.com/Vidvox/OSCQueryProposal for the specification.
+*/
+package oscquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// Access is the access to an OSC method's value.
+type Access int
+
+// Enum for OSCQuery access values.
+const (
+	NoAccess  Access = 0
+	ReadOnly  Access = 1
+	WriteOnly Access = 2
+	ReadWrite Access = 3
+)
+
+// Readable reports whether the value can be read.
+func (a Access) Readable() bool {
+	return a&ReadOnly != 0
+}
+
+// Writable reports whether the value can be written by sending the method an
+// OSC message.
+func (a Access) Writable() bool {
+	return a&WriteOnly != 0
+}
+
+// Node is a node of an OSCQuery namespace: a container of other nodes, an OSC
+// method, or both. Value holds the method's arguments as the Go types of
+// osc.ParseMessage for the tags of Type; they are converted to and from their
+// OSCQuery JSON values when the node is marshaled and unmarshaled.
+type Node struct {
+	FullPath    string           `json:"FULL_PATH"`
+	Contents    map[string]*Node `json:"CONTENTS,omitempty"`
+	Type        string           `json:"TYPE,omitempty"`
+	Access      Access           `json:"ACCESS,omitempty"`
+	Value       []interface{}    `json:"VALUE,omitempty"`
+	Range       []Range          `json:"RANGE,omitempty"`
+	Description string           `json:"DESCRIPTION,omitempty"`
+}
+
+// Range is the range of an argument's values: from Min to Max inclusive, or
+// one of Vals.
+type Range struct {
+	Min  interface{}   `json:"MIN,omitempty"`
+	Max  interface{}   `json:"MAX,omitempty"`
+	Vals []interface{} `json:"VALS,omitempty"`
+}
+
+// HostInfo describes an OSCQuery server and the OSC server whose namespace it
+// serves. Empty OSC and WebSocket addresses mean the same host as the HTTP
+// server.
+type HostInfo struct {
+	Name         string          `json:"NAME,omitempty"`
+	Extensions   map[string]bool `json:"EXTENSIONS,omitempty"`
+	OSCIP        string          `json:"OSC_IP,omitempty"`
+	OSCPort      int             `json:"OSC_PORT,omitempty"`
+	OSCTransport string          `json:"OSC_TRANSPORT,omitempty"`
+	WSIP         string          `json:"WS_IP,omitempty"`
+	WSPort       int             `json:"WS_PORT,omitempty"`
+}
+
+// Lookup returns the node at the path below n, or nil if there is none.
+func (n *Node) Lookup(path string) *Node {
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if n = n.Contents[part]; n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// IsMethod reports whether the node is an OSC method, as opposed to only a
+// container of other nodes.
+func (n *Node) IsMethod() bool {
+	return n.Type != ""
+}
+
+// Walk calls fn for n and every node below it, depth first with the contents
+// of each node in name order. Walk stops at the first error fn returns and
+// returns it.
+func (n *Node) Walk(fn func(n *Node) error) error {
+	if err := fn(n); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(n.Contents))
+	for name := range n.Contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := n.Contents[name].Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonNode is a Node without its JSON methods.
+type jsonNode Node
+
+// MarshalJSON implements the json.Marshaler interface for Node.
+func (n Node) MarshalJSON() ([]byte, error) {
+	j := jsonNode(n)
+	j.Value = jsonValues(n.Type, n.Value)
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Node. Values
+// that do not match their type tag, or whose tag is unknown, are kept as
+// decoded by encoding/json.
+func (n *Node) UnmarshalJSON(data []byte) error {
+	var j struct {
+		jsonNode
+		Value []json.RawMessage `json:"VALUE"`
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*n = Node(j.jsonNode)
+	n.Value = nil
+	tags := valueTags(n.Type)
+	for i, raw := range j.Value {
+		var tag byte
+		if i < len(tags) {
+			tag = tags[i]
+		}
+		v, err := goValue(tag, raw)
+		if err != nil {
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return err
+			}
+		}
+		n.Value = append(n.Value, v)
+	}
+	return nil
+}
+
+// valueTags returns the type tags of a node's values. Arrays are not
+// supported, so their brackets are dropped.
+func valueTags(typeTag string) string {
+	return strings.NewReplacer("[", "", "]", "").Replace(typeTag)
+}
+
+// jsonValues converts OSC arguments to their OSCQuery JSON values.
+func jsonValues(typeTag string, args []interface{}) []interface{} {
+	if args == nil {
+		return nil
+	}
+	tags := valueTags(typeTag)
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		var tag byte
+		if i < len(tags) {
+			tag = tags[i]
+		}
+		switch v := arg.(type) {
+		case osc.RGBA:
+			values[i] = fmt.Sprintf("#%08X", uint32(v))
+		case osc.MIDI:
+			values[i] = []int{int(v[0]), int(v[1]), int(v[2]), int(v[3])}
+		case osc.TimeTag:
+			values[i] = uint64(v)
+		case rune:
+			if tag == 'c' {
+				values[i] = string(v)
+			} else {
+				values[i] = v
+			}
+		default:
+			values[i] = v
+		}
+	}
+	return values
+}
+
+// goValue converts an OSCQuery JSON value to the Go type of the tag.
+func goValue(tag byte, raw json.RawMessage) (interface{}, error) {
+	var err error
+	switch tag {
+	case 'i':
+		var v int32
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case 'h':
+		var v int64
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case 'f':
+		var v float32
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case 'd':
+		var v float64
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case 's', 'S':
+		var v string
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case 'b':
+		var v []byte
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case 't':
+		var v uint64
+		err = json.Unmarshal(raw, &v)
+		return osc.TimeTag(v), err
+	case 'T', 'F':
+		var v bool
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case 'N', 'I':
+		return nil, nil
+	case 'c':
+		var v string
+		if err = json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		if r := []rune(v); len(r) == 1 {
+			return r[0], nil
+		}
+		return nil, fmt.Errorf("invalid char %q", v)
+	case 'r':
+		var v string
+		if err = json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		if len(v) != 9 || v[0] != '#' {
+			return nil, fmt.Errorf("invalid color %q", v)
+		}
+		c, err := strconv.ParseUint(v[1:], 16, 32)
+		return osc.RGBA(c), err
+	case 'm':
+		var v [4]byte
+		err = json.Unmarshal(raw, &v)
+		return osc.MIDI(v), err
+	}
+	return nil, fmt.Errorf("unsupported type tag %q", tag)
+}
@@ -0,0 +1,291 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+	"github.com/goaudiovideo/osc/internal/websocket"
+)
+
+// Server is an http.Handler that serves the methods of an osc.Dispatcher as
+// an OSCQuery namespace. Methods registered with the dispatcher's Handle are
+// writable and methods described with a Value function are readable.
+type Server struct {
+	// HostInfo is served for GET /?HOST_INFO. Its Extensions are set by the
+	// Server.
+	HostInfo HostInfo
+
+	// Dispatcher holds the methods of the namespace.
+	Dispatcher *osc.Dispatcher
+
+	// CheckOrigin reports whether a LISTEN WebSocket connection is accepted
+	// from the Origin header of its request. If nil, only the pages served
+	// from the server's own host, and clients other than browsers, are
+	// accepted, so that other web sites cannot control the namespace through
+	// the browsers of users. Mismatched origins get a 403 Forbidden reply.
+	CheckOrigin func(r *http.Request) bool
+
+	mu        sync.Mutex
+	listeners map[*websocket.Conn]map[string]bool
+}
+
+// extensions lists the OSCQuery extensions supported by Server.
+var extensions = map[string]bool{
+	"ACCESS":        true,
+	"VALUE":         true,
+	"RANGE":         true,
+	"DESCRIPTION":   true,
+	"LISTEN":        true,
+	"PATH_CHANGED":  false,
+	"TAGS":          false,
+	"EXTENDED_TYPE": false,
+	"UNIT":          false,
+	"CRITICAL":      false,
+	"CLIPMODE":      false,
+}
+
+// ServeHTTP implements the http.Handler interface for Server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		s.serveWebSocket(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	attr := r.URL.RawQuery
+	if attr == "HOST_INFO" {
+		info := s.HostInfo
+		info.Extensions = extensions
+		writeJSON(w, info)
+		return
+	}
+	node := s.Namespace().Lookup(r.URL.Path)
+	if node == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if attr == "" {
+		writeJSON(w, node)
+		return
+	}
+	b, err := json.Marshal(node)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(b, &attrs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	value, ok := attrs[attr]
+	switch {
+	case ok:
+		writeJSON(w, map[string]json.RawMessage{attr: value})
+	case nodeAttributes[attr]:
+		// The node does not have the attribute, e.g. a container's VALUE.
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, fmt.Sprintf("unsupported attribute %s", attr), http.StatusBadRequest)
+	}
+}
+
+// nodeAttributes lists the attributes a Node can have.
+var nodeAttributes = map[string]bool{
+	"FULL_PATH":   true,
+	"CONTENTS":    true,
+	"TYPE":        true,
+	"ACCESS":      true,
+	"VALUE":       true,
+	"RANGE":       true,
+	"DESCRIPTION": true,
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// Namespace returns the root node of the namespace with the current values
+// of the readable methods.
+func (s *Server) Namespace() *Node {
+	root := &Node{FullPath: "/"}
+	if s.Dispatcher == nil {
+		return root
+	}
+	for _, info := range s.Dispatcher.Methods() {
+		n := root
+		parts := strings.Split(info.Address[1:], "/")
+		for i, part := range parts {
+			if n.Contents == nil {
+				n.Contents = make(map[string]*Node)
+			}
+			child, ok := n.Contents[part]
+			if !ok {
+				child = &Node{FullPath: "/" + strings.Join(parts[:i+1], "/")}
+				n.Contents[part] = child
+			}
+			n = child
+		}
+		n.Type = info.TypeTag
+		n.Description = info.Description
+		if info.Writable {
+			n.Access |= WriteOnly
+		}
+		if info.Value != nil {
+			n.Access |= ReadOnly
+			n.Value = info.Value()
+		}
+		for _, r := range info.Range {
+			n.Range = append(n.Range, Range{Min: r.Min, Max: r.Max, Vals: r.Vals})
+		}
+	}
+	return root
+}
+
+// ServeOSC implements the osc.Handler interface for Server by dispatching the
+// packet and then sending the new values of the methods it was dispatched to
+// to their listeners. Bundles with a future time tag are dispatched at their
+// time tag, and only then are the listeners sent the new values.
+func (s *Server) ServeOSC(p osc.Packet, from net.Addr) {
+	if s.Dispatcher == nil {
+		return
+	}
+	switch p := p.(type) {
+	case *osc.Msg:
+		s.Dispatcher.Dispatch(p, from)
+		s.notifyDispatched(p)
+	case *osc.Bundle:
+		if p.Time != osc.Immediately {
+			if delay := time.Until(p.Time.Time()); delay > 0 {
+				time.AfterFunc(delay, func() {
+					s.ServeOSC(&osc.Bundle{Time: osc.Immediately, Packets: p.Packets}, from)
+				})
+				return
+			}
+			// An empty bundle lets the dispatcher observe that it is late.
+			s.Dispatcher.ServeOSC(&osc.Bundle{Time: p.Time}, from)
+		}
+		for _, elem := range p.Packets {
+			s.ServeOSC(elem, from)
+		}
+	}
+}
+
+// notifyDispatched sends the new values of the methods the message was
+// dispatched to to their listeners.
+func (s *Server) notifyDispatched(m *osc.Msg) {
+	for _, info := range s.Dispatcher.Methods() {
+		if !info.Writable || !osc.Match(m.Address, info.Address) {
+			continue
+		}
+		changed := &osc.Msg{Address: info.Address, TypeTag: m.TypeTag, Args: m.Args}
+		if info.Value != nil {
+			changed.TypeTag, changed.Args = info.TypeTag, info.Value()
+		}
+		s.Notify(changed)
+	}
+}
+
+// Notify sends the message to the WebSocket clients listening to its
+// address.
+func (s *Server) Notify(m *osc.Msg) error {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	var conns []*websocket.Conn
+	for c, paths := range s.listeners {
+		if paths[m.Address] {
+			conns = append(conns, c)
+		}
+	}
+	s.mu.Unlock()
+	for _, c := range conns {
+		c.WriteMessage(websocket.BinaryMessage, b)
+	}
+	return nil
+}
+
+// command is a command sent by a WebSocket client.
+type command struct {
+	Command string `json:"COMMAND"`
+	Data    string `json:"DATA"`
+}
+
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	c, err := websocket.Upgrade(w, r, s.CheckOrigin)
+	if err != nil {
+		return
+	}
+	paths := make(map[string]bool)
+	s.mu.Lock()
+	if s.listeners == nil {
+		s.listeners = make(map[*websocket.Conn]map[string]bool)
+	}
+	s.listeners[c] = paths
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	for {
+		typ, data, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		if typ == websocket.BinaryMessage {
+			// Clients may also send OSC packets over the WebSocket.
+			if p, err := osc.ParsePacket(data); err == nil {
+				s.ServeOSC(p, wsAddr(r.RemoteAddr))
+			}
+			continue
+		}
+		var cmd command
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			continue
+		}
+		s.mu.Lock()
+		switch cmd.Command {
+		case "LISTEN":
+			paths[cmd.Data] = true
+		case "IGNORE":
+			delete(paths, cmd.Data)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// wsAddr is the address of a WebSocket client.
+type wsAddr string
+
+func (a wsAddr) Network() string {
+	return "ws"
+}
+
+func (a wsAddr) String() string {
+	return string(a)
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscquery
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+	"github.com/goaudiovideo/osc/internal/websocket"
+)
+
+// testMixer is a tiny OSC service with a fader and a read-only meter.
+type testMixer struct {
+	mu    sync.Mutex
+	fader float32
+}
+
+func newTestServer(t *testing.T) (*Server, *testMixer) {
+	t.Helper()
+	var mixer testMixer
+	d := &osc.Dispatcher{}
+	d.Handle("/ch/01/mix/fader", func(m *osc.Msg, from net.Addr) {
+		if f, ok := m.Args[0].(float32); ok {
+			mixer.mu.Lock()
+			mixer.fader = f
+			mixer.mu.Unlock()
+		}
+	})
+	infos := []osc.MethodInfo{
+		{
+			Address:     "/ch/01/mix/fader",
+			TypeTag:     "f",
+			Description: "channel 1 fader",
+			Range:       []osc.Range{{Min: 0, Max: 1}},
+			Value: func() []interface{} {
+				mixer.mu.Lock()
+				defer mixer.mu.Unlock()
+				return []interface{}{mixer.fader}
+			},
+		},
+		{
+			Address: "/meters/1",
+			TypeTag: "i",
+			Value: func() []interface{} {
+				return []interface{}{int32(-42)}
+			},
+		},
+	}
+	for _, info := range infos {
+		if err := d.Describe(info); err != nil {
+			t.Fatalf("error describing %s: %s", info.Address, err)
+		}
+	}
+	return &Server{
+		HostInfo:   HostInfo{Name: "test mixer", OSCPort: 10023, OSCTransport: "UDP"},
+		Dispatcher: d,
+	}, &mixer
+}
+
+func TestServerHTTP(t *testing.T) {
+	s, _ := newTestServer(t)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	var tests = []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{
+			"/", http.StatusOK,
+			`{"FULL_PATH":"/","CONTENTS":{` +
+				`"ch":{"FULL_PATH":"/ch","CONTENTS":{"01":{"FULL_PATH":"/ch/01","CONTENTS":{"mix":{"FULL_PATH":"/ch/01/mix","CONTENTS":{` +
+				`"fader":{"FULL_PATH":"/ch/01/mix/fader","TYPE":"f","ACCESS":3,"VALUE":[0],"RANGE":[{"MIN":0,"MAX":1}],"DESCRIPTION":"channel 1 fader"}}}}}}},` +
+				`"meters":{"FULL_PATH":"/meters","CONTENTS":{"1":{"FULL_PATH":"/meters/1","TYPE":"i","ACCESS":1,"VALUE":[-42]}}}}}`,
+		},
+		{
+			"/meters/1", http.StatusOK,
+			`{"FULL_PATH":"/meters/1","TYPE":"i","ACCESS":1,"VALUE":[-42]}`,
+		},
+		{"/ch/01/mix/fader?VALUE", http.StatusOK, `{"VALUE":[0]}`},
+		{"/ch/01/mix/fader?ACCESS", http.StatusOK, `{"ACCESS":3}`},
+		{"/ch?VALUE", http.StatusNoContent, ""},
+		{"/ch/01/mix/fader?BOGUS", http.StatusBadRequest, "unsupported attribute BOGUS\n"},
+		{"/ch/02", http.StatusNotFound, "404 page not found\n"},
+		{
+			"/?HOST_INFO", http.StatusOK,
+			`{"NAME":"test mixer","EXTENSIONS":{"ACCESS":true,"CLIPMODE":false,"CRITICAL":false,` +
+				`"DESCRIPTION":true,"EXTENDED_TYPE":false,"LISTEN":true,"PATH_CHANGED":false,"RANGE":true,` +
+				`"TAGS":false,"UNIT":false,"VALUE":true},"OSC_PORT":10023,"OSC_TRANSPORT":"UDP"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + test.path)
+			if err != nil {
+				t.Fatalf("error getting: %s", err)
+			}
+			defer resp.Body.Close()
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("error reading: %s", err)
+			}
+			if resp.StatusCode != test.status {
+				t.Errorf("\t got = %d\n\t\t\twant = %d", resp.StatusCode, test.status)
+			}
+			if string(b) != test.want {
+				t.Errorf("\t got = %s\n\t\t\twant = %s", b, test.want)
+			}
+		})
+	}
+}
+
+func TestServerListen(t *testing.T) {
+	s, mixer := newTestServer(t)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	c, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer c.Close()
+	if err := c.WriteMessage(websocket.TextMessage, []byte(`{"COMMAND":"LISTEN","DATA":"/ch/01/mix/fader"}`)); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+
+	// The fader is set over the WebSocket so that the LISTEN command is
+	// known to have been handled first.
+	set := &osc.Msg{Address: "/ch/*/mix/fader", TypeTag: "f", Args: []interface{}{float32(0.5)}}
+	b, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error encoding: %s", err)
+	}
+	if err := c.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	typ, data, err := c.ReadMessage()
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+	p, err := osc.ParsePacket(data)
+	if err != nil || typ != websocket.BinaryMessage {
+		t.Fatalf("expected binary OSC message, got %d %q", typ, data)
+	}
+	if got, want := p.String(), "/ch/01/mix/fader ,f 0.5"; got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+	mixer.mu.Lock()
+	defer mixer.mu.Unlock()
+	if mixer.fader != 0.5 {
+		t.Errorf("\t got = %g\n\t\t\twant = %g", mixer.fader, 0.5)
+	}
+}
+
+func TestServerListenLater(t *testing.T) {
+	s, mixer := newTestServer(t)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	c, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer c.Close()
+	if err := c.WriteMessage(websocket.TextMessage, []byte(`{"COMMAND":"LISTEN","DATA":"/ch/01/mix/fader"}`)); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+
+	// The listener is sent the value set by the bundle once it is
+	// dispatched, not the value before.
+	later := &osc.Bundle{Time: osc.NewTimeTag(time.Now().Add(100 * time.Millisecond)), Packets: []osc.Packet{
+		&osc.Msg{Address: "/ch/01/mix/fader", TypeTag: "f", Args: []interface{}{float32(0.25)}},
+	}}
+	b, err := later.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error encoding: %s", err)
+	}
+	if err := c.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+	p, err := osc.ParsePacket(data)
+	if err != nil {
+		t.Fatalf("error decoding: %s", err)
+	}
+	if got, want := p.String(), "/ch/01/mix/fader ,f 0.25"; got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+	mixer.mu.Lock()
+	defer mixer.mu.Unlock()
+	if mixer.fader != 0.25 {
+		t.Errorf("\t got = %g\n\t\t\twant = %g", mixer.fader, 0.25)
+	}
+}
+
+func TestServerListenOrigin(t *testing.T) {
+	s, _ := newTestServer(t)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	for origin, want := range map[string]int{
+		srv.URL:                   http.StatusSwitchingProtocols,
+		"http://evil.example.com": http.StatusForbidden,
+	} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if err != nil {
+			t.Fatalf("error creating request: %s", err)
+		}
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		req.Header.Set("Origin", origin)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("error requesting upgrade: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s:\t got = %d\n\t\t\twant = %d", origin, resp.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+/*
+Package osctest provides utilities for testing code that talks to Open Sound
+Control (OSC) devices without a network.
+
+A Device is a fake device at the end of an in-memory osc.Pipe. The code under
+test is given the other end, the Device records the messages it receives and
+replies to them as scripted, and the test checks the messages with
+ExpectMessage:
+
+	dev, conn := osctest.NewDevice(t)
+	dev.Reply("/info", &osc.Msg{Address: "/info", TypeTag: "ssss",
+		Args: []interface{}{"V2.05", "osc-server", "X32", "4.06"}})
+	mixer := x32.NewMixer(conn)
+	mixer.MuteChannel(1)
+	dev.ExpectMessage("/ch/01/mix/on", 0)
+*/
+package osctest
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// DefaultTimeout is how long ExpectMessage waits for a message by default.
+const DefaultTimeout = time.Second
+
+// Device is a fake OSC device for tests.
+type Device struct {
+	// Timeout is how long ExpectMessage waits for a message.
+	Timeout time.Duration
+
+	t    testing.TB
+	conn osc.Conn
+	done chan struct{}
+
+	mu       sync.Mutex
+	received []*osc.Msg
+	next     int // index of the next message for ExpectMessage
+	arrived  chan struct{}
+	scripts  []script
+}
+
+// script replies to the messages that match a pattern.
+type script struct {
+	pattern string
+	reply   func(m *osc.Msg) []osc.Packet
+}
+
+// NewDevice starts a fake device and returns it with the connection to it.
+// The device is closed when the test finishes.
+func NewDevice(t testing.TB) (*Device, osc.Conn) {
+	conn, deviceConn := osc.Pipe()
+	d := &Device{
+		Timeout: DefaultTimeout,
+		t:       t,
+		conn:    deviceConn,
+		done:    make(chan struct{}),
+		arrived: make(chan struct{}, 1),
+	}
+	go d.serve()
+	t.Cleanup(func() {
+		d.Close()
+		conn.Close()
+	})
+	return d, conn
+}
+
+func (d *Device) serve() {
+	defer close(d.done)
+	for {
+		b, _, err := d.conn.ReadPacket()
+		if err != nil {
+			return
+		}
+		p, err := osc.ParsePacket(b)
+		if err != nil {
+			d.t.Errorf("osctest: device received invalid packet %q: %s", b, err)
+			continue
+		}
+		for _, m := range messages(p) {
+			d.receive(m)
+		}
+	}
+}
+
+// messages returns the messages of the packet in order.
+func messages(p osc.Packet) []*osc.Msg {
+	switch p := p.(type) {
+	case *osc.Msg:
+		return []*osc.Msg{p}
+	case *osc.Bundle:
+		var msgs []*osc.Msg
+		for _, elem := range p.Packets {
+			msgs = append(msgs, messages(elem)...)
+		}
+		return msgs
+	}
+	return nil
+}
+
+func (d *Device) receive(m *osc.Msg) {
+	d.mu.Lock()
+	d.received = append(d.received, m)
+	var reply func(m *osc.Msg) []osc.Packet
+	for _, s := range d.scripts {
+		if osc.Match(s.pattern, m.Address) {
+			reply = s.reply
+			break
+		}
+	}
+	d.mu.Unlock()
+	select {
+	case d.arrived <- struct{}{}:
+	default:
+	}
+	if reply == nil {
+		return
+	}
+	for _, p := range reply(m) {
+		if err := d.Send(p); err != nil {
+			return
+		}
+	}
+}
+
+// Reply scripts the device to reply with the packets to the messages whose
+// address matches the OSC address pattern. The first script whose pattern
+// matches a message replies to it.
+func (d *Device) Reply(pattern string, replies ...osc.Packet) {
+	d.ReplyFunc(pattern, func(m *osc.Msg) []osc.Packet {
+		return replies
+	})
+}
+
+// ReplyFunc scripts the device to reply to the messages whose address matches
+// the OSC address pattern with the packets returned by f.
+func (d *Device) ReplyFunc(pattern string, f func(m *osc.Msg) []osc.Packet) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.scripts = append(d.scripts, script{pattern, f})
+}
+
+// Send sends the packet from the device, e.g. to simulate a value changed on
+// the device itself.
+func (d *Device) Send(p osc.Packet) error {
+	b, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return d.conn.WritePacket(b)
+}
+
+// Messages returns the messages received so far, with the messages of bundles
+// in order.
+func (d *Device) Messages() []*osc.Msg {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*osc.Msg(nil), d.received...)
+}
+
+// ExpectMessage waits for the next message received by the device and
+// reports a test error, showing the differences, unless it has the address
+// and the arguments. Go ints match i and h arguments, and float64s match f
+// and d arguments, of the same value; other arguments must have the Go type
+// decoded by osc.ParseMessage. ExpectMessage returns the message, or nil if
+// none arrived before the Timeout.
+func (d *Device) ExpectMessage(addr string, args ...interface{}) *osc.Msg {
+	d.t.Helper()
+	m := d.nextMessage(d.Timeout)
+	if m == nil {
+		d.t.Errorf("osctest: timed out waiting for message\n\twant = %s", formatWant(addr, args))
+		return nil
+	}
+	if diff := Diff(m, addr, args...); diff != "" {
+		d.t.Errorf("osctest: unexpected message\n%s", diff)
+	}
+	return m
+}
+
+// ExpectNoMessage reports a test error if the device receives a message
+// within the duration.
+func (d *Device) ExpectNoMessage(timeout time.Duration) {
+	d.t.Helper()
+	if m := d.nextMessage(timeout); m != nil {
+		d.t.Errorf("osctest: unexpected message %s", m)
+	}
+}
+
+// nextMessage waits up to the timeout for the next message for
+// ExpectMessage.
+func (d *Device) nextMessage(timeout time.Duration) *osc.Msg {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		d.mu.Lock()
+		if d.next < len(d.received) {
+			m := d.received[d.next]
+			d.next++
+			d.mu.Unlock()
+			return m
+		}
+		d.mu.Unlock()
+		select {
+		case <-d.arrived:
+		case <-timer.C:
+			return nil
+		}
+	}
+}
+
+// Close stops the device.
+func (d *Device) Close() error {
+	err := d.conn.Close()
+	<-d.done
+	return err
+}
+
+// Diff returns a readable description of the differences between the
+// message and the address and arguments, as compared by ExpectMessage, or ""
+// if there are none.
+func Diff(m *osc.Msg, addr string, args ...interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\t got = %s\n\twant = %s", m, formatWant(addr, args))
+	same := true
+	if m.Address != addr {
+		same = false
+		fmt.Fprintf(&b, "\n\taddress: got %s, want %s", m.Address, addr)
+	}
+	if len(m.Args) != len(args) {
+		same = false
+		fmt.Fprintf(&b, "\n\targuments: got %d, want %d", len(m.Args), len(args))
+	}
+	for i := 0; i < len(m.Args) && i < len(args); i++ {
+		if !argEqual(m.Args[i], args[i]) {
+			same = false
+			fmt.Fprintf(&b, "\n\targument %d: got %T(%v), want %T(%v)", i, m.Args[i], m.Args[i], args[i], args[i])
+		}
+	}
+	if same {
+		return ""
+	}
+	return b.String()
+}
+
+// formatWant formats an expected message.
+func formatWant(addr string, args []interface{}) string {
+	var b strings.Builder
+	b.WriteString(addr)
+	for _, arg := range args {
+		fmt.Fprintf(&b, " %#v", arg)
+	}
+	return b.String()
+}
+
+// argEqual reports whether a decoded argument matches the expected one.
+func argEqual(got, want interface{}) bool {
+	switch w := want.(type) {
+	case int:
+		switch g := got.(type) {
+		case int32:
+			return int(g) == w
+		case int64:
+			return g == int64(w)
+		}
+		return false
+	case float64:
+		switch g := got.(type) {
+		case float32:
+			return g == float32(w) || math.IsNaN(float64(g)) && math.IsNaN(w)
+		case float64:
+			return g == w || math.IsNaN(g) && math.IsNaN(w)
+		}
+		return false
+	}
+	return reflect.DeepEqual(got, want)
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osctest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+)
+
+// recordingT records the errors reported by a Device.
+type recordingT struct {
+	testing.TB
+	mu     sync.Mutex
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) reported() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.Join(t.errors, "\n")
+}
+
+func TestDevice(t *testing.T) {
+	dev, conn := NewDevice(t)
+	dev.Reply("/info", &osc.Msg{Address: "/info", TypeTag: "ss", Args: []interface{}{"V2.05", "X32"}})
+	dev.ReplyFunc("/ch/*/mix/fader", func(m *osc.Msg) []osc.Packet {
+		return []osc.Packet{&osc.Msg{Address: m.Address, TypeTag: "f", Args: []interface{}{float32(0.5)}}}
+	})
+
+	send := func(text string) {
+		t.Helper()
+		p, err := osc.ParseText(text)
+		if err != nil {
+			t.Fatalf("error parsing %s: %s", text, err)
+		}
+		b, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatalf("error encoding %s: %s", text, err)
+		}
+		if err := conn.WritePacket(b); err != nil {
+			t.Fatalf("error writing %s: %s", text, err)
+		}
+	}
+	receive := func(want string) {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		b, _, err := conn.ReadPacket()
+		if err != nil {
+			t.Fatalf("error reading: %s", err)
+		}
+		p, err := osc.ParsePacket(b)
+		if err != nil {
+			t.Fatalf("error decoding: %s", err)
+		}
+		if got := p.String(); got != want {
+			t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+		}
+	}
+
+	send("/info")
+	receive(`/info ,ss "V2.05" "X32"`)
+	dev.ExpectMessage("/info")
+	send("#bundle immediately { /ch/01/mix/on ,i 0 ; /ch/02/mix/fader ,f 0.75 }")
+	receive("/ch/02/mix/fader ,f 0.5")
+	dev.ExpectMessage("/ch/01/mix/on", 0)
+	dev.ExpectMessage("/ch/02/mix/fader", 0.75)
+	dev.ExpectNoMessage(10 * time.Millisecond)
+
+	// Messages sent by the device reach the connection.
+	if err := dev.Send(&osc.Msg{Address: "/xremote"}); err != nil {
+		t.Fatalf("error sending: %s", err)
+	}
+	receive("/xremote")
+
+	if got := len(dev.Messages()); got != 3 {
+		t.Errorf("\t got = %d\n\t\t\twant = %d", got, 3)
+	}
+}
+
+func TestDeviceErrors(t *testing.T) {
+	rt := &recordingT{TB: t}
+	dev, conn := NewDevice(rt)
+	dev.Timeout = 10 * time.Millisecond
+	m := &osc.Msg{Address: "/ch/01/mix/on", TypeTag: "i", Args: []interface{}{int32(1)}}
+	b, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error encoding: %s", err)
+	}
+	conn.WritePacket(b)
+	dev.ExpectMessage("/ch/01/mix/on", "off")
+	dev.ExpectMessage("/ch/02/mix/on", 0)
+	want := "osctest: unexpected message\n" +
+		"\t got = /ch/01/mix/on ,i 1\n" +
+		"\twant = /ch/01/mix/on \"off\"\n" +
+		"\targument 0: got int32(1), want string(off)\n" +
+		"osctest: timed out waiting for message\n" +
+		"\twant = /ch/02/mix/on 0"
+	if got := rt.reported(); got != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", got, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	m := &osc.Msg{Address: "/a", TypeTag: "ihfds", Args: []interface{}{int32(1), int64(2), float32(0.5), 0.25, "x"}}
+	var tests = []struct {
+		addr string
+		args []interface{}
+		want string
+	}{
+		{"/a", []interface{}{1, 2, 0.5, 0.25, "x"}, ""},
+		{"/a", []interface{}{int32(1), int64(2), float32(0.5), 0.25, "x"}, ""},
+		{
+			"/b", []interface{}{1, 2, 0.5, 0.25},
+			"\t got = /a ,ihfds 1 2 0.5 0.25 \"x\"\n\twant = /b 1 2 0.5 0.25\n" +
+				"\taddress: got /a, want /b\n\targuments: got 5, want 4",
+		},
+		{
+			"/a", []interface{}{int64(1), 2, 0.5, 0.25, "x"},
+			"\t got = /a ,ihfds 1 2 0.5 0.25 \"x\"\n\twant = /a 1 2 0.5 0.25 \"x\"\n" +
+				"\targument 0: got int32(1), want int64(1)",
+		},
+	}
+	for _, test := range tests {
+		if got := Diff(m, test.addr, test.args...); got != test.want {
+			t.Errorf("\t got = %q\n\t\t\twant = %q", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+/*
+Package oscws bridges Open Sound Control (OSC) over WebSocket so that browsers,
+which cannot send UDP, can control OSC devices through a Go service.
+
+Each binary WebSocket message carries one encoded OSC packet. Text messages
+carry one packet in the JSON representation of osc.Msg.MarshalJSON. Browsers
+may send either; the bridge sends binary messages unless the WebSocket URL has
+the query parameter format=json.
+*/
+package oscws
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/goaudiovideo/osc"
+	"github.com/goaudiovideo/osc/internal/websocket"
+)
+
+// Bridge is an http.Handler that accepts WebSocket connections from browsers
+// and bridges them to OSC. Packets received from browsers are passed to the
+// Handler and written to every Upstream writer. Packets passed to Broadcast,
+// or to ServeOSC, are sent to every connected browser.
+type Bridge struct {
+	// Handler handles the packets received from browsers, typically the
+	// osc.Dispatcher also used by an osc.Server listening on UDP.
+	Handler osc.Handler
+
+	// Upstream receives the encoded packets received from browsers, one
+	// packet per Write, typically osc.Clients connected to devices.
+	Upstream []io.Writer
+
+	// ErrorLog logs packets that cannot be decoded or forwarded. If nil, they
+	// are logged using the log package's standard logger.
+	ErrorLog *log.Logger
+
+	// CheckOrigin reports whether a WebSocket connection is accepted from
+	// the Origin header of its request. If nil, only the pages served from
+	// the bridge's own host, and clients other than browsers, are accepted,
+	// so that other web sites cannot control the devices through the
+	// browsers of users. Mismatched origins get a 403 Forbidden reply.
+	CheckOrigin func(r *http.Request) bool
+
+	mu    sync.Mutex
+	conns map[*conn]struct{}
+}
+
+// conn is a browser connected to the bridge.
+type conn struct {
+	ws   *websocket.Conn
+	json bool
+}
+
+// Addr is the network address of a browser connected to a Bridge, which is
+// passed to the Handler along with the packets the browser sends.
+type Addr string
+
+// Network implements the net.Addr interface for Addr.
+func (a Addr) Network() string {
+	return "ws"
+}
+
+// String implements the net.Addr interface for Addr.
+func (a Addr) String() string {
+	return string(a)
+}
+
+// ServeHTTP implements the http.Handler interface for Bridge.
+func (b *Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := websocket.Upgrade(w, r, b.CheckOrigin)
+	if err != nil {
+		return
+	}
+	c := &conn{ws: ws, json: r.URL.Query().Get("format") == "json"}
+	b.mu.Lock()
+	if b.conns == nil {
+		b.conns = make(map[*conn]struct{})
+	}
+	b.conns[c] = struct{}{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, c)
+		b.mu.Unlock()
+		ws.Close()
+	}()
+
+	from := Addr(r.RemoteAddr)
+	for {
+		typ, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		var p osc.Packet
+		if typ == websocket.TextMessage {
+			p, err = osc.UnmarshalPacketJSON(data)
+			if err == nil {
+				data, err = p.MarshalBinary()
+			}
+		} else {
+			p, err = osc.ParsePacket(data)
+		}
+		if err != nil {
+			b.logf("oscws: decoding packet from %s: %s", from, err)
+			continue
+		}
+		if b.Handler != nil {
+			b.Handler.ServeOSC(p, from)
+		}
+		for _, up := range b.Upstream {
+			if _, err := up.Write(data); err != nil {
+				b.logf("oscws: forwarding packet from %s: %s", from, err)
+			}
+		}
+	}
+}
+
+// Broadcast sends the packet to every connected browser.
+func (b *Bridge) Broadcast(p osc.Packet) error {
+	bin, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	var text []byte
+	b.mu.Lock()
+	conns := make([]*conn, 0, len(b.conns))
+	for c := range b.conns {
+		conns = append(conns, c)
+		if c.json && text == nil {
+			if text, err = json.Marshal(p); err != nil {
+				b.mu.Unlock()
+				return err
+			}
+		}
+	}
+	b.mu.Unlock()
+	for _, c := range conns {
+		if c.json {
+			err = c.ws.WriteMessage(websocket.TextMessage, text)
+		} else {
+			err = c.ws.WriteMessage(websocket.BinaryMessage, bin)
+		}
+		if err != nil {
+			b.logf("oscws: sending packet to %s: %s", c.ws.RemoteAddr(), err)
+		}
+	}
+	return nil
+}
+
+// ServeOSC implements the osc.Handler interface for Bridge by broadcasting the
+// packet, so that a Bridge can handle the packets an osc.Server receives from
+// devices.
+func (b *Bridge) ServeOSC(p osc.Packet, from net.Addr) {
+	if err := b.Broadcast(p); err != nil {
+		b.logf("oscws: broadcasting packet from %s: %s", from, err)
+	}
+}
+
+// Relay broadcasts the replies received by the connection, typically one of
+// the Upstream writers, until reading from it fails.
+func (b *Bridge) Relay(c osc.Conn) error {
+	for {
+		data, from, err := c.ReadPacket()
+		if err != nil {
+			return err
+		}
+		p, err := osc.ParsePacket(data)
+		if err != nil {
+			b.logf("oscws: decoding packet from %s: %s", from, err)
+			continue
+		}
+		b.ServeOSC(p, from)
+	}
+}
+
+func (b *Bridge) logf(format string, args ...interface{}) {
+	if b.ErrorLog != nil {
+		b.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package oscws
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goaudiovideo/osc"
+	"github.com/goaudiovideo/osc/internal/websocket"
+)
+
+func TestBridge(t *testing.T) {
+	// The upstream device is a UDP socket that replies to /info.
+	device, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	defer device.Close()
+	upstream, err := osc.Dial("udp", device.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer upstream.Close()
+
+	dispatched := make(chan *osc.Msg, 2)
+	var d osc.Dispatcher
+	d.Handle("/ch/01/mix/fader", func(m *osc.Msg, from net.Addr) {
+		if _, ok := from.(Addr); !ok {
+			t.Errorf("got address %T, want Addr", from)
+		}
+		dispatched <- m
+	})
+	b := &Bridge{
+		Handler:  &d,
+		Upstream: []io.Writer{upstream},
+		ErrorLog: log.New(ioutil.Discard, "", 0),
+	}
+	go b.Relay(upstream)
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	bin, err := websocket.Dial(ctx, url)
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer bin.Close()
+	text, err := websocket.Dial(ctx, url+"?format=json")
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer text.Close()
+
+	want := "/ch/01/mix/fader\x00\x00\x00\x00,f\x00\x00\x3f\x40\x00\x00"
+	if err := bin.WriteMessage(websocket.BinaryMessage, []byte("garbage")); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	if err := bin.WriteMessage(websocket.BinaryMessage, []byte(want)); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	json := `{"address":"/ch/01/mix/fader","typeTag":"f","args":[0.75]}`
+	if err := text.WriteMessage(websocket.TextMessage, []byte(json)); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case m := <-dispatched:
+			if got := m.String(); got != "/ch/01/mix/fader ,f 0.75" {
+				t.Errorf("\t got = %s\n\t\t\twant = %s", got, "/ch/01/mix/fader ,f 0.75")
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for dispatch")
+		}
+		buf := make([]byte, 64)
+		device.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := device.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("error reading upstream: %s", err)
+		}
+		if got := string(buf[:n]); got != want {
+			t.Errorf("\t got = %q\n\t\t\twant = %q", got, want)
+		}
+	}
+
+	// Replies from the device reach both browsers in their format.
+	reply := "/info\x00\x00\x00,s\x00\x00V2.05\x00\x00\x00"
+	if _, err := device.WriteTo([]byte(reply), upstream.LocalAddr()); err != nil {
+		t.Fatalf("error replying: %s", err)
+	}
+	var tests = []struct {
+		name string
+		ws   *websocket.Conn
+		typ  int
+		want string
+	}{
+		{"binary", bin, websocket.BinaryMessage, reply},
+		{"json", text, websocket.TextMessage, `{"address":"/info","typeTag":"s","args":["V2.05"]}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.ws.SetReadDeadline(time.Now().Add(time.Second))
+			typ, data, err := test.ws.ReadMessage()
+			if err != nil {
+				t.Fatalf("error reading: %s", err)
+			}
+			if typ != test.typ || string(data) != test.want {
+				t.Errorf("\t got = %d %q\n\t\t\twant = %d %q", typ, data, test.typ, test.want)
+			}
+		})
+	}
+}
+
+func TestBridgeOrigin(t *testing.T) {
+	var tests = []struct {
+		name        string
+		checkOrigin func(r *http.Request) bool
+		origin      string
+		want        int
+	}{
+		{"foreign", nil, "http://evil.example.com", http.StatusForbidden},
+		{"same", nil, "", http.StatusSwitchingProtocols},
+		{"allowed", func(r *http.Request) bool {
+			return r.Header.Get("Origin") == "http://console.local"
+		}, "http://console.local", http.StatusSwitchingProtocols},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := &Bridge{CheckOrigin: test.checkOrigin, ErrorLog: log.New(ioutil.Discard, "", 0)}
+			srv := httptest.NewServer(b)
+			defer srv.Close()
+			req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if err != nil {
+				t.Fatalf("error creating request: %s", err)
+			}
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Sec-WebSocket-Version", "13")
+			req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+			origin := test.origin
+			if origin == "" {
+				origin = srv.URL
+			}
+			req.Header.Set("Origin", origin)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("error requesting upgrade: %s", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != test.want {
+				t.Errorf("\t got = %d\n\t\t\twant = %d", resp.StatusCode, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,447 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Packet is an OSC message or bundle.
+type Packet interface {
+	// MarshalBinary encodes the packet into its OSC wire format.
+	MarshalBinary() ([]byte, error)
+
+	// String returns the packet in the text syntax read by ParseText.
+	String() string
+}
+
+// Msg models an OSC message. Args holds one argument for each type tag other
+// than the array brackets '[' and ']'. Decoded arguments have the following
+// Go types:
+//
+//	i  int32      h  int64      f  float32     d  float64
+//	s  string     S  string     b  []byte      t  TimeTag
+//	c  rune       r  RGBA       m  MIDI        T  true
+//	F  false      N  nil        I  nil
+//
+// When encoding, any Go integer type is accepted for i, h, c and r, a TimeTag,
+// uint64 or time.Time for t, either float type for f and d, and nil or the
+// matching bool for T and F.
+//
+// NewMessage and Arguments convert to and from Args, which carry their type
+// tag.
+type Msg struct {
+	Address string
+	TypeTag string
+	Args    []interface{}
+}
+
+// Bundle models an OSC bundle. The packets are to be dispatched at the given
+// time, or immediately if Time is Immediately.
+type Bundle struct {
+	Time    TimeTag
+	Packets []Packet
+}
+
+// RGBA is a 32-bit RGBA color (type tag r).
+type RGBA uint32
+
+// MIDI is a MIDI message (type tag m): the port id, status byte, and two data
+// bytes.
+type MIDI [4]byte
+
+// TimeTag is an OSC time tag: the number of seconds since midnight on January
+// 1, 1900 in the upper 32 bits and fractions of a second in the lower 32
+// bits, as in NTP.
+type TimeTag uint64
+
+// Immediately is the special time tag that means "now".
+const Immediately TimeTag = 1
+
+// ntpEpochOffset is the number of seconds between the NTP and Unix epochs.
+const ntpEpochOffset = 2208988800
+
+// NewTimeTag returns the time tag for the given time.
+func NewTimeTag(t time.Time) TimeTag {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return TimeTag(secs<<32 | frac)
+}
+
+// Time returns the time of the time tag.
+func (tt TimeTag) Time() time.Time {
+	secs := int64(tt>>32) - ntpEpochOffset
+	nanos := (uint64(tt&0xffffffff)*uint64(time.Second) + 1<<31) >> 32
+	return time.Unix(secs, int64(nanos))
+}
+
+// String implements the Stringer interface for TimeTag.
+func (tt TimeTag) String() string {
+	if tt == Immediately {
+		return "immediately"
+	}
+	return tt.Time().UTC().Format(time.RFC3339Nano)
+}
+
+// ParsePacket decodes an OSC message or bundle.
+func ParsePacket(b []byte) (Packet, error) {
+	if bytes.HasPrefix(b, []byte(bundleTag+"\x00")) {
+		return ParseBundle(b)
+	}
+	return ParseMessage(b)
+}
+
+// ParseMessage decodes an OSC message.
+func ParseMessage(b []byte) (*Msg, error) {
+	addr, rest, err := readString(b)
+	if err != nil {
+		return nil, fmt.Errorf("reading address: %w", err)
+	}
+	if !strings.HasPrefix(addr, "/") {
+		return nil, fmt.Errorf("invalid address %q", addr)
+	}
+	m := &Msg{Address: addr}
+	// Very old implementations omit the type tag string when there are no
+	// arguments.
+	if len(rest) == 0 {
+		return m, nil
+	}
+	typeTag, rest, err := readString(rest)
+	if err != nil {
+		return nil, fmt.Errorf("reading type tag: %w", err)
+	}
+	if !strings.HasPrefix(typeTag, ",") {
+		return nil, fmt.Errorf("invalid type tag %q", typeTag)
+	}
+	m.TypeTag = typeTag[1:]
+	if err := checkArrays(m.TypeTag); err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(m.TypeTag); i++ {
+		var arg interface{}
+		arg, rest, err = readArg(m.TypeTag[i], rest)
+		if err == errArray {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading argument %c: %w", m.TypeTag[i], err)
+		}
+		m.Args = append(m.Args, arg)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%d unexpected bytes after arguments", len(rest))
+	}
+	return m, nil
+}
+
+// ParseBundle decodes an OSC bundle.
+func ParseBundle(b []byte) (*Bundle, error) {
+	tag, rest, err := readString(b)
+	if err != nil || tag != bundleTag {
+		return nil, errors.New("missing #bundle tag")
+	}
+	if len(rest) < 8 {
+		return nil, errShort
+	}
+	bundle := &Bundle{Time: TimeTag(binary.BigEndian.Uint64(rest))}
+	rest = rest[8:]
+	for len(rest) > 0 {
+		if len(rest) < 4 {
+			return nil, errShort
+		}
+		size := binary.BigEndian.Uint32(rest)
+		rest = rest[4:]
+		if size%4 != 0 || uint64(size) > uint64(len(rest)) {
+			return nil, fmt.Errorf("invalid bundle element size %d", size)
+		}
+		p, err := ParsePacket(rest[:size])
+		if err != nil {
+			return nil, err
+		}
+		bundle.Packets = append(bundle.Packets, p)
+		rest = rest[size:]
+	}
+	return bundle, nil
+}
+
+var (
+	errShort = errors.New("packet too short")
+	errArray = errors.New("array bracket")
+)
+
+// readString reads an OSC-string, returning it and the bytes that follow it.
+func readString(b []byte) (string, []byte, error) {
+	i := bytes.IndexByte(b, 0)
+	if i < 0 {
+		return "", nil, errors.New("unterminated string")
+	}
+	n := i + 1 + numZeroBytes(i+1)
+	if n > len(b) {
+		return "", nil, errShort
+	}
+	return string(b[:i]), b[n:], nil
+}
+
+// readArg reads the argument for the type tag, returning it and the bytes
+// that follow it. It returns errArray for array brackets.
+func readArg(tag byte, b []byte) (interface{}, []byte, error) {
+	switch tag {
+	case 'i', 'f', 'c', 'r', 'm':
+		if len(b) < 4 {
+			return nil, nil, errShort
+		}
+		u := binary.BigEndian.Uint32(b)
+		var arg interface{}
+		switch tag {
+		case 'i':
+			arg = int32(u)
+		case 'f':
+			arg = math.Float32frombits(u)
+		case 'c':
+			arg = rune(u)
+		case 'r':
+			arg = RGBA(u)
+		case 'm':
+			arg = MIDI{b[0], b[1], b[2], b[3]}
+		}
+		return arg, b[4:], nil
+	case 'h', 'd', 't':
+		if len(b) < 8 {
+			return nil, nil, errShort
+		}
+		u := binary.BigEndian.Uint64(b)
+		var arg interface{}
+		switch tag {
+		case 'h':
+			arg = int64(u)
+		case 'd':
+			arg = math.Float64frombits(u)
+		case 't':
+			arg = TimeTag(u)
+		}
+		return arg, b[8:], nil
+	case 's', 'S':
+		return readString(b)
+	case 'b':
+		if len(b) < 4 {
+			return nil, nil, errShort
+		}
+		size := uint64(binary.BigEndian.Uint32(b))
+		n := size + uint64(numZeroBytes(int(size%4)))
+		if 4+n > uint64(len(b)) {
+			return nil, nil, errShort
+		}
+		blob := make([]byte, size)
+		copy(blob, b[4:])
+		return blob, b[4+n:], nil
+	case 'T':
+		return true, b, nil
+	case 'F':
+		return false, b, nil
+	case 'N', 'I':
+		return nil, b, nil
+	case '[', ']':
+		return nil, b, errArray
+	}
+	return nil, nil, fmt.Errorf("unknown type tag %c", tag)
+}
+
+// checkArrays checks that the array brackets in the type tag are balanced.
+func checkArrays(typeTag string) error {
+	depth := 0
+	for _, c := range typeTag {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unbalanced ] in type tag %q", typeTag)
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced [ in type tag %q", typeTag)
+	}
+	return nil
+}
+
+// numArgs returns the number of arguments the type tag requires.
+func numArgs(typeTag string) int {
+	return len(typeTag) - strings.Count(typeTag, "[") - strings.Count(typeTag, "]")
+}
+
+// MarshalBinary implements the BinaryMarshaler interface for Msg.
+func (m *Msg) MarshalBinary() ([]byte, error) {
+	if !strings.HasPrefix(m.Address, "/") {
+		return nil, fmt.Errorf("invalid address %q", m.Address)
+	}
+	if err := checkArrays(m.TypeTag); err != nil {
+		return nil, err
+	}
+	if n := numArgs(m.TypeTag); n != len(m.Args) {
+		return nil, fmt.Errorf("type tag %q needs %d arguments, got %d", m.TypeTag, n, len(m.Args))
+	}
+	b := appendString(nil, m.Address)
+	b = appendString(b, ","+m.TypeTag)
+	args := m.Args
+	for i := 0; i < len(m.TypeTag); i++ {
+		tag := m.TypeTag[i]
+		if tag == '[' || tag == ']' {
+			continue
+		}
+		var err error
+		b, err = appendArg(b, tag, args[0])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d of %s: %w", len(m.Args)-len(args), m.Address, err)
+		}
+		args = args[1:]
+	}
+	return b, nil
+}
+
+// MarshalBinary implements the BinaryMarshaler interface for Bundle.
+func (bundle *Bundle) MarshalBinary() ([]byte, error) {
+	b := appendString(nil, bundleTag)
+	b = appendUint64(b, uint64(bundle.Time))
+	for _, p := range bundle.Packets {
+		elem, err := p.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = appendUint32(b, uint32(len(elem)))
+		b = append(b, elem...)
+	}
+	return b, nil
+}
+
+// appendString appends the OSC-string.
+func appendString(b []byte, s string) []byte {
+	b = append(b, s...)
+	b = append(b, 0)
+	return addZeroBytes(b)
+}
+
+func appendUint32(b []byte, u uint32) []byte {
+	return append(b, byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
+
+func appendUint64(b []byte, u uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(u>>32)), uint32(u))
+}
+
+// appendArg appends the argument encoded for the type tag.
+func appendArg(b []byte, tag byte, arg interface{}) ([]byte, error) {
+	switch tag {
+	case 'i', 'c':
+		n, ok := toInt64(arg)
+		if !ok || n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("cannot encode %T %v as %c", arg, arg, tag)
+		}
+		return appendUint32(b, uint32(n)), nil
+	case 'r':
+		n, ok := toInt64(arg)
+		if !ok || n < 0 || n > math.MaxUint32 {
+			return nil, fmt.Errorf("cannot encode %T %v as r", arg, arg)
+		}
+		return appendUint32(b, uint32(n)), nil
+	case 'h':
+		n, ok := toInt64(arg)
+		if !ok {
+			return nil, fmt.Errorf("cannot encode %T as h", arg)
+		}
+		return appendUint64(b, uint64(n)), nil
+	case 't':
+		switch v := arg.(type) {
+		case TimeTag:
+			return appendUint64(b, uint64(v)), nil
+		case uint64:
+			return appendUint64(b, v), nil
+		case time.Time:
+			return appendUint64(b, uint64(NewTimeTag(v))), nil
+		}
+	case 'f':
+		switch v := arg.(type) {
+		case float32:
+			return appendUint32(b, math.Float32bits(v)), nil
+		case float64:
+			return appendUint32(b, math.Float32bits(float32(v))), nil
+		}
+	case 'd':
+		switch v := arg.(type) {
+		case float32:
+			return appendUint64(b, math.Float64bits(float64(v))), nil
+		case float64:
+			return appendUint64(b, math.Float64bits(v)), nil
+		}
+	case 's', 'S':
+		if v, ok := arg.(string); ok {
+			if strings.IndexByte(v, 0) >= 0 {
+				return nil, errors.New("string contains a zero byte")
+			}
+			return appendString(b, v), nil
+		}
+	case 'b':
+		if v, ok := arg.([]byte); ok {
+			b = appendUint32(b, uint32(len(v)))
+			b = append(b, v...)
+			return addZeroBytes(b), nil
+		}
+	case 'm':
+		switch v := arg.(type) {
+		case MIDI:
+			return append(b, v[:]...), nil
+		case [4]byte:
+			return append(b, v[:]...), nil
+		}
+	case 'T', 'F':
+		if v, ok := arg.(bool); arg == nil || (ok && v == (tag == 'T')) {
+			return b, nil
+		}
+	case 'N', 'I':
+		if arg == nil {
+			return b, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown type tag %c", tag)
+	}
+	return nil, fmt.Errorf("cannot encode %T as %c", arg, tag)
+}
+
+// toInt64 converts any Go integer to an int64.
+func toInt64(arg interface{}) (int64, bool) {
+	switch v := arg.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), v <= math.MaxInt64
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	case RGBA:
+		return int64(v), true
+	}
+	return 0, false
+}
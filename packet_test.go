@@ -0,0 +1,166 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseMessage(t *testing.T) {
+	var tests = []struct {
+		name  string
+		given string
+		want  *Msg
+	}{
+		{"no type tag", "/info\x00\x00\x00", &Msg{Address: "/info"}},
+		{"no args", "/info\x00\x00\x00,\x00\x00\x00", &Msg{Address: "/info"}},
+		{
+			"info reply", "/info\x00\x00\x00,ssss\x00\x00\x00V2.05\x00\x00\x00osc-server\x00\x00X32\x004.06\x00\x00\x00\x00",
+			&Msg{"/info", "ssss", []interface{}{"V2.05", "osc-server", "X32", "4.06"}},
+		},
+		{
+			"fader", "/ch/01/eq/1/q\x00\x00\x00,f\x00\x00\x3e\xed\xfa\x44",
+			&Msg{"/ch/01/eq/1/q", "f", []interface{}{float32(0.4648)}},
+		},
+		{
+			"all types",
+			"/all\x00\x00\x00\x00,ihdSbtcrmTFNI[i]\x00\x00\x00" +
+				"\xff\xff\xff\xfe" +
+				"\x00\x00\x00\x01\x00\x00\x00\x00" +
+				"\x3f\xf8\x00\x00\x00\x00\x00\x00" +
+				"sym\x00" +
+				"\x00\x00\x00\x05hello\x00\x00\x00" +
+				"\x00\x00\x00\x00\x00\x00\x00\x01" +
+				"\x00\x00\x00\x61" +
+				"\xff\x00\x80\xff" +
+				"\x00\x90\x3c\x7f" +
+				"\x00\x00\x00\x07",
+			&Msg{"/all", "ihdSbtcrmTFNI[i]", []interface{}{
+				int32(-2), int64(1 << 32), 1.5, "sym", []byte("hello"), Immediately,
+				'a', RGBA(0xff0080ff), MIDI{0, 0x90, 0x3c, 0x7f}, true, false, nil, nil, int32(7),
+			}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseMessage([]byte(test.given))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("\t got = %#v\n\t\t\twant = %#v", got, test.want)
+			}
+			if test.want.TypeTag == "" {
+				return
+			}
+			b, err := got.MarshalBinary()
+			if err != nil {
+				t.Fatalf("unexpected error encoding: %s", err)
+			}
+			if string(b) != test.given {
+				t.Errorf("\t got = %q\n\t\t\twant = %q", b, test.given)
+			}
+		})
+	}
+}
+
+func TestParseMessageErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		given string
+	}{
+		{"empty", ""},
+		{"no address", "info\x00\x00\x00\x00"},
+		{"unterminated address", "/info"},
+		{"bad type tag", "/info\x00\x00\x00ssss\x00\x00\x00\x00"},
+		{"missing int", "/a\x00\x00,i\x00\x00"},
+		{"short blob", "/a\x00\x00,b\x00\x00\x00\x00\x00\x08abcd"},
+		{"unknown tag", "/a\x00\x00,x\x00\x00\x00\x00\x00\x00"},
+		{"unbalanced array", "/a\x00\x00,[i\x00\x00\x00\x00\x00\x01"},
+		{"trailing bytes", "/a\x00\x00,\x00\x00\x00\x00\x00\x00\x01"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ParseMessage([]byte(test.given)); err == nil {
+				t.Errorf("expected error parsing %q", test.given)
+			}
+		})
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	given := &Bundle{
+		Time: TimeTag(0xdeadbeef00000001),
+		Packets: []Packet{
+			&Msg{"/ch/01/mix/on", "i", []interface{}{int32(0)}},
+			&Bundle{Immediately, []Packet{&Msg{"/ch/02/mix/on", "i", []interface{}{int32(1)}}}},
+		},
+	}
+	b, err := given.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+	want := "#bundle\x00\xde\xad\xbe\xef\x00\x00\x00\x01" +
+		"\x00\x00\x00\x18/ch/01/mix/on\x00\x00\x00,i\x00\x00\x00\x00\x00\x00" +
+		"\x00\x00\x00\x2c#bundle\x00\x00\x00\x00\x00\x00\x00\x00\x01" +
+		"\x00\x00\x00\x18/ch/02/mix/on\x00\x00\x00,i\x00\x00\x00\x00\x00\x01"
+	if string(b) != want {
+		t.Errorf("\t got = %q\n\t\t\twant = %q", b, want)
+	}
+	got, err := ParsePacket(b)
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	if !reflect.DeepEqual(got, given) {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", got, given)
+	}
+}
+
+func TestMarshalBinaryErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		msg  *Msg
+	}{
+		{"bad address", &Msg{"info", "", nil}},
+		{"too few args", &Msg{"/a", "ii", []interface{}{1}}},
+		{"too many args", &Msg{"/a", "i", []interface{}{1, 2}}},
+		{"int32 overflow", &Msg{"/a", "i", []interface{}{int64(1 << 40)}}},
+		{"wrong type", &Msg{"/a", "s", []interface{}{1}}},
+		{"wrong bool", &Msg{"/a", "T", []interface{}{false}}},
+		{"zero byte", &Msg{"/a", "s", []interface{}{"a\x00b"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.msg.MarshalBinary(); err == nil {
+				t.Errorf("expected error encoding %v", test.msg)
+			}
+		})
+	}
+}
+
+func TestTimeTag(t *testing.T) {
+	var tests = []struct {
+		given time.Time
+		want  TimeTag
+	}{
+		{time.Unix(0, 0), TimeTag(ntpEpochOffset << 32)},
+		{time.Unix(1, int64(500*time.Millisecond)), TimeTag((ntpEpochOffset+1)<<32 | 1<<31)},
+		{time.Unix(1600000000, 123456789), NewTimeTag(time.Unix(1600000000, 123456789))},
+	}
+	for _, test := range tests {
+		t.Run(test.given.String(), func(t *testing.T) {
+			got := NewTimeTag(test.given)
+			if got != test.want {
+				t.Errorf("\t got = %x\n\t\t\twant = %x", got, test.want)
+			}
+			if back := got.Time(); !back.Equal(test.given) {
+				t.Errorf("\t got = %s\n\t\t\twant = %s", back, test.given)
+			}
+		})
+	}
+}
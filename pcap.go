@@ -0,0 +1,181 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Link-layer header types found in pcap files.
+const (
+	linkTypeNull     = 0
+	linkTypeEthernet = 1
+	linkTypeRaw      = 101
+	linkTypeLinuxSLL = 113
+)
+
+// maxSnapLen is the largest snapshot length of the pcap files imported, that
+// used by default by tcpdump and Wireshark.
+const maxSnapLen = 262144
+
+// ImportPcap reads the UDP payloads captured in a pcap file (not pcapng) and
+// returns them as records. The port identifies the device the capture was
+// made against: datagrams to the port are recorded as sent to the device and
+// datagrams from the port as received from it, so that replaying the sent
+// records reproduces the session against the device. When port is zero every
+// UDP datagram is recorded as sent to its destination.
+func ImportPcap(r io.Reader, port int) ([]Record, error) {
+	var hdr [24]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("reading pcap header: %w", err)
+	}
+	var order binary.ByteOrder
+	var nano bool
+	switch {
+	case binary.BigEndian.Uint32(hdr[:4]) == 0xa1b2c3d4:
+		order = binary.BigEndian
+	case binary.LittleEndian.Uint32(hdr[:4]) == 0xa1b2c3d4:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(hdr[:4]) == 0xa1b23c4d:
+		order, nano = binary.BigEndian, true
+	case binary.LittleEndian.Uint32(hdr[:4]) == 0xa1b23c4d:
+		order, nano = binary.LittleEndian, true
+	case binary.LittleEndian.Uint32(hdr[:4]) == 0x0a0d0d0a:
+		return nil, errors.New("pcapng files are not supported")
+	default:
+		return nil, errors.New("not a pcap file")
+	}
+	linkType := order.Uint32(hdr[20:]) & 0x0fffffff
+	snapLen := order.Uint32(hdr[16:])
+	if snapLen == 0 || snapLen > maxSnapLen {
+		snapLen = maxSnapLen
+	}
+
+	var records []Record
+	for {
+		var rh [16]byte
+		if _, err := io.ReadFull(r, rh[:]); err != nil {
+			if err == io.EOF {
+				return records, nil
+			}
+			return records, fmt.Errorf("reading pcap record: %w", err)
+		}
+		frac := time.Duration(order.Uint32(rh[4:]))
+		if !nano {
+			frac *= time.Microsecond
+		}
+		ts := time.Unix(int64(order.Uint32(rh[:4])), int64(frac))
+		n := order.Uint32(rh[8:])
+		if n > snapLen {
+			return records, fmt.Errorf("pcap record of %d bytes exceeds snapshot length %d", n, snapLen)
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return records, fmt.Errorf("reading pcap record: %w", noEOF(err))
+		}
+		d, ok := udpDatagram(linkType, data)
+		if !ok {
+			continue
+		}
+		rec := Record{Time: ts, Packet: d.payload}
+		switch {
+		case port == 0 || d.dstPort == port:
+			rec.Direction = Sent
+			rec.Peer = net.JoinHostPort(d.dst.String(), strconv.Itoa(d.dstPort))
+		case d.srcPort == port:
+			rec.Direction = Received
+			rec.Peer = net.JoinHostPort(d.src.String(), strconv.Itoa(d.srcPort))
+		default:
+			continue
+		}
+		records = append(records, rec)
+	}
+}
+
+type udpInfo struct {
+	src, dst         net.IP
+	srcPort, dstPort int
+	payload          []byte
+}
+
+// udpDatagram extracts the UDP datagram from a captured frame. Fragmented
+// IPv4 packets and IPv6 packets with extension headers are skipped.
+func udpDatagram(linkType uint32, frame []byte) (udpInfo, bool) {
+	var d udpInfo
+	var ip []byte
+	switch linkType {
+	case linkTypeNull:
+		if len(frame) < 4 {
+			return d, false
+		}
+		ip = frame[4:]
+	case linkTypeEthernet:
+		if len(frame) < 14 {
+			return d, false
+		}
+		etherType, rest := binary.BigEndian.Uint16(frame[12:]), frame[14:]
+		for etherType == 0x8100 && len(rest) >= 4 {
+			etherType, rest = binary.BigEndian.Uint16(rest[2:]), rest[4:]
+		}
+		if etherType != 0x0800 && etherType != 0x86dd {
+			return d, false
+		}
+		ip = rest
+	case linkTypeRaw:
+		ip = frame
+	case linkTypeLinuxSLL:
+		if len(frame) < 16 {
+			return d, false
+		}
+		ip = frame[16:]
+	default:
+		return d, false
+	}
+	if len(ip) < 1 {
+		return d, false
+	}
+
+	var udp []byte
+	switch ip[0] >> 4 {
+	case 4:
+		if len(ip) < 20 {
+			return d, false
+		}
+		ihl := int(ip[0]&0x0f) * 4
+		total := int(binary.BigEndian.Uint16(ip[2:]))
+		fragment := binary.BigEndian.Uint16(ip[6:]) & 0x3fff
+		if ip[9] != 17 || fragment != 0 || ihl < 20 || total < ihl || total > len(ip) {
+			return d, false
+		}
+		d.src, d.dst = net.IP(ip[12:16]), net.IP(ip[16:20])
+		udp = ip[ihl:total]
+	case 6:
+		if len(ip) < 40 || ip[6] != 17 {
+			return d, false
+		}
+		d.src, d.dst = net.IP(ip[8:24]), net.IP(ip[24:40])
+		udp = ip[40:]
+	default:
+		return d, false
+	}
+	if len(udp) < 8 {
+		return d, false
+	}
+	length := int(binary.BigEndian.Uint16(udp[4:]))
+	if length < 8 || length > len(udp) {
+		return d, false
+	}
+	d.srcPort = int(binary.BigEndian.Uint16(udp[0:]))
+	d.dstPort = int(binary.BigEndian.Uint16(udp[2:]))
+	d.payload = append([]byte(nil), udp[8:length]...)
+	return d, true
+}
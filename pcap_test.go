@@ -0,0 +1,111 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// pcapFrame builds an Ethernet frame carrying an IPv4 UDP datagram.
+func pcapFrame(src, dst [4]byte, srcPort, dstPort uint16, payload string) []byte {
+	udp := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint16(udp[0:], srcPort)
+	binary.BigEndian.PutUint16(udp[2:], dstPort)
+	binary.BigEndian.PutUint16(udp[4:], uint16(8+len(payload)))
+	udp = append(udp, payload...)
+
+	ip := make([]byte, 20, 20+len(udp))
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:], uint16(20+len(udp)))
+	ip[8] = 64
+	ip[9] = 17
+	copy(ip[12:], src[:])
+	copy(ip[16:], dst[:])
+	ip = append(ip, udp...)
+
+	eth := make([]byte, 14, 14+len(ip))
+	binary.BigEndian.PutUint16(eth[12:], 0x0800)
+	return append(eth, ip...)
+}
+
+func TestImportPcap(t *testing.T) {
+	client := [4]byte{192, 168, 1, 20}
+	console := [4]byte{192, 168, 1, 10}
+	frames := [][]byte{
+		pcapFrame(client, console, 50000, 10023, "/info\x00\x00\x00,\x00\x00\x00"),
+		pcapFrame(console, client, 10023, 50000, "/info\x00\x00\x00,s\x00\x00V2.05\x00\x00\x00"),
+		pcapFrame(client, [4]byte{10, 0, 0, 1}, 50001, 53, "dns"),
+	}
+
+	var b bytes.Buffer
+	hdr := make([]byte, 24)
+	binary.LittleEndian.PutUint32(hdr[0:], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(hdr[4:], 2)
+	binary.LittleEndian.PutUint16(hdr[6:], 4)
+	binary.LittleEndian.PutUint32(hdr[16:], 65535)
+	binary.LittleEndian.PutUint32(hdr[20:], 1)
+	b.Write(hdr)
+	for i, f := range frames {
+		rh := make([]byte, 16)
+		binary.LittleEndian.PutUint32(rh[0:], 1600000000)
+		binary.LittleEndian.PutUint32(rh[4:], uint32(i*1000))
+		binary.LittleEndian.PutUint32(rh[8:], uint32(len(f)))
+		binary.LittleEndian.PutUint32(rh[12:], uint32(len(f)))
+		b.Write(rh)
+		b.Write(f)
+	}
+
+	got, err := ImportPcap(&b, 10023)
+	if err != nil {
+		t.Fatalf("error importing pcap: %s", err)
+	}
+	want := []Record{
+		{time.Unix(1600000000, 0), Sent, "192.168.1.10:10023", []byte("/info\x00\x00\x00,\x00\x00\x00")},
+		{time.Unix(1600000000, int64(time.Millisecond)), Received, "192.168.1.10:10023", []byte("/info\x00\x00\x00,s\x00\x00V2.05\x00\x00\x00")},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("\t got = %d records\n\t\t\twant = %d records", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Time.Equal(want[i].Time) || got[i].Direction != want[i].Direction ||
+			got[i].Peer != want[i].Peer || string(got[i].Packet) != string(want[i].Packet) {
+			t.Errorf("record %d\n\t got = %v\n\t\t\twant = %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestImportPcapRecordTooLong(t *testing.T) {
+	hdr := make([]byte, 24+16)
+	binary.LittleEndian.PutUint32(hdr[0:], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint32(hdr[16:], 65535)
+	binary.LittleEndian.PutUint32(hdr[20:], 1)
+	binary.LittleEndian.PutUint32(hdr[24+8:], 0xffffffff)
+	binary.LittleEndian.PutUint32(hdr[24+12:], 0xffffffff)
+	if _, err := ImportPcap(bytes.NewReader(hdr), 0); err == nil {
+		t.Error("expected error importing pcap")
+	}
+}
+
+func TestImportPcapBadHeader(t *testing.T) {
+	var tests = []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"pcapng", append([]byte{0x0a, 0x0d, 0x0d, 0x0a}, make([]byte, 20)...)},
+		{"unknown", make([]byte, 24)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ImportPcap(bytes.NewReader(test.data), 0); err == nil {
+				t.Error("expected error importing pcap")
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Recordings hold the OSC traffic of a session so that it can be replayed
+// later. A recording starts with an 8-byte file header followed by any number
+// of records. All integers are big-endian, as they are in OSC itself.
+//
+// The file header is:
+//
+//	magic    6 bytes  "OSCREC"
+//	version  uint16   currently 1
+//
+// Each record in version 1 is:
+//
+//	time     int64    nanoseconds since the Unix epoch
+//	dir      uint8    1 = received, 2 = sent
+//	peerLen  uint8    length of peer
+//	peer     peerLen bytes, the remote address, e.g. "192.168.1.10:10023"
+//	size     uint32   length of packet, up to MaxRecordSize
+//	packet   size bytes, the raw OSC message or bundle
+const (
+	recordMagic   = "OSCREC"
+	RecordVersion = 1
+)
+
+// MaxRecordSize is the size of the largest packet that can be recorded, that
+// of the largest UDP datagram. It bounds the memory allocated for a record
+// read from a corrupt recording.
+const MaxRecordSize = 1<<16 - 1
+
+// Direction identifies whether a recorded packet was received from or sent
+// to the peer.
+type Direction uint8
+
+// Enum for record directions.
+const (
+	Received Direction = 1
+	Sent     Direction = 2
+)
+
+// String implements the Stringer interface for Direction.
+func (d Direction) String() string {
+	switch d {
+	case Received:
+		return "received"
+	case Sent:
+		return "sent"
+	}
+	return fmt.Sprintf("direction(%d)", uint8(d))
+}
+
+// Record models a single packet of a recorded OSC session.
+type Record struct {
+	Time      time.Time
+	Direction Direction
+	Peer      string
+	Packet    []byte
+}
+
+// Recorder writes OSC records to a writer. It is safe for concurrent use so
+// that both directions of a connection can be recorded at once.
+type Recorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewRecorder writes the file header to w and returns a Recorder that writes
+// records to it.
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	hdr := make([]byte, 0, 8)
+	hdr = append(hdr, recordMagic...)
+	hdr = append(hdr, 0, RecordVersion)
+	if _, err := w.Write(hdr); err != nil {
+		return nil, err
+	}
+	return &Recorder{w: w}, nil
+}
+
+// Record records the packet as received from or sent to the peer now.
+func (r *Recorder) Record(dir Direction, peer string, packet []byte) error {
+	return r.WriteRecord(Record{
+		Time:      time.Now(),
+		Direction: dir,
+		Peer:      peer,
+		Packet:    packet,
+	})
+}
+
+// WriteRecord writes the record.
+func (r *Recorder) WriteRecord(rec Record) error {
+	if rec.Direction != Received && rec.Direction != Sent {
+		return fmt.Errorf("invalid record direction %d", rec.Direction)
+	}
+	if len(rec.Peer) > 255 {
+		return fmt.Errorf("peer %s too long (255 char limit)", rec.Peer)
+	}
+	if len(rec.Packet) > MaxRecordSize {
+		return fmt.Errorf("packet too long (%d bytes)", len(rec.Packet))
+	}
+	b := make([]byte, 0, 14+len(rec.Peer)+len(rec.Packet))
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(rec.Time.UnixNano()))
+	b = append(b, n[:]...)
+	b = append(b, byte(rec.Direction), byte(len(rec.Peer)))
+	b = append(b, rec.Peer...)
+	binary.BigEndian.PutUint32(n[:4], uint32(len(rec.Packet)))
+	b = append(b, n[:4]...)
+	b = append(b, rec.Packet...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err := r.w.Write(b)
+	return err
+}
+
+// RecordReader reads OSC records from a recording.
+type RecordReader struct {
+	r *bufio.Reader
+}
+
+// NewRecordReader reads and verifies the file header from r and returns a
+// RecordReader that reads the records following it.
+func NewRecordReader(r io.Reader) (*RecordReader, error) {
+	br := bufio.NewReader(r)
+	var hdr [8]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return nil, fmt.Errorf("reading recording header: %w", err)
+	}
+	if string(hdr[:6]) != recordMagic {
+		return nil, errors.New("not an OSC recording")
+	}
+	if v := binary.BigEndian.Uint16(hdr[6:]); v != RecordVersion {
+		return nil, fmt.Errorf("unsupported recording version %d", v)
+	}
+	return &RecordReader{r: br}, nil
+}
+
+// Next returns the next record. It returns io.EOF when there are no more
+// records, io.ErrUnexpectedEOF if the recording ends within a record and an
+// error if the record is larger than MaxRecordSize.
+func (rr *RecordReader) Next() (Record, error) {
+	var rec Record
+	var hdr [10]byte
+	if _, err := io.ReadFull(rr.r, hdr[:]); err != nil {
+		return rec, err
+	}
+	rec.Time = time.Unix(0, int64(binary.BigEndian.Uint64(hdr[:8])))
+	rec.Direction = Direction(hdr[8])
+	peer := make([]byte, hdr[9])
+	if _, err := io.ReadFull(rr.r, peer); err != nil {
+		return rec, noEOF(err)
+	}
+	rec.Peer = string(peer)
+	var size [4]byte
+	if _, err := io.ReadFull(rr.r, size[:]); err != nil {
+		return rec, noEOF(err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > MaxRecordSize {
+		return rec, fmt.Errorf("record of %d bytes exceeds MaxRecordSize", n)
+	}
+	rec.Packet = make([]byte, n)
+	if _, err := io.ReadFull(rr.r, rec.Packet); err != nil {
+		return rec, noEOF(err)
+	}
+	return rec, nil
+}
+
+// noEOF converts io.EOF into io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Replay writes the packets of the records read from rr that have the given
+// direction to w, each as a single Write. The original spacing between the
+// records is kept, scaled by speed: 1 replays at the original speed, 2 twice
+// as fast, and 0 as fast as possible. Replay returns nil once all records
+// have been replayed or the context's error if it is done first.
+func Replay(ctx context.Context, w io.Writer, rr *RecordReader, dir Direction, speed float64) error {
+	if speed < 0 {
+		return fmt.Errorf("invalid replay speed %g", speed)
+	}
+	var first time.Time
+	start := time.Now()
+	for {
+		rec, err := rr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if rec.Direction != dir {
+			continue
+		}
+		if first.IsZero() {
+			first = rec.Time
+		}
+		if speed > 0 {
+			at := start.Add(time.Duration(float64(rec.Time.Sub(first)) / speed))
+			timer := time.NewTimer(time.Until(at))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := w.Write(rec.Packet); err != nil {
+			return err
+		}
+	}
+}
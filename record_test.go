@@ -0,0 +1,172 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	start := time.Unix(1600000000, 123456789)
+	records := []Record{
+		{start, Sent, "192.168.1.10:10023", []byte("/info\x00\x00\x00,\x00\x00\x00")},
+		{start.Add(3 * time.Millisecond), Received, "192.168.1.10:10023", []byte("/info\x00\x00\x00,s\x00\x00V2.05\x00\x00\x00")},
+		{start.Add(5 * time.Millisecond), Sent, "", nil},
+	}
+	var b bytes.Buffer
+	rec, err := NewRecorder(&b)
+	if err != nil {
+		t.Fatalf("error creating recorder: %s", err)
+	}
+	for _, r := range records {
+		if err := rec.WriteRecord(r); err != nil {
+			t.Fatalf("error writing record: %s", err)
+		}
+	}
+	if got := b.String()[:8]; got != "OSCREC\x00\x01" {
+		t.Errorf("\t got = %q\n\t\t\twant = %q", got, "OSCREC\x00\x01")
+	}
+
+	rr, err := NewRecordReader(&b)
+	if err != nil {
+		t.Fatalf("error creating record reader: %s", err)
+	}
+	for i, want := range records {
+		got, err := rr.Next()
+		if err != nil {
+			t.Fatalf("error reading record %d: %s", i, err)
+		}
+		if !got.Time.Equal(want.Time) || got.Direction != want.Direction ||
+			got.Peer != want.Peer || string(got.Packet) != string(want.Packet) {
+			t.Errorf("record %d\n\t got = %v\n\t\t\twant = %v", i, got, want)
+		}
+	}
+	if _, err := rr.Next(); err != io.EOF {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", err, io.EOF)
+	}
+}
+
+func TestRecordBadInput(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"bad magic", "OSCREX\x00\x01"},
+		{"bad version", "OSCREC\x00\x02"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewRecordReader(bytes.NewBufferString(test.data)); err == nil {
+				t.Errorf("expected error reading recording %q", test.data)
+			}
+		})
+	}
+
+	rr, err := NewRecordReader(bytes.NewBufferString("OSCREC\x00\x01\x00\x00"))
+	if err != nil {
+		t.Fatalf("error creating record reader: %s", err)
+	}
+	if _, err := rr.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", err, io.ErrUnexpectedEOF)
+	}
+
+	// A corrupt size must not allocate the packet.
+	rr, err = NewRecordReader(bytes.NewBufferString("OSCREC\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\xff\xff\xff\xff"))
+	if err != nil {
+		t.Fatalf("error creating record reader: %s", err)
+	}
+	if _, err := rr.Next(); err == nil || err == io.ErrUnexpectedEOF {
+		t.Errorf("expected size error, got %v", err)
+	}
+
+	rec, err := NewRecorder(io.Discard)
+	if err != nil {
+		t.Fatalf("error creating recorder: %s", err)
+	}
+	if err := rec.Record(Direction(0), "", nil); err == nil {
+		t.Error("expected error recording invalid direction")
+	}
+	if err := rec.Record(Sent, "", make([]byte, MaxRecordSize+1)); err == nil {
+		t.Error("expected error recording packet too long")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Now()
+	var b bytes.Buffer
+	rec, err := NewRecorder(&b)
+	if err != nil {
+		t.Fatalf("error creating recorder: %s", err)
+	}
+	records := []Record{
+		{start, Sent, "", []byte("first")},
+		{start.Add(10 * time.Millisecond), Received, "", []byte("reply")},
+		{start.Add(40 * time.Millisecond), Sent, "", []byte("second")},
+	}
+	for _, r := range records {
+		if err := rec.WriteRecord(r); err != nil {
+			t.Fatalf("error writing record: %s", err)
+		}
+	}
+	data := b.Bytes()
+
+	var tests = []struct {
+		name    string
+		speed   float64
+		minTime time.Duration
+	}{
+		{"original speed", 1, 40 * time.Millisecond},
+		{"double speed", 2, 20 * time.Millisecond},
+		{"fast as possible", 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr, err := NewRecordReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("error creating record reader: %s", err)
+			}
+			var r packetRecorder
+			began := time.Now()
+			if err := Replay(context.Background(), &r, rr, Sent, test.speed); err != nil {
+				t.Fatalf("error replaying: %s", err)
+			}
+			if elapsed := time.Since(began); elapsed < test.minTime {
+				t.Errorf("replay took %s, want at least %s", elapsed, test.minTime)
+			}
+			assertPackets(t, r.sent(), []string{"first", "second"})
+		})
+	}
+}
+
+func TestReplayCanceled(t *testing.T) {
+	var b bytes.Buffer
+	rec, err := NewRecorder(&b)
+	if err != nil {
+		t.Fatalf("error creating recorder: %s", err)
+	}
+	start := time.Now()
+	for _, d := range []time.Duration{0, time.Hour} {
+		if err := rec.WriteRecord(Record{start.Add(d), Sent, "", []byte("msg")}); err != nil {
+			t.Fatalf("error writing record: %s", err)
+		}
+	}
+	rr, err := NewRecordReader(&b)
+	if err != nil {
+		t.Fatalf("error creating record reader: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var r packetRecorder
+	if err := Replay(ctx, &r, rr, Sent, 1); err != context.DeadlineExceeded {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", err, context.DeadlineExceeded)
+	}
+	assertPackets(t, r.sent(), []string{"msg"})
+}
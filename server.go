@@ -0,0 +1,197 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+)
+
+// ErrServerClosed is returned by the Server's Serve, ServePacket and
+// ListenAndServe methods after a call to Close.
+var ErrServerClosed = errors.New("server closed")
+
+// Handler handles the OSC packets received by a Server.
+type Handler interface {
+	ServeOSC(p Packet, from net.Addr)
+}
+
+// HandlerFunc adapts an ordinary function to the Handler interface.
+type HandlerFunc func(p Packet, from net.Addr)
+
+// ServeOSC implements the Handler interface by calling f.
+func (f HandlerFunc) ServeOSC(p Packet, from net.Addr) {
+	f(p, from)
+}
+
+// Server receives OSC packets over UDP or TCP and passes them to its Handler.
+// Packets received over UDP are handled one at a time in the order they
+// arrive. Each TCP connection is handled in its own goroutine.
+type Server struct {
+	Handler Handler
+
+	// ErrorLog logs packets that cannot be decoded. If nil, they are logged
+	// using the log package's standard logger.
+	ErrorLog *log.Logger
+
+	// Observer, if not nil, observes the packets received.
+	Observer Observer
+
+	mu      sync.Mutex
+	closers map[io.Closer]struct{}
+	closed  bool
+}
+
+// ListenAndServe listens on the address of the named network, which must be
+// one of "udp", "udp4", "udp6", "tcp", "tcp4" or "tcp6", and serves the
+// packets received.
+func (s *Server) ListenAndServe(network, addr string) error {
+	switch {
+	case strings.HasPrefix(network, "udp"):
+		conn, err := net.ListenPacket(network, addr)
+		if err != nil {
+			return err
+		}
+		return s.ServePacket(conn)
+	case strings.HasPrefix(network, "tcp"):
+		l, err := net.Listen(network, addr)
+		if err != nil {
+			return err
+		}
+		return s.Serve(l)
+	}
+	return fmt.Errorf("unsupported network %s", network)
+}
+
+// ServePacket serves the packets received on the datagram connection until it
+// fails or the Server is closed. The connection is closed on return.
+func (s *Server) ServePacket(conn net.PacketConn) error {
+	if !s.track(conn) {
+		conn.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(conn)
+	b := make([]byte, maxDatagram)
+	for {
+		n, from, err := conn.ReadFrom(b)
+		if err != nil {
+			return s.serveErr(err)
+		}
+		s.handle(b[:n], from)
+	}
+}
+
+// Serve accepts TCP connections on the listener and serves the size-prefixed
+// packets received on each of them until the listener fails or the Server is
+// closed. The listener is closed on return.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.track(l) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(l)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return s.serveErr(err)
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	if !s.track(conn) {
+		conn.Close()
+		return
+	}
+	defer s.untrack(conn)
+	r := bufio.NewReader(conn)
+	for {
+		b, err := readFrame(r)
+		if err != nil {
+			if err != io.EOF && !s.isClosed() {
+				s.logf("osc: reading from %s: %s", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		s.handle(b, conn.RemoteAddr())
+	}
+}
+
+func (s *Server) handle(b []byte, from net.Addr) {
+	observe(s.Observer, Event{Kind: PacketReceived, Peer: from, Size: len(b)})
+	p, err := ParsePacket(b)
+	if err != nil {
+		observe(s.Observer, Event{Kind: DecodeError, Peer: from, Size: len(b), Err: err})
+		s.logf("osc: decoding packet from %s: %s", from, err)
+		return
+	}
+	if s.Handler != nil {
+		s.Handler.ServeOSC(p, from)
+	}
+}
+
+// Close closes all listeners and connections being served.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	var err error
+	for c := range s.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	s.closers = nil
+	return err
+}
+
+func (s *Server) track(c io.Closer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	if s.closers == nil {
+		s.closers = make(map[io.Closer]struct{})
+	}
+	s.closers[c] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(c io.Closer) {
+	s.mu.Lock()
+	delete(s.closers, c)
+	s.mu.Unlock()
+	c.Close()
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) serveErr(err error) error {
+	if s.isClosed() {
+		return ErrServerClosed
+	}
+	return err
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
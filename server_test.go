@@ -0,0 +1,146 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"io/ioutil"
+	"log"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServerAndClient(t *testing.T) {
+	var tests = []struct {
+		network string
+		listen  func(s *Server) (string, error)
+	}{
+		{"udp", func(s *Server) (string, error) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				return "", err
+			}
+			go s.ServePacket(conn)
+			return conn.LocalAddr().String(), nil
+		}},
+		{"tcp", func(s *Server) (string, error) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				return "", err
+			}
+			go s.Serve(l)
+			return l.Addr().String(), nil
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.network, func(t *testing.T) {
+			received := make(chan Packet, 1)
+			s := &Server{
+				Handler: HandlerFunc(func(p Packet, from net.Addr) {
+					received <- p
+				}),
+				ErrorLog: log.New(ioutil.Discard, "", 0),
+			}
+			addr, err := test.listen(s)
+			if err != nil {
+				t.Fatalf("error listening: %s", err)
+			}
+			defer s.Close()
+
+			c, err := Dial(test.network, addr)
+			if err != nil {
+				t.Fatalf("error dialing: %s", err)
+			}
+			defer c.Close()
+			// An undecodable packet is logged and skipped.
+			if _, err := c.Write([]byte("garbage")); err != nil {
+				t.Fatalf("error writing: %s", err)
+			}
+			want := &Bundle{Immediately, []Packet{
+				&Msg{"/ch/01/mix/fader", "f", []interface{}{float32(0.75)}},
+			}}
+			if err := c.Send(want); err != nil {
+				t.Fatalf("error sending: %s", err)
+			}
+			select {
+			case got := <-received:
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("\t got = %v\n\t\t\twant = %v", got, want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for packet")
+			}
+		})
+	}
+}
+
+func TestClientReceive(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	defer conn.Close()
+	c, err := Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer c.Close()
+
+	if err := c.WriteMessage("/info", ""); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	b := make([]byte, 64)
+	n, from, err := conn.ReadFrom(b)
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+	if got, want := string(b[:n]), "/info\x00\x00\x00,\x00\x00\x00"; got != want {
+		t.Errorf("\t got = %q\n\t\t\twant = %q", got, want)
+	}
+	reply := "/info\x00\x00\x00,s\x00\x00V2.05\x00\x00\x00"
+	if _, err := conn.WriteTo([]byte(reply), from); err != nil {
+		t.Fatalf("error replying: %s", err)
+	}
+	if err := c.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("error setting deadline: %s", err)
+	}
+	got, err := c.Receive()
+	if err != nil {
+		t.Fatalf("error receiving: %s", err)
+	}
+	want := &Msg{"/info", "s", []interface{}{"V2.05"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", got, want)
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	s := &Server{}
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ServePacket(conn)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := s.Close(); err != nil {
+		t.Fatalf("error closing: %s", err)
+	}
+	select {
+	case err := <-done:
+		if err != ErrServerClosed {
+			t.Errorf("\t got = %v\n\t\t\twant = %v", err, ErrServerClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for server to close")
+	}
+	if err := s.ListenAndServe("udp", "127.0.0.1:0"); err != ErrServerClosed {
+		t.Errorf("\t got = %v\n\t\t\twant = %v", err, ErrServerClosed)
+	}
+}
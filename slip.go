@@ -0,0 +1,118 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// SLIP special characters, see RFC 1055.
+const (
+	slipEnd    = 0xc0
+	slipEsc    = 0xdb
+	slipEscEnd = 0xdc
+	slipEscEsc = 0xdd
+)
+
+// slipConn is a Conn that frames packets with SLIP.
+type slipConn struct {
+	rwc io.ReadWriteCloser
+	r   *bufio.Reader
+	wmu sync.Mutex
+}
+
+// NewSLIPConn creates a Conn that frames the packets sent over a stream, such
+// as a serial port or a TCP connection, with the double-END SLIP encoding of
+// OSC 1.1. The address of the received packets is the stream's remote address
+// if it is a net.Conn, and nil otherwise.
+func NewSLIPConn(rwc io.ReadWriteCloser) Conn {
+	return &slipConn{rwc: rwc, r: bufio.NewReader(rwc)}
+}
+
+func (c *slipConn) WritePacket(p []byte) error {
+	b := make([]byte, 0, len(p)+len(p)/16+2)
+	b = append(b, slipEnd)
+	for _, x := range p {
+		switch x {
+		case slipEnd:
+			b = append(b, slipEsc, slipEscEnd)
+		case slipEsc:
+			b = append(b, slipEsc, slipEscEsc)
+		default:
+			b = append(b, x)
+		}
+	}
+	b = append(b, slipEnd)
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := c.rwc.Write(b)
+	return err
+}
+
+func (c *slipConn) ReadPacket() ([]byte, net.Addr, error) {
+	var b []byte
+	for {
+		x, err := c.r.ReadByte()
+		if err != nil {
+			if len(b) > 0 {
+				err = noEOF(err)
+			}
+			return nil, nil, err
+		}
+		switch x {
+		case slipEnd:
+			// Empty packets are the END of the previous packet followed by the
+			// END starting this one.
+			if len(b) > 0 {
+				return b, c.remoteAddr(), nil
+			}
+		case slipEsc:
+			x, err = c.r.ReadByte()
+			if err != nil {
+				return nil, nil, noEOF(err)
+			}
+			switch x {
+			case slipEscEnd:
+				b = append(b, slipEnd)
+			case slipEscEsc:
+				b = append(b, slipEsc)
+			default:
+				return nil, nil, fmt.Errorf("invalid SLIP escape 0x%02x", x)
+			}
+		default:
+			if len(b) >= maxStreamPacket {
+				return nil, nil, fmt.Errorf("packet size exceeds limit %d", maxStreamPacket)
+			}
+			b = append(b, x)
+		}
+	}
+}
+
+func (c *slipConn) remoteAddr() net.Addr {
+	if conn, ok := c.rwc.(net.Conn); ok {
+		return conn.RemoteAddr()
+	}
+	return nil
+}
+
+// SetReadDeadline sets the read deadline of the stream if it has one, as
+// net.Conns and os.Files do.
+func (c *slipConn) SetReadDeadline(t time.Time) error {
+	if d, ok := c.rwc.(interface{ SetReadDeadline(time.Time) error }); ok {
+		return d.SetReadDeadline(t)
+	}
+	return errors.New("SLIP stream does not support deadlines")
+}
+
+func (c *slipConn) Close() error {
+	return c.rwc.Close()
+}
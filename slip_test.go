@@ -0,0 +1,98 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+// nopCloser adds a no-op Close method to a ReadWriter.
+type nopCloser struct {
+	io.ReadWriter
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func TestSLIP(t *testing.T) {
+	var b bytes.Buffer
+	c := NewSLIPConn(nopCloser{&b})
+	packets := []string{
+		"/a\x00\x00,b\x00\x00\x00\x00\x00\x02\xc0\xdb\x00\x00",
+		"/b\x00\x00,\x00\x00\x00",
+	}
+	for _, p := range packets {
+		if err := c.WritePacket([]byte(p)); err != nil {
+			t.Fatalf("error writing: %s", err)
+		}
+	}
+	want := "\xc0/a\x00\x00,b\x00\x00\x00\x00\x00\x02\xdb\xdc\xdb\xdd\x00\x00\xc0" +
+		"\xc0/b\x00\x00,\x00\x00\x00\xc0"
+	if got := b.String(); got != want {
+		t.Errorf("\t got = %x\n\t\t\twant = %x", got, want)
+	}
+	for _, p := range packets {
+		got, from, err := c.ReadPacket()
+		if err != nil {
+			t.Fatalf("error reading: %s", err)
+		}
+		if string(got) != p || from != nil {
+			t.Errorf("\t got = %x %v\n\t\t\twant = %x <nil>", got, from, p)
+		}
+	}
+	if _, _, err := c.ReadPacket(); err != io.EOF {
+		t.Errorf("got error %v, want %v", err, io.EOF)
+	}
+}
+
+func TestSLIPErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"truncated", "\xc0/a\x00\x00", "unexpected EOF"},
+		{"truncated escape", "\xc0/a\xdb", "unexpected EOF"},
+		{"invalid escape", "\xc0/a\xdb\x00", "invalid SLIP escape 0x00"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewSLIPConn(nopCloser{bytes.NewBufferString(test.in)})
+			_, _, err := c.ReadPacket()
+			if err == nil || err.Error() != test.want {
+				t.Errorf("\t got = %v\n\t\t\twant = %s", err, test.want)
+			}
+		})
+	}
+	c := NewSLIPConn(nopCloser{&bytes.Buffer{}})
+	if err := c.SetReadDeadline(time.Time{}); err == nil {
+		t.Error("expected error setting deadline on a buffer")
+	}
+}
+
+func TestSLIPNetConn(t *testing.T) {
+	a, b := net.Pipe()
+	defer b.Close()
+	c := NewSLIPConn(a)
+	defer c.Close()
+	go func() {
+		b.Write([]byte("\xc0/a\x00\x00,\x00\x00\x00\xc0"))
+		io.Copy(ioutil.Discard, b)
+	}()
+	got, from, err := c.ReadPacket()
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+	if string(got) != "/a\x00\x00,\x00\x00\x00" || from != a.RemoteAddr() {
+		t.Errorf("got %q from %v", got, from)
+	}
+}
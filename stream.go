@@ -0,0 +1,43 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// maxStreamPacket limits the size of the packets read from stream
+// connections.
+const maxStreamPacket = 1 << 24
+
+// writeFrame writes the packet to a stream prefixed with its size, as
+// specified by OSC 1.0 for stream-oriented transports such as TCP.
+func writeFrame(w io.Writer, p []byte) error {
+	b := make([]byte, 0, 4+len(p))
+	b = appendUint32(b, uint32(len(p)))
+	b = append(b, p...)
+	_, err := w.Write(b)
+	return err
+}
+
+// readFrame reads a size-prefixed packet from a stream.
+func readFrame(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxStreamPacket {
+		return nil, fmt.Errorf("packet size %d exceeds limit", n)
+	}
+	p := make([]byte, n)
+	if _, err := io.ReadFull(r, p); err != nil {
+		return nil, noEOF(err)
+	}
+	return p, nil
+}
@@ -0,0 +1,432 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParseText parses an OSC message or bundle written in the text syntax that
+// Msg.String and Bundle.String produce.
+//
+// A message is an address followed by an optional type tag, starting with a
+// comma, and the arguments, all separated by white space:
+//
+//	/ch/01/mix/fader ,f 0.75
+//	/ch/01/config/name ,s "Kick In"
+//	/meters ,si "/meters/1" 5
+//
+// Arguments are formatted according to their type tag:
+//
+//	i h        decimal, or hexadecimal with a 0x prefix
+//	f d        decimal floating point
+//	s S        double-quoted Go string, or a word without spaces
+//	b          hexadecimal bytes with a 0x prefix
+//	t          "immediately", "now" or an RFC 3339 time
+//	c          single-quoted Go character
+//	r          hexadecimal RRGGBBAA color with a 0x prefix
+//	m          hexadecimal port, status and data bytes with a 0x prefix
+//	T F N I [ ]  no argument
+//
+// Without a type tag, the types are inferred: integers become i (or h if
+// they do not fit in 32 bits), other numbers f, true and false T and F, nil
+// N, single-quoted characters c, and anything else s.
+//
+// A bundle is the word #bundle, an optional time tag, and its packets
+// enclosed in braces and separated by semicolons:
+//
+//	#bundle now { /ch/01/mix/on ,i 0 ; /ch/02/mix/on ,i 0 }
+//
+// The braces must be separated from their neighbors by white space.
+func ParseText(s string) (Packet, error) {
+	toks, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &textParser{toks: toks}
+	pkt, err := p.packet()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %s after packet", p.peek().text)
+	}
+	return pkt, nil
+}
+
+type token struct {
+	text   string
+	quoted byte
+}
+
+// tokenize splits the text into white-space separated words, quoted strings
+// and characters, and semicolons.
+func tokenize(s string) ([]token, error) {
+	var toks []token
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			return toks, nil
+		}
+		switch s[0] {
+		case '"', '\'':
+			q := quotedPrefix(s)
+			text, err := strconv.Unquote(q)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted text %s", q)
+			}
+			toks = append(toks, token{text: text, quoted: s[0]})
+			s = s[len(q):]
+		case ';':
+			toks = append(toks, token{text: ";"})
+			s = s[1:]
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return unicode.IsSpace(r) || r == ';'
+			})
+			if end < 0 {
+				end = len(s)
+			}
+			toks = append(toks, token{text: s[:end]})
+			s = s[end:]
+		}
+	}
+}
+
+// quotedPrefix returns the quoted string at the start of s, up to and
+// including the closing quote, or all of s if the quote is not closed.
+func quotedPrefix(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case s[0]:
+			return s[:i+1]
+		}
+	}
+	return s
+}
+
+type textParser struct {
+	toks []token
+}
+
+func (p *textParser) done() bool {
+	return len(p.toks) == 0
+}
+
+func (p *textParser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.toks[0]
+}
+
+func (p *textParser) next() token {
+	t := p.peek()
+	if !p.done() {
+		p.toks = p.toks[1:]
+	}
+	return t
+}
+
+// atEnd reports whether the next token ends the current message.
+func (p *textParser) atEnd() bool {
+	t := p.peek()
+	return p.done() || (t.quoted == 0 && (t.text == ";" || t.text == "}"))
+}
+
+func (p *textParser) packet() (Packet, error) {
+	t := p.next()
+	if t.quoted == 0 && t.text == bundleTag {
+		return p.bundle()
+	}
+	if t.quoted != 0 || !strings.HasPrefix(t.text, "/") {
+		return nil, fmt.Errorf("expected address, got %q", t.text)
+	}
+	m := &Msg{Address: t.text}
+	if t := p.peek(); t.quoted == 0 && strings.HasPrefix(t.text, ",") {
+		p.next()
+		m.TypeTag = t.text[1:]
+		if err := checkArrays(m.TypeTag); err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(m.TypeTag); i++ {
+			tag := m.TypeTag[i]
+			switch tag {
+			case '[', ']':
+				continue
+			case 'T', 'F', 'N', 'I':
+				arg, _, err := readArg(tag, nil)
+				if err != nil {
+					return nil, err
+				}
+				m.Args = append(m.Args, arg)
+				continue
+			}
+			if p.atEnd() {
+				return nil, fmt.Errorf("missing argument for type tag %c", tag)
+			}
+			arg, err := parseArg(tag, p.next())
+			if err != nil {
+				return nil, err
+			}
+			m.Args = append(m.Args, arg)
+		}
+		if !p.atEnd() {
+			return nil, fmt.Errorf("unexpected argument %q", p.peek().text)
+		}
+		return m, nil
+	}
+	for !p.atEnd() {
+		tag, arg, err := inferArg(p.next())
+		if err != nil {
+			return nil, err
+		}
+		m.TypeTag += string(tag)
+		m.Args = append(m.Args, arg)
+	}
+	return m, nil
+}
+
+func (p *textParser) bundle() (Packet, error) {
+	b := &Bundle{Time: Immediately}
+	if t := p.next(); t.quoted != 0 || t.text != "{" {
+		tt, err := parseTimeTag(t.text)
+		if err != nil {
+			return nil, err
+		}
+		b.Time = tt
+		if t := p.next(); t.quoted != 0 || t.text != "{" {
+			return nil, fmt.Errorf("expected { after #bundle, got %q", t.text)
+		}
+	}
+	for {
+		if t := p.peek(); t.quoted == 0 && t.text == "}" {
+			p.next()
+			return b, nil
+		}
+		if p.done() {
+			return nil, errors.New("missing } at end of bundle")
+		}
+		pkt, err := p.packet()
+		if err != nil {
+			return nil, err
+		}
+		b.Packets = append(b.Packets, pkt)
+		if t := p.peek(); t.quoted == 0 && t.text == ";" {
+			p.next()
+		}
+	}
+}
+
+// parseArg parses the token as an argument for the type tag.
+func parseArg(tag byte, t token) (interface{}, error) {
+	var arg interface{}
+	var err error
+	switch tag {
+	case 'i':
+		var n int64
+		n, err = strconv.ParseInt(t.text, 0, 32)
+		arg = int32(n)
+	case 'h':
+		arg, err = strconv.ParseInt(t.text, 0, 64)
+	case 'f':
+		var f float64
+		f, err = strconv.ParseFloat(t.text, 32)
+		arg = float32(f)
+	case 'd':
+		arg, err = strconv.ParseFloat(t.text, 64)
+	case 's', 'S':
+		arg = t.text
+	case 'b':
+		arg, err = parseHex(t.text)
+	case 't':
+		arg, err = parseTimeTag(t.text)
+	case 'c':
+		r, size := utf8.DecodeRuneInString(t.text)
+		if size == 0 || size != len(t.text) {
+			err = errors.New("not a single character")
+		}
+		arg = r
+	case 'r':
+		var n uint64
+		n, err = strconv.ParseUint(t.text, 0, 32)
+		arg = RGBA(n)
+	case 'm':
+		var b []byte
+		b, err = parseHex(t.text)
+		if err == nil && len(b) != 4 {
+			err = errors.New("MIDI message is not 4 bytes")
+		}
+		var m MIDI
+		copy(m[:], b)
+		arg = m
+	default:
+		return nil, fmt.Errorf("unknown type tag %c", tag)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %c argument %q: %w", tag, t.text, err)
+	}
+	return arg, nil
+}
+
+// inferArg infers the type tag of the token and parses it.
+func inferArg(t token) (byte, interface{}, error) {
+	switch {
+	case t.quoted == '"':
+		return 's', t.text, nil
+	case t.quoted == '\'':
+		arg, err := parseArg('c', t)
+		return 'c', arg, err
+	case t.text == "true":
+		return 'T', true, nil
+	case t.text == "false":
+		return 'F', false, nil
+	case t.text == "nil":
+		return 'N', nil, nil
+	}
+	if n, err := strconv.ParseInt(t.text, 0, 32); err == nil {
+		return 'i', int32(n), nil
+	}
+	if n, err := strconv.ParseInt(t.text, 0, 64); err == nil {
+		return 'h', n, nil
+	}
+	if strings.IndexAny(t.text[:1], "0123456789+-.") == 0 {
+		if f, err := strconv.ParseFloat(t.text, 32); err == nil {
+			return 'f', float32(f), nil
+		}
+	}
+	return 's', t.text, nil
+}
+
+// parseHex parses hexadecimal bytes with a 0x prefix.
+func parseHex(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return nil, errors.New("missing 0x prefix")
+	}
+	return hex.DecodeString(s[2:])
+}
+
+// parseTimeTag parses "immediately", "now" or an RFC 3339 time.
+func parseTimeTag(s string) (TimeTag, error) {
+	switch s {
+	case "immediately":
+		return Immediately, nil
+	case "now":
+		return NewTimeTag(time.Now()), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time tag %q", s)
+	}
+	return NewTimeTag(t), nil
+}
+
+// String implements the Stringer interface for Msg using the text syntax read
+// by ParseText. Arguments that do not match their type tag are formatted with
+// the %v verb.
+func (m *Msg) String() string {
+	var sb strings.Builder
+	sb.WriteString(m.Address)
+	if m.TypeTag == "" && len(m.Args) == 0 {
+		return sb.String()
+	}
+	sb.WriteString(" ,")
+	sb.WriteString(m.TypeTag)
+	args := m.Args
+	for i := 0; i < len(m.TypeTag) && len(args) > 0; i++ {
+		tag := m.TypeTag[i]
+		switch tag {
+		case '[', ']':
+			continue
+		case 'T', 'F', 'N', 'I':
+			args = args[1:]
+			continue
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(formatArg(tag, args[0]))
+		args = args[1:]
+	}
+	return sb.String()
+}
+
+// formatArg formats the argument for the type tag.
+func formatArg(tag byte, arg interface{}) string {
+	switch tag {
+	case 'f':
+		switch v := arg.(type) {
+		case float32:
+			return strconv.FormatFloat(float64(v), 'g', -1, 32)
+		case float64:
+			return strconv.FormatFloat(v, 'g', -1, 32)
+		}
+	case 'd':
+		switch v := arg.(type) {
+		case float32:
+			return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		case float64:
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	case 's', 'S':
+		if v, ok := arg.(string); ok {
+			return strconv.Quote(v)
+		}
+	case 'b':
+		if v, ok := arg.([]byte); ok {
+			return "0x" + hex.EncodeToString(v)
+		}
+	case 't':
+		switch v := arg.(type) {
+		case TimeTag:
+			return v.String()
+		case time.Time:
+			return NewTimeTag(v).String()
+		}
+	case 'c':
+		if n, ok := toInt64(arg); ok {
+			return strconv.QuoteRune(rune(n))
+		}
+	case 'r':
+		if n, ok := toInt64(arg); ok {
+			return fmt.Sprintf("0x%08x", n)
+		}
+	case 'm':
+		switch v := arg.(type) {
+		case MIDI:
+			return "0x" + hex.EncodeToString(v[:])
+		case [4]byte:
+			return "0x" + hex.EncodeToString(v[:])
+		}
+	}
+	return fmt.Sprint(arg)
+}
+
+// String implements the Stringer interface for Bundle using the text syntax
+// read by ParseText.
+func (bundle *Bundle) String() string {
+	var sb strings.Builder
+	sb.WriteString(bundleTag)
+	sb.WriteByte(' ')
+	sb.WriteString(bundle.Time.String())
+	sb.WriteString(" {")
+	for i, p := range bundle.Packets {
+		if i > 0 {
+			sb.WriteString(" ;")
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(p.String())
+	}
+	sb.WriteString(" }")
+	return sb.String()
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseText(t *testing.T) {
+	var tests = []struct {
+		given string
+		want  Packet
+		text  string
+	}{
+		{"/info", &Msg{Address: "/info"}, "/info"},
+		{
+			"/ch/01/mix/fader ,f 0.75",
+			&Msg{"/ch/01/mix/fader", "f", []interface{}{float32(0.75)}},
+			"/ch/01/mix/fader ,f 0.75",
+		},
+		{
+			`/ch/01/config/name "Kick In"`,
+			&Msg{"/ch/01/config/name", "s", []interface{}{"Kick In"}},
+			`/ch/01/config/name ,s "Kick In"`,
+		},
+		{
+			"/infer 1 4294967296 -0.5 true false nil word 'x'",
+			&Msg{"/infer", "ihfTFNsc", []interface{}{
+				int32(1), int64(1 << 32), float32(-0.5), true, false, nil, "word", 'x',
+			}},
+			"/infer ,ihfTFNsc 1 4294967296 -0.5 \"word\" 'x'",
+		},
+		{
+			`/all ,ihfdsSbtcrmTFNI[i] 0x10 -3 1 2.5 a "b c" 0x0102 immediately '\n' 0xff0080ff 0x00903c7f 5`,
+			&Msg{"/all", "ihfdsSbtcrmTFNI[i]", []interface{}{
+				int32(16), int64(-3), float32(1), 2.5, "a", "b c", []byte{1, 2}, Immediately,
+				'\n', RGBA(0xff0080ff), MIDI{0, 0x90, 0x3c, 0x7f}, true, false, nil, nil, int32(5),
+			}},
+			`/all ,ihfdsSbtcrmTFNI[i] 16 -3 1 2.5 "a" "b c" 0x0102 immediately '\n' 0xff0080ff 0x00903c7f 5`,
+		},
+		{
+			"#bundle 2020-09-13T12:26:40Z { /ch/01/mix/on ,i 0; #bundle { /a 1 } ; /b }",
+			&Bundle{NewTimeTag(time.Unix(1600000000, 0)), []Packet{
+				&Msg{"/ch/01/mix/on", "i", []interface{}{int32(0)}},
+				&Bundle{Immediately, []Packet{&Msg{"/a", "i", []interface{}{int32(1)}}}},
+				&Msg{Address: "/b"},
+			}},
+			"#bundle 2020-09-13T12:26:40Z { /ch/01/mix/on ,i 0 ; #bundle immediately { /a ,i 1 } ; /b }",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.given, func(t *testing.T) {
+			got, err := ParseText(test.given)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("\t got = %#v\n\t\t\twant = %#v", got, test.want)
+			}
+			if s := got.String(); s != test.text {
+				t.Errorf("\t got = %s\n\t\t\twant = %s", s, test.text)
+			}
+			again, err := ParseText(got.String())
+			if err != nil {
+				t.Fatalf("unexpected error parsing %s: %s", got, err)
+			}
+			if !reflect.DeepEqual(again, test.want) {
+				t.Errorf("\t got = %#v\n\t\t\twant = %#v", again, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTextErrors(t *testing.T) {
+	var tests = []string{
+		"",
+		"info",
+		`"/info"`,
+		"/a ,i",
+		"/a ,i x",
+		"/a ,i 1 2",
+		"/a ,b 0102",
+		"/a ,m 0x0102",
+		"/a ,c ab",
+		`/a "unterminated`,
+		"#bundle { /a",
+		"#bundle yesterday { /a }",
+		"#bundle now /a }",
+		"/a 1 }",
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			if p, err := ParseText(test); err == nil {
+				t.Errorf("expected error parsing %q, got %v", test, p)
+			}
+		})
+	}
+}
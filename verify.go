@@ -0,0 +1,325 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default backoff of a Verifier.
+const (
+	DefaultMinBackoff = 50 * time.Millisecond
+	DefaultMaxBackoff = time.Second
+)
+
+// DefaultTolerance is the largest difference between float arguments that a
+// Verifier considers equal by default. It covers the quantization of devices
+// such as the X32, whose faders have 1024 steps.
+const DefaultTolerance = 1e-3
+
+// Verifier reliably sets parameters of devices that echo their state, such
+// as the X32, over unreliable transports such as UDP. It sets a parameter by
+// sending a message to its address, then queries it by sending a message with
+// no arguments to the same address, and retries with exponential backoff
+// until the device replies with the value set.
+//
+// The Verifier must receive the device's replies: either call Serve, which
+// reads them from the connection, or pass them to ServeOSC when the
+// connection is already read elsewhere.
+type Verifier struct {
+	// MinBackoff is how long to wait for the first reply. Each retry waits
+	// twice as long, up to MaxBackoff. If zero, DefaultMinBackoff and
+	// DefaultMaxBackoff are used.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Equal reports whether the arguments read back match the arguments set.
+	// If nil, integers and strings must be equal and floats must be within
+	// DefaultTolerance.
+	Equal func(got, want []interface{}) bool
+
+	// Handler, if not nil, is passed the packets read by Serve.
+	Handler Handler
+
+	conn Conn
+
+	mu      sync.Mutex
+	waiters map[string]map[chan *Msg]struct{}
+}
+
+// NewVerifier creates a Verifier that sends to the device over the
+// connection.
+func NewVerifier(conn Conn) *Verifier {
+	return &Verifier{conn: conn}
+}
+
+// Serve reads the packets from the connection, passing them to ServeOSC and
+// to the Handler, until reading fails.
+func (v *Verifier) Serve() error {
+	for {
+		b, from, err := v.conn.ReadPacket()
+		if err != nil {
+			return err
+		}
+		p, err := ParsePacket(b)
+		if err != nil {
+			continue
+		}
+		v.ServeOSC(p, from)
+		if v.Handler != nil {
+			v.Handler.ServeOSC(p, from)
+		}
+	}
+}
+
+// ServeOSC implements the Handler interface for Verifier by passing the
+// messages to the pending verifications of their addresses.
+func (v *Verifier) ServeOSC(p Packet, from net.Addr) {
+	switch p := p.(type) {
+	case *Msg:
+		v.mu.Lock()
+		for c := range v.waiters[p.Address] {
+			// Only the latest reply matters.
+			select {
+			case <-c:
+			default:
+			}
+			c <- p
+		}
+		v.mu.Unlock()
+	case *Bundle:
+		for _, elem := range p.Packets {
+			v.ServeOSC(elem, from)
+		}
+	}
+}
+
+// SetAndVerify sets the parameter at the address to the value and waits until
+// the device reports it, retrying until the context is done. The value is
+// either an Arg or a Go value from which its type tag is inferred: i for int
+// and int32, h for int64, f for float32 and float64, s for string, b for
+// []byte and T or F for bool. If the value cannot be verified, the returned
+// error is a *VerifyError.
+func (v *Verifier) SetAndVerify(ctx context.Context, addr string, value interface{}) error {
+	arg, err := verifyArg(value)
+	if err != nil {
+		return err
+	}
+	msg, err := NewMessage(addr, arg)
+	if err != nil {
+		return err
+	}
+	set, err := msg.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	query, err := (&Msg{Address: addr}).MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	c := make(chan *Msg, 1)
+	v.mu.Lock()
+	if v.waiters == nil {
+		v.waiters = make(map[string]map[chan *Msg]struct{})
+	}
+	if v.waiters[addr] == nil {
+		v.waiters[addr] = make(map[chan *Msg]struct{})
+	}
+	v.waiters[addr][c] = struct{}{}
+	v.mu.Unlock()
+	defer func() {
+		v.mu.Lock()
+		delete(v.waiters[addr], c)
+		if len(v.waiters[addr]) == 0 {
+			delete(v.waiters, addr)
+		}
+		v.mu.Unlock()
+	}()
+
+	want := []interface{}{arg.Value()}
+	backoff, maxBackoff := v.MinBackoff, v.MaxBackoff
+	if backoff <= 0 {
+		backoff, maxBackoff = DefaultMinBackoff, DefaultMaxBackoff
+	}
+	verr := &VerifyError{Address: addr, Value: arg}
+	for {
+		verr.Attempts++
+		if err := v.conn.WritePacket(set); err != nil {
+			verr.Err = err
+			return verr
+		}
+		if err := v.conn.WritePacket(query); err != nil {
+			verr.Err = err
+			return verr
+		}
+		timer := time.NewTimer(backoff)
+	wait:
+		for {
+			select {
+			case m := <-c:
+				verr.Got = m.Args
+				if v.equal(m.Args, want) {
+					timer.Stop()
+					return nil
+				}
+			case <-timer.C:
+				break wait
+			case <-ctx.Done():
+				timer.Stop()
+				verr.Err = ctx.Err()
+				return verr
+			}
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// Setting is the value of a parameter for SetAllAndVerify.
+type Setting struct {
+	Address string
+	Value   interface{}
+}
+
+// SetAllAndVerify sets and verifies the parameters concurrently, as
+// SetAndVerify does, and waits for them all. If any of them cannot be
+// verified, the returned error is a VerifyErrors listing them in order.
+func (v *Verifier) SetAllAndVerify(ctx context.Context, settings []Setting) error {
+	errs := make([]error, len(settings))
+	var wg sync.WaitGroup
+	for i, s := range settings {
+		wg.Add(1)
+		go func(i int, s Setting) {
+			defer wg.Done()
+			errs[i] = v.SetAndVerify(ctx, s.Address, s.Value)
+		}(i, s)
+	}
+	wg.Wait()
+	var failed VerifyErrors
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		verr, ok := err.(*VerifyError)
+		if !ok {
+			verr = &VerifyError{Address: settings[i].Address, Err: err}
+		}
+		failed = append(failed, verr)
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
+
+func (v *Verifier) equal(got, want []interface{}) bool {
+	if v.Equal != nil {
+		return v.Equal(got, want)
+	}
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		g, gok := toFloat64(got[i])
+		w, wok := toFloat64(want[i])
+		if gok && wok {
+			if math.Abs(g-w) > DefaultTolerance {
+				return false
+			}
+			continue
+		}
+		if n, ok := toInt64(got[i]); ok {
+			if m, ok := toInt64(want[i]); !ok || n != m {
+				return false
+			}
+			continue
+		}
+		if fmt.Sprint(got[i]) != fmt.Sprint(want[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// toFloat64 converts a Go float to a float64.
+func toFloat64(arg interface{}) (float64, bool) {
+	switch v := arg.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// verifyArg returns the Arg for a value passed to SetAndVerify.
+func verifyArg(value interface{}) (Arg, error) {
+	switch v := value.(type) {
+	case Arg:
+		return v, nil
+	case int:
+		return NewArg('i', v)
+	case int32:
+		return Int32(v), nil
+	case int64:
+		return Int64(v), nil
+	case float32:
+		return Float32(v), nil
+	case float64:
+		return Float32(float32(v)), nil
+	case string:
+		return String(v), nil
+	case []byte:
+		return Blob(v), nil
+	case bool:
+		return Bool(v), nil
+	}
+	return Arg{}, fmt.Errorf("cannot infer the type tag of %T", value)
+}
+
+// VerifyError reports a parameter that could not be verified.
+type VerifyError struct {
+	Address string
+	Value   Arg
+
+	// Got holds the arguments last read back, or nil if the device never
+	// replied.
+	Got []interface{}
+
+	Attempts int
+	Err      error
+}
+
+func (e *VerifyError) Error() string {
+	if e.Got == nil {
+		return fmt.Sprintf("verifying %s %s: no reply after %d attempts: %s", e.Address, e.Value, e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("verifying %s %s: read back %v after %d attempts: %s", e.Address, e.Value, e.Got, e.Attempts, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyErrors lists the parameters that could not be verified.
+type VerifyErrors []*VerifyError
+
+func (errs VerifyErrors) Error() string {
+	addrs := make([]string, len(errs))
+	for i, e := range errs {
+		addrs[i] = e.Address
+	}
+	return fmt.Sprintf("%d parameters not verified: %s", len(errs), strings.Join(addrs, ", "))
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2021 The goaudiovideo developers. All rights reserved.
+// Project site: https://github.com/goaudiovideo/osc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE file for the project.
+
+package osc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// echoDevice serves a parameter store on the connection, echoing the value of
+// a parameter when queried. It drops the first sets of each address, and
+// clamps the floats to 0.5.
+func echoDevice(conn Conn, drops int) {
+	state := make(map[string][]interface{})
+	dropped := make(map[string]int)
+	for {
+		b, _, err := conn.ReadPacket()
+		if err != nil {
+			return
+		}
+		m, err := ParseMessage(b)
+		if err != nil {
+			continue
+		}
+		if len(m.Args) > 0 {
+			if dropped[m.Address] < drops {
+				dropped[m.Address]++
+				continue
+			}
+			if f, ok := m.Args[0].(float32); ok && f > 0.5 {
+				m.Args[0] = float32(0.5)
+			}
+			state[m.Address] = m.Args
+			continue
+		}
+		if args, ok := state[m.Address]; ok {
+			reply := &Msg{Address: m.Address, Args: args}
+			for _, arg := range args {
+				reply.TypeTag += string(tagOf(arg))
+			}
+			b, _ := reply.MarshalBinary()
+			conn.WritePacket(b)
+		}
+	}
+}
+
+func tagOf(arg interface{}) byte {
+	switch arg.(type) {
+	case int32:
+		return 'i'
+	case float32:
+		return 'f'
+	}
+	return 's'
+}
+
+func TestSetAndVerify(t *testing.T) {
+	a, b := Pipe()
+	defer a.Close()
+	go echoDevice(b, 2)
+	v := NewVerifier(a)
+	v.MinBackoff, v.MaxBackoff = time.Millisecond, 4*time.Millisecond
+	go v.Serve()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, value := range []interface{}{1, float32(0.25), 0.4999, "Kick", Int32(0)} {
+		if err := v.SetAndVerify(ctx, "/ch/01/param", value); err != nil {
+			t.Errorf("%v: %s", value, err)
+		}
+	}
+	if _, err := verifyArg(struct{}{}); err == nil {
+		t.Error("expected error inferring the type tag of a struct")
+	}
+}
+
+func TestSetAllAndVerify(t *testing.T) {
+	a, b := Pipe()
+	defer a.Close()
+	go echoDevice(b, 1)
+	v := NewVerifier(a)
+	v.MinBackoff, v.MaxBackoff = time.Millisecond, 4*time.Millisecond
+	go v.Serve()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := v.SetAllAndVerify(ctx, []Setting{
+		{"/ch/01/mix/on", 1},
+		{"/ch/01/mix/fader", 0.75},
+		{"/ch/02/mix/fader", 0.25},
+		{"/ch/03/mix/fader", 1.0},
+	})
+	var errs VerifyErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected VerifyErrors, got %v", err)
+	}
+	if want := "2 parameters not verified: /ch/01/mix/fader, /ch/03/mix/fader"; err.Error() != want {
+		t.Errorf("\t got = %s\n\t\t\twant = %s", err, want)
+	}
+	e := errs[0]
+	if !errors.Is(e, context.DeadlineExceeded) || e.Attempts < 2 {
+		t.Errorf("unexpected error %v after %d attempts", e.Err, e.Attempts)
+	}
+	if len(e.Got) != 1 || e.Got[0] != float32(0.5) {
+		t.Errorf("\t got = %v\n\t\t\twant = [0.5]", e.Got)
+	}
+}